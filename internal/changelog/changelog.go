@@ -0,0 +1,291 @@
+// Package changelog generates change logs in Keep a Changelog format.
+// See https://keepachangelog.com/en/1.0.0
+package changelog
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/moorara/cherry/pkg/conventional"
+)
+
+const (
+	// Header is the beginning of a change log file.
+	Header = `# Changelog
+
+All notable changes to this project will be documented in this file.
+
+The format is based on [Keep a Changelog](https://keepachangelog.com/en/1.0.0),
+and this project adheres to [Semantic Versioning](https://semver.org/spec/v2.0.0.html).
+`
+
+	// Unreleased is the title of a section for the changes that are not released yet.
+	Unreleased = "Unreleased"
+)
+
+// Groups in the order they appear in a release section.
+const (
+	Added      = "Added"
+	Changed    = "Changed"
+	Deprecated = "Deprecated"
+	Removed    = "Removed"
+	Fixed      = "Fixed"
+	Security   = "Security"
+)
+
+var (
+	groups = []string{Added, Changed, Deprecated, Removed, Fixed, Security, ClosedIssues}
+
+	// groupByType maps conventional commit types to change log groups.
+	// Commit types not in this map (i.e. docs, test, chore, ci) are not included in a change log.
+	groupByType = map[string]string{
+		"feat":       Added,
+		"perf":       Changed,
+		"refactor":   Changed,
+		"revert":     Changed,
+		"deprecate":  Deprecated,
+		"deprecated": Deprecated,
+		"remove":     Removed,
+		"fix":        Fixed,
+		"security":   Security,
+	}
+)
+
+// Commit is a git commit.
+type Commit struct {
+	Hash    string
+	Message string
+}
+
+// Release is a set of commits, pull requests, and issues released together.
+type Release struct {
+	// Tag is the git tag for the release. An empty tag means the commits are not released yet.
+	Tag string
+	// PreviousTag is the git tag for the previous release. It is empty for the first release.
+	PreviousTag string
+	Date        time.Time
+	Commits     []Commit
+	Items       []Item
+}
+
+// Generator creates change log sections from git commits or GitHub pull requests and issues.
+type Generator struct {
+	// RepoURL is the web URL of the repository (i.e. https://github.com/moorara/cherry).
+	// If set, releases and commits are linked to the repository.
+	RepoURL string
+}
+
+// Section returns a Markdown section for a release.
+// Commits are grouped by their conventional commit types.
+// Commits not following Conventional Commits are considered as changes.
+// Pull requests and issues are grouped by their labels and credit their authors.
+func (g *Generator) Section(r Release) string {
+	entries := map[string][]string{}
+
+	for _, c := range r.Commits {
+		var group, entry string
+
+		if cc, err := conventional.Parse(c.Message); err != nil {
+			group = Changed
+			entry = strings.TrimSpace(strings.SplitN(strings.TrimSpace(c.Message), "\n", 2)[0])
+		} else if cc.Breaking {
+			// Breaking changes are always highlighted at the top of changed group
+			group = Changed
+			entry = "**BREAKING:** " + g.description(cc.Scope, cc.BreakingChange())
+		} else if group = groupByType[cc.Type]; group != "" {
+			entry = g.description(cc.Scope, cc.Description)
+		}
+
+		if group == "" || entry == "" {
+			continue
+		}
+
+		if c.Hash != "" {
+			entry += " " + g.commitLink(c.Hash)
+		}
+
+		if strings.HasPrefix(entry, "**BREAKING:**") {
+			entries[group] = append([]string{entry}, entries[group]...)
+		} else {
+			entries[group] = append(entries[group], entry)
+		}
+	}
+
+	for _, item := range r.Items {
+		group := item.group()
+		entries[group] = append(entries[group], g.itemEntry(item))
+	}
+
+	var b strings.Builder
+
+	b.WriteString("## " + g.title(r) + "\n")
+
+	for _, group := range groups {
+		if len(entries[group]) == 0 {
+			continue
+		}
+
+		b.WriteString("\n### " + group + "\n\n")
+		for _, entry := range entries[group] {
+			b.WriteString("- " + entry + "\n")
+		}
+	}
+
+	return b.String()
+}
+
+// Document returns a complete change log for a list of releases.
+// The releases should be sorted from the newest to the oldest.
+func (g *Generator) Document(releases []Release) string {
+	var b strings.Builder
+
+	b.WriteString(Header)
+	for _, r := range releases {
+		b.WriteString("\n" + g.Section(r))
+	}
+
+	return b.String()
+}
+
+func (g *Generator) title(r Release) string {
+	if r.Tag == "" {
+		if g.RepoURL != "" && r.PreviousTag != "" {
+			return fmt.Sprintf("[%s](%s/compare/%s...HEAD)", Unreleased, g.RepoURL, r.PreviousTag)
+		}
+		return fmt.Sprintf("[%s]", Unreleased)
+	}
+
+	date := r.Date.Format("2006-01-02")
+
+	switch {
+	case g.RepoURL != "" && r.PreviousTag != "":
+		return fmt.Sprintf("[%s](%s/compare/%s...%s) - %s", r.Tag, g.RepoURL, r.PreviousTag, r.Tag, date)
+	case g.RepoURL != "":
+		return fmt.Sprintf("[%s](%s/tree/%s) - %s", r.Tag, g.RepoURL, r.Tag, date)
+	default:
+		return fmt.Sprintf("[%s] - %s", r.Tag, date)
+	}
+}
+
+func (g *Generator) description(scope, description string) string {
+	if scope != "" {
+		return fmt.Sprintf("**%s:** %s", scope, description)
+	}
+	return description
+}
+
+func (g *Generator) commitLink(hash string) string {
+	short := hash
+	if len(short) > 7 {
+		short = short[:7]
+	}
+
+	if g.RepoURL != "" {
+		return fmt.Sprintf("([%s](%s/commit/%s))", short, g.RepoURL, hash)
+	}
+	return fmt.Sprintf("(%s)", short)
+}
+
+func (g *Generator) itemEntry(item Item) string {
+	entry := strings.TrimSpace(item.Title)
+
+	if item.URL != "" {
+		entry += fmt.Sprintf(" [#%d](%s)", item.Number, item.URL)
+	} else {
+		entry += fmt.Sprintf(" #%d", item.Number)
+	}
+
+	if item.Author != "" {
+		if item.AuthorURL != "" {
+			entry += fmt.Sprintf(" ([@%s](%s))", item.Author, item.AuthorURL)
+		} else {
+			entry += fmt.Sprintf(" (@%s)", item.Author)
+		}
+	}
+
+	return entry
+}
+
+// Body returns a release section without its heading.
+// This can be used as the description of a release.
+func Body(section string) string {
+	if strings.HasPrefix(section, "## ") {
+		if i := strings.Index(section, "\n"); i >= 0 {
+			section = section[i+1:]
+		} else {
+			section = ""
+		}
+	}
+
+	return strings.Trim(section, "\n")
+}
+
+// Update adds a release section to an existing change log and returns the updated change log.
+// The new section is added before all other release sections except the Unreleased section.
+// If the change log already has a section with the same title, it will be replaced.
+// If the change log is empty, the standard header is added too.
+func Update(changelog, section string) string {
+	if strings.TrimSpace(changelog) == "" {
+		return Header + "\n" + section
+	}
+
+	lines := strings.Split(changelog, "\n")
+	heading := strings.SplitN(section, "\n", 2)[0]
+	key := sectionKey(heading)
+
+	// Find the first release section and any existing section for the same release
+	first, start, end := -1, -1, -1
+	for i, line := range lines {
+		if !strings.HasPrefix(line, "## ") {
+			continue
+		}
+
+		if first == -1 && sectionKey(line) != Unreleased {
+			first = i
+		}
+
+		if start != -1 && end == -1 {
+			end = i
+		}
+
+		if start == -1 && sectionKey(line) == key {
+			start = i
+		}
+	}
+
+	section = strings.TrimRight(section, "\n") + "\n"
+
+	if start != -1 {
+		if end == -1 {
+			end = len(lines)
+		}
+		rest := strings.Join(lines[end:], "\n")
+		if end < len(lines) {
+			rest = "\n" + rest
+		}
+		return strings.Join(lines[:start], "\n") + "\n" + section + rest
+	}
+
+	if first == -1 {
+		return strings.TrimRight(changelog, "\n") + "\n\n" + section
+	}
+
+	return strings.Join(lines[:first], "\n") + "\n" + section + "\n" + strings.Join(lines[first:], "\n")
+}
+
+// sectionKey returns the release name from a section heading (i.e. "## [v0.2.7](...) - 2020-08-20" --> "v0.2.7").
+func sectionKey(heading string) string {
+	heading = strings.TrimPrefix(heading, "## ")
+	if strings.HasPrefix(heading, "[") {
+		if i := strings.Index(heading, "]"); i > 0 {
+			return heading[1:i]
+		}
+	}
+
+	if fields := strings.Fields(heading); len(fields) > 0 {
+		return fields[0]
+	}
+
+	return ""
+}
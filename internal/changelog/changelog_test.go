@@ -0,0 +1,244 @@
+package changelog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	date    = time.Date(2020, 8, 20, 16, 30, 0, 0, time.UTC)
+	commits = []Commit{
+		{Hash: "25aa2bdbaf10fa30b6db40c2c0a15d280ad9f378", Message: "feat(release): add -auto flag"},
+		{Hash: "83bbb9c1ff8a1a3cc3a6b2d8eb1aa3c3ab5f4f5d", Message: "fix: handle empty tag\n\nFixes #12"},
+		{Hash: "0f2e7d5ab1c4c2b68a9f0d0a1a2e3f4b5c6d7e8f", Message: "docs: update README"},
+		{Hash: "d1c0f3b3a2b9d0b1c3f6e1a8c3d9e4f5a6b7c8d9", Message: "refactor(spec)!: rename release.build\n\nBREAKING CHANGE: use release.artifacts instead"},
+		{Hash: "9a8b7c6d5e4f3a2b1c0d9e8f7a6b5c4d3e2f1a0b", Message: "Update dependencies"},
+		{Hash: "1b2c3d4e5f6a7b8c9d0e1f2a3b4c5d6e7f8a9b0c", Message: "perf: cache compiled regexes"},
+		{Hash: "abcdeff", Message: "security: bump golang.org/x/crypto"},
+	}
+)
+
+func TestGeneratorSection(t *testing.T) {
+	tests := []struct {
+		name            string
+		generator       *Generator
+		release         Release
+		expectedSection string
+	}{
+		{
+			name:      "NoCommit",
+			generator: &Generator{},
+			release: Release{
+				Tag:  "v0.1.0",
+				Date: date,
+			},
+			expectedSection: "## [v0.1.0] - 2020-08-20\n",
+		},
+		{
+			name:      "Unreleased",
+			generator: &Generator{},
+			release: Release{
+				PreviousTag: "v0.1.0",
+				Commits: []Commit{
+					{Hash: "abcdeff", Message: "fix: handle empty tag"},
+				},
+			},
+			expectedSection: "## [Unreleased]\n\n### Fixed\n\n- handle empty tag (abcdeff)\n",
+		},
+		{
+			name:      "UnreleasedWithRepoURL",
+			generator: &Generator{RepoURL: "https://github.com/moorara/cherry"},
+			release: Release{
+				PreviousTag: "v0.1.0",
+			},
+			expectedSection: "## [Unreleased](https://github.com/moorara/cherry/compare/v0.1.0...HEAD)\n",
+		},
+		{
+			name:      "FirstReleaseWithRepoURL",
+			generator: &Generator{RepoURL: "https://github.com/moorara/cherry"},
+			release: Release{
+				Tag:  "v0.1.0",
+				Date: date,
+				Commits: []Commit{
+					{Hash: "abcdeff", Message: "feat: initial commit"},
+				},
+			},
+			expectedSection: "## [v0.1.0](https://github.com/moorara/cherry/tree/v0.1.0) - 2020-08-20\n\n### Added\n\n- initial commit ([abcdeff](https://github.com/moorara/cherry/commit/abcdeff))\n",
+		},
+		{
+			name:      "Grouped",
+			generator: &Generator{},
+			release: Release{
+				Tag:         "v0.2.0",
+				PreviousTag: "v0.1.0",
+				Date:        date,
+				Commits:     commits,
+			},
+			expectedSection: `## [v0.2.0] - 2020-08-20
+
+### Added
+
+- **release:** add -auto flag (25aa2bd)
+
+### Changed
+
+- **BREAKING:** **spec:** use release.artifacts instead (d1c0f3b)
+- Update dependencies (9a8b7c6)
+- cache compiled regexes (1b2c3d4)
+
+### Fixed
+
+- handle empty tag (83bbb9c)
+
+### Security
+
+- bump golang.org/x/crypto (abcdeff)
+`,
+		},
+		{
+			name:      "GroupedWithRepoURL",
+			generator: &Generator{RepoURL: "https://github.com/moorara/cherry"},
+			release: Release{
+				Tag:         "v0.2.0",
+				PreviousTag: "v0.1.0",
+				Date:        date,
+				Commits:     commits[:2],
+			},
+			expectedSection: `## [v0.2.0](https://github.com/moorara/cherry/compare/v0.1.0...v0.2.0) - 2020-08-20
+
+### Added
+
+- **release:** add -auto flag ([25aa2bd](https://github.com/moorara/cherry/commit/25aa2bdbaf10fa30b6db40c2c0a15d280ad9f378))
+
+### Fixed
+
+- handle empty tag ([83bbb9c](https://github.com/moorara/cherry/commit/83bbb9c1ff8a1a3cc3a6b2d8eb1aa3c3ab5f4f5d))
+`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedSection, tc.generator.Section(tc.release))
+		})
+	}
+}
+
+func TestGeneratorDocument(t *testing.T) {
+	g := &Generator{}
+	releases := []Release{
+		{
+			Tag:         "v0.2.0",
+			PreviousTag: "v0.1.0",
+			Date:        date,
+			Commits:     commits[:1],
+		},
+		{
+			Tag:     "v0.1.0",
+			Date:    date,
+			Commits: commits[1:2],
+		},
+	}
+
+	expectedDocument := Header + `
+## [v0.2.0] - 2020-08-20
+
+### Added
+
+- **release:** add -auto flag (25aa2bd)
+
+## [v0.1.0] - 2020-08-20
+
+### Fixed
+
+- handle empty tag (83bbb9c)
+`
+
+	assert.Equal(t, expectedDocument, g.Document(releases))
+}
+
+func TestBody(t *testing.T) {
+	tests := []struct {
+		name         string
+		section      string
+		expectedBody string
+	}{
+		{
+			name:         "Empty",
+			section:      "",
+			expectedBody: "",
+		},
+		{
+			name:         "HeadingOnly",
+			section:      "## [v0.1.0] - 2020-08-20",
+			expectedBody: "",
+		},
+		{
+			name:         "OK",
+			section:      "## [v0.1.0] - 2020-08-20\n\n### Fixed\n\n- handle empty tag (abcdeff)\n",
+			expectedBody: "### Fixed\n\n- handle empty tag (abcdeff)",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedBody, Body(tc.section))
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	section := "## [v0.2.0] - 2020-08-20\n\n### Fixed\n\n- handle empty tag (abcdeff)\n"
+
+	tests := []struct {
+		name              string
+		changelog         string
+		section           string
+		expectedChangelog string
+	}{
+		{
+			name:              "Empty",
+			changelog:         "",
+			section:           section,
+			expectedChangelog: Header + "\n" + section,
+		},
+		{
+			name:              "NoSection",
+			changelog:         "# Changelog\n",
+			section:           section,
+			expectedChangelog: "# Changelog\n\n" + section,
+		},
+		{
+			name:              "Prepend",
+			changelog:         "# Changelog\n\n## [v0.1.0] - 2020-08-18\n\n- first\n",
+			section:           section,
+			expectedChangelog: "# Changelog\n\n" + section + "\n## [v0.1.0] - 2020-08-18\n\n- first\n",
+		},
+		{
+			name:              "KeepUnreleased",
+			changelog:         "# Changelog\n\n## [Unreleased]\n\n- next\n\n## [v0.1.0] - 2020-08-18\n\n- first\n",
+			section:           section,
+			expectedChangelog: "# Changelog\n\n## [Unreleased]\n\n- next\n\n" + section + "\n## [v0.1.0] - 2020-08-18\n\n- first\n",
+		},
+		{
+			name:              "Replace",
+			changelog:         "# Changelog\n\n## [v0.2.0] - 2020-08-19\n\n- old\n\n## [v0.1.0] - 2020-08-18\n\n- first\n",
+			section:           section,
+			expectedChangelog: "# Changelog\n\n" + section + "\n## [v0.1.0] - 2020-08-18\n\n- first\n",
+		},
+		{
+			name:              "ReplaceLast",
+			changelog:         "# Changelog\n\n## [v0.2.0](https://github.com/moorara/cherry/tree/v0.2.0) (2020-08-19)\n\n- old\n",
+			section:           section,
+			expectedChangelog: "# Changelog\n\n" + section,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedChangelog, Update(tc.changelog, tc.section))
+		})
+	}
+}
@@ -0,0 +1,249 @@
+package changelog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/moorara/cherry/internal/httputil"
+)
+
+const defaultGitHubAPIURL = "https://api.github.com"
+
+// ClosedIssues is the group for closed issues without any label mapped to another group.
+const ClosedIssues = "Closed Issues"
+
+// groupByLabel maps GitHub labels to change log groups.
+// Pull requests without any of these labels are considered as changes.
+var groupByLabel = map[string]string{
+	"enhancement": Added,
+	"feature":     Added,
+	"deprecation": Deprecated,
+	"deprecated":  Deprecated,
+	"removal":     Removed,
+	"removed":     Removed,
+	"bug":         Fixed,
+	"security":    Security,
+}
+
+// Item is a merged pull request or a closed issue.
+type Item struct {
+	Number      int
+	Title       string
+	URL         string
+	Author      string
+	AuthorURL   string
+	Labels      []string
+	PullRequest bool
+	ClosedAt    time.Time
+}
+
+// group returns the change log group for an item based on its labels.
+func (i Item) group() string {
+	for _, label := range i.Labels {
+		if group, ok := groupByLabel[strings.ToLower(label)]; ok {
+			return group
+		}
+	}
+
+	if i.PullRequest {
+		return Changed
+	}
+	return ClosedIssues
+}
+
+// GitHubSource fetches merged pull requests and closed issues using GitHub REST API v3.
+// See https://docs.github.com/en/rest/reference/issues#list-repository-issues
+// See https://docs.github.com/en/rest/reference/pulls#list-pull-requests
+type GitHubSource struct {
+	Client *http.Client
+	// APIURL is the base URL of GitHub API (default: https://api.github.com).
+	APIURL string
+	Token  string
+	Owner  string
+	Repo   string
+	// ExcludeLabels are the labels for excluding pull requests and issues (i.e. question, duplicate, invalid, wontfix).
+	ExcludeLabels []string
+}
+
+// Items returns the pull requests merged and the issues closed after since and not after until.
+// A zero since or until means no lower or upper bound respectively.
+// The items are sorted from the newest to the oldest.
+func (s *GitHubSource) Items(ctx context.Context, since, until time.Time) ([]Item, error) {
+	items := []Item{}
+
+	// Issues
+
+	query := url.Values{}
+	query.Set("state", "closed")
+	query.Set("per_page", "100")
+	if !since.IsZero() {
+		// since filters the issues by their update time, so closed issues are filtered later
+		query.Set("since", since.UTC().Format(time.RFC3339))
+	}
+
+	type githubIssue struct {
+		Number      int             `json:"number"`
+		Title       string          `json:"title"`
+		HTMLURL     string          `json:"html_url"`
+		User        githubUser      `json:"user"`
+		Labels      []githubLabel   `json:"labels"`
+		PullRequest json.RawMessage `json:"pull_request"`
+		ClosedAt    *time.Time      `json:"closed_at"`
+	}
+
+	err := s.list(ctx, fmt.Sprintf("/repos/%s/%s/issues?%s", s.Owner, s.Repo, query.Encode()), func(page []byte) (bool, error) {
+		var issues []githubIssue
+		if err := json.Unmarshal(page, &issues); err != nil {
+			return false, err
+		}
+
+		for _, issue := range issues {
+			// Pull requests are also returned as issues
+			if issue.PullRequest != nil || issue.ClosedAt == nil || !inRange(*issue.ClosedAt, since, until) {
+				continue
+			}
+
+			item := Item{
+				Number:    issue.Number,
+				Title:     issue.Title,
+				URL:       issue.HTMLURL,
+				Author:    issue.User.Login,
+				AuthorURL: issue.User.HTMLURL,
+				Labels:    labelNames(issue.Labels),
+				ClosedAt:  *issue.ClosedAt,
+			}
+
+			if !s.excluded(item) {
+				items = append(items, item)
+			}
+		}
+
+		return true, nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Pull requests
+
+	query = url.Values{}
+	query.Set("state", "closed")
+	query.Set("sort", "updated")
+	query.Set("direction", "desc")
+	query.Set("per_page", "100")
+
+	type githubPull struct {
+		Number    int           `json:"number"`
+		Title     string        `json:"title"`
+		HTMLURL   string        `json:"html_url"`
+		User      githubUser    `json:"user"`
+		Labels    []githubLabel `json:"labels"`
+		UpdatedAt time.Time     `json:"updated_at"`
+		MergedAt  *time.Time    `json:"merged_at"`
+	}
+
+	err = s.list(ctx, fmt.Sprintf("/repos/%s/%s/pulls?%s", s.Owner, s.Repo, query.Encode()), func(page []byte) (bool, error) {
+		var pulls []githubPull
+		if err := json.Unmarshal(page, &pulls); err != nil {
+			return false, err
+		}
+
+		for _, pull := range pulls {
+			// Pull requests are sorted by their update time, and a pull request cannot be merged after its last update
+			if !since.IsZero() && pull.UpdatedAt.Before(since) {
+				return false, nil
+			}
+
+			if pull.MergedAt == nil || !inRange(*pull.MergedAt, since, until) {
+				continue
+			}
+
+			item := Item{
+				Number:      pull.Number,
+				Title:       pull.Title,
+				URL:         pull.HTMLURL,
+				Author:      pull.User.Login,
+				AuthorURL:   pull.User.HTMLURL,
+				Labels:      labelNames(pull.Labels),
+				PullRequest: true,
+				ClosedAt:    *pull.MergedAt,
+			}
+
+			if !s.excluded(item) {
+				items = append(items, item)
+			}
+		}
+
+		return true, nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].ClosedAt.After(items[j].ClosedAt)
+	})
+
+	return items, nil
+}
+
+// list requests a paginated list and calls the handle function for each page.
+// The pages are followed using the Link header until there is no next page or the handle function returns false.
+// See https://docs.github.com/en/rest/guides/traversing-with-pagination
+func (s *GitHubSource) list(ctx context.Context, path string, handle func([]byte) (bool, error)) error {
+	apiURL := s.APIURL
+	if apiURL == "" {
+		apiURL = defaultGitHubAPIURL
+	}
+
+	header := http.Header{}
+	header.Set("Accept", "application/vnd.github.v3+json")
+	header.Set("User-Agent", "cherry")
+	if s.Token != "" {
+		header.Set("Authorization", "token "+s.Token)
+	}
+
+	return httputil.ListPages(ctx, s.Client, strings.TrimRight(apiURL, "/")+path, header, handle)
+}
+
+func (s *GitHubSource) excluded(item Item) bool {
+	for _, label := range item.Labels {
+		for _, excluded := range s.ExcludeLabels {
+			if strings.EqualFold(label, excluded) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+type githubUser struct {
+	Login   string `json:"login"`
+	HTMLURL string `json:"html_url"`
+}
+
+type githubLabel struct {
+	Name string `json:"name"`
+}
+
+func labelNames(labels []githubLabel) []string {
+	names := make([]string, len(labels))
+	for i, label := range labels {
+		names[i] = label.Name
+	}
+
+	return names
+}
+
+func inRange(t, since, until time.Time) bool {
+	return (since.IsZero() || t.After(since)) && (until.IsZero() || !t.After(until))
+}
@@ -0,0 +1,219 @@
+package changelog
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	issuesPage1 = `[
+		{
+			"number": 14,
+			"title": "Support pre-releases",
+			"html_url": "https://github.com/moorara/cherry/issues/14",
+			"user": { "login": "octocat", "html_url": "https://github.com/octocat" },
+			"labels": [ { "name": "enhancement" } ],
+			"closed_at": "2020-08-19T10:00:00Z"
+		},
+		{
+			"number": 13,
+			"title": "Add -auto flag",
+			"html_url": "https://github.com/moorara/cherry/pull/13",
+			"user": { "login": "octocat", "html_url": "https://github.com/octocat" },
+			"labels": [],
+			"pull_request": { "url": "https://api.github.com/repos/moorara/cherry/pulls/13" },
+			"closed_at": "2020-08-19T09:00:00Z"
+		}
+	]`
+
+	issuesPage2 = `[
+		{
+			"number": 12,
+			"title": "How do I release?",
+			"html_url": "https://github.com/moorara/cherry/issues/12",
+			"user": { "login": "hubot", "html_url": "https://github.com/hubot" },
+			"labels": [ { "name": "Question" } ],
+			"closed_at": "2020-08-18T10:00:00Z"
+		},
+		{
+			"number": 11,
+			"title": "Tag is empty",
+			"html_url": "https://github.com/moorara/cherry/issues/11",
+			"user": { "login": "hubot", "html_url": "https://github.com/hubot" },
+			"labels": [],
+			"closed_at": "2020-08-18T09:00:00Z"
+		},
+		{
+			"number": 10,
+			"title": "Closed before since",
+			"html_url": "https://github.com/moorara/cherry/issues/10",
+			"user": { "login": "hubot", "html_url": "https://github.com/hubot" },
+			"labels": [],
+			"closed_at": "2020-08-01T09:00:00Z"
+		}
+	]`
+
+	pullsPage1 = `[
+		{
+			"number": 15,
+			"title": "Merged after until",
+			"html_url": "https://github.com/moorara/cherry/pull/15",
+			"user": { "login": "octocat", "html_url": "https://github.com/octocat" },
+			"labels": [],
+			"updated_at": "2020-08-22T09:00:00Z",
+			"merged_at": "2020-08-22T09:00:00Z"
+		},
+		{
+			"number": 13,
+			"title": "Add -auto flag",
+			"html_url": "https://github.com/moorara/cherry/pull/13",
+			"user": { "login": "octocat", "html_url": "https://github.com/octocat" },
+			"labels": [ { "name": "enhancement" } ],
+			"updated_at": "2020-08-19T09:00:00Z",
+			"merged_at": "2020-08-19T09:00:00Z"
+		}
+	]`
+
+	pullsPage2 = `[
+		{
+			"number": 9,
+			"title": "Closed without merging",
+			"html_url": "https://github.com/moorara/cherry/pull/9",
+			"user": { "login": "hubot", "html_url": "https://github.com/hubot" },
+			"labels": [],
+			"updated_at": "2020-08-18T12:00:00Z",
+			"merged_at": null
+		},
+		{
+			"number": 8,
+			"title": "Fix empty tag",
+			"html_url": "https://github.com/moorara/cherry/pull/8",
+			"user": { "login": "hubot", "html_url": "https://github.com/hubot" },
+			"labels": [ { "name": "bug" } ],
+			"updated_at": "2020-08-18T11:00:00Z",
+			"merged_at": "2020-08-18T11:00:00Z"
+		},
+		{
+			"number": 7,
+			"title": "Updated before since",
+			"html_url": "https://github.com/moorara/cherry/pull/7",
+			"user": { "login": "hubot", "html_url": "https://github.com/hubot" },
+			"labels": [],
+			"updated_at": "2020-08-01T11:00:00Z",
+			"merged_at": "2020-08-01T11:00:00Z"
+		}
+	]`
+)
+
+func newGitHubServer(t *testing.T) *httptest.Server {
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "token github-token", r.Header.Get("Authorization"))
+
+		switch {
+		case r.URL.Path == "/repos/moorara/cherry/issues" && r.URL.Query().Get("page") == "":
+			assert.Equal(t, "closed", r.URL.Query().Get("state"))
+			assert.Equal(t, "2020-08-10T00:00:00Z", r.URL.Query().Get("since"))
+			w.Header().Set("Link", fmt.Sprintf(`<%s/repositories/1/issues?page=2>; rel="next", <%s/repositories/1/issues?page=2>; rel="last"`, ts.URL, ts.URL))
+			fmt.Fprint(w, issuesPage1)
+		case r.URL.Path == "/repositories/1/issues" && r.URL.Query().Get("page") == "2":
+			fmt.Fprint(w, issuesPage2)
+		case r.URL.Path == "/repos/moorara/cherry/pulls" && r.URL.Query().Get("page") == "":
+			assert.Equal(t, "updated", r.URL.Query().Get("sort"))
+			w.Header().Set("Link", fmt.Sprintf(`<%s/repositories/1/pulls?page=2>; rel="next"`, ts.URL))
+			fmt.Fprint(w, pullsPage1)
+		case r.URL.Path == "/repositories/1/pulls" && r.URL.Query().Get("page") == "2":
+			// The last pull request is updated before since, so the next page should not be requested
+			w.Header().Set("Link", fmt.Sprintf(`<%s/repositories/1/pulls?page=3>; rel="next"`, ts.URL))
+			fmt.Fprint(w, pullsPage2)
+		default:
+			t.Errorf("unexpected request: %s", r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	return ts
+}
+
+func TestGitHubSourceItems(t *testing.T) {
+	ts := newGitHubServer(t)
+	defer ts.Close()
+
+	s := &GitHubSource{
+		APIURL:        ts.URL,
+		Token:         "github-token",
+		Owner:         "moorara",
+		Repo:          "cherry",
+		ExcludeLabels: []string{"question", "duplicate", "invalid", "wontfix"},
+	}
+
+	since := time.Date(2020, 8, 10, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2020, 8, 20, 0, 0, 0, 0, time.UTC)
+
+	items, err := s.Items(context.Background(), since, until)
+	assert.NoError(t, err)
+	assert.Equal(t, []Item{
+		{
+			Number:    14,
+			Title:     "Support pre-releases",
+			URL:       "https://github.com/moorara/cherry/issues/14",
+			Author:    "octocat",
+			AuthorURL: "https://github.com/octocat",
+			Labels:    []string{"enhancement"},
+			ClosedAt:  time.Date(2020, 8, 19, 10, 0, 0, 0, time.UTC),
+		},
+		{
+			Number:      13,
+			Title:       "Add -auto flag",
+			URL:         "https://github.com/moorara/cherry/pull/13",
+			Author:      "octocat",
+			AuthorURL:   "https://github.com/octocat",
+			Labels:      []string{"enhancement"},
+			PullRequest: true,
+			ClosedAt:    time.Date(2020, 8, 19, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			Number:      8,
+			Title:       "Fix empty tag",
+			URL:         "https://github.com/moorara/cherry/pull/8",
+			Author:      "hubot",
+			AuthorURL:   "https://github.com/hubot",
+			Labels:      []string{"bug"},
+			PullRequest: true,
+			ClosedAt:    time.Date(2020, 8, 18, 11, 0, 0, 0, time.UTC),
+		},
+		{
+			Number:    11,
+			Title:     "Tag is empty",
+			URL:       "https://github.com/moorara/cherry/issues/11",
+			Author:    "hubot",
+			AuthorURL: "https://github.com/hubot",
+			Labels:    []string{},
+			ClosedAt:  time.Date(2020, 8, 18, 9, 0, 0, 0, time.UTC),
+		},
+	}, items)
+}
+
+func TestGitHubSourceItemsError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{ "message": "Bad credentials" }`)
+	}))
+	defer ts.Close()
+
+	s := &GitHubSource{
+		APIURL: ts.URL,
+		Owner:  "moorara",
+		Repo:   "cherry",
+	}
+
+	items, err := s.Items(context.Background(), time.Time{}, time.Time{})
+	assert.EqualError(t, err, fmt.Sprintf("GET %s/repos/moorara/cherry/issues?per_page=100&state=closed: invalid status code 401", ts.URL))
+	assert.Nil(t, items)
+}
@@ -0,0 +1,282 @@
+package command
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultArchiveTime is the modification time of the files in archives if SOURCE_DATE_EPOCH is not set.
+// It is the earliest time that can be stored in zip archives.
+var defaultArchiveTime = time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// archiveEntry is a file in an archive.
+type archiveEntry struct {
+	// name is the slash-separated path of the file in the archive.
+	name string
+	// path is the path of the file on disk.
+	path string
+	mode os.FileMode
+}
+
+// archiveTime returns the modification time of the files in archives, so the same files always result in the same archive.
+// SOURCE_DATE_EPOCH environment variable can be used for setting it (https://reproducible-builds.org/specs/source-date-epoch).
+func archiveTime() (time.Time, error) {
+	val := os.Getenv("SOURCE_DATE_EPOCH")
+	if val == "" {
+		return defaultArchiveTime, nil
+	}
+
+	sec, err := strconv.ParseInt(val, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid SOURCE_DATE_EPOCH %q: %s", val, err)
+	}
+
+	t := time.Unix(sec, 0).UTC()
+	if t.Before(defaultArchiveTime) {
+		t = defaultArchiveTime
+	}
+
+	return t, nil
+}
+
+// archiveFiles returns the LICENSE and README files in a directory and the files matching a list of glob patterns.
+// Directories matching the patterns are included with all of their files.
+func archiveFiles(dir string, patterns []string) ([]archiveEntry, error) {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	entries := []archiveEntry{}
+
+	add := func(path string, info os.FileInfo) error {
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		name := filepath.ToSlash(rel)
+		if strings.HasPrefix(name, "../") {
+			return fmt.Errorf("%s is not in %s", path, dir)
+		}
+
+		if !seen[name] {
+			seen[name] = true
+			entries = append(entries, archiveEntry{name: name, path: path, mode: fileMode(info)})
+		}
+
+		return nil
+	}
+
+	for _, info := range infos {
+		upper := strings.ToUpper(info.Name())
+		if info.Mode().IsRegular() && (strings.HasPrefix(upper, "LICENSE") || strings.HasPrefix(upper, "README")) {
+			if err := add(filepath.Join(dir, info.Name()), info); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(filepath.Join(dir, filepath.FromSlash(pattern)))
+		if err != nil {
+			return nil, err
+		}
+
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no file matching %s", pattern)
+		}
+
+		for _, match := range matches {
+			err := filepath.Walk(match, func(path string, info os.FileInfo, err error) error {
+				if err != nil || !info.Mode().IsRegular() {
+					return err
+				}
+				return add(path, info)
+			})
+
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return entries, nil
+}
+
+// fileMode returns the permissions of a file in archives, so they do not depend on the umask.
+func fileMode(info os.FileInfo) os.FileMode {
+	if info.Mode()&0111 != 0 {
+		return 0755
+	}
+
+	return 0644
+}
+
+// createArchive creates a tar.gz or zip archive.
+// The entries are sorted by name and have the same modification time and no owner, so the archive is reproducible.
+func createArchive(path, format string, entries []archiveEntry, mtime time.Time) (err error) {
+	sorted := make([]archiveEntry, len(entries))
+	copy(sorted, entries)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].name < sorted[j].name
+	})
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			os.Remove(path)
+		}
+	}()
+
+	switch format {
+	case "tar.gz":
+		return writeTarGz(f, sorted, mtime)
+	case "zip":
+		return writeZip(f, sorted, mtime)
+	default:
+		return fmt.Errorf("unknown archive format %q", format)
+	}
+}
+
+func writeTarGz(w io.Writer, entries []archiveEntry, mtime time.Time) error {
+	// The gzip header has no name and modification time
+	gw, err := gzip.NewWriterLevel(w, gzip.BestCompression)
+	if err != nil {
+		return err
+	}
+
+	tw := tar.NewWriter(gw)
+
+	for _, e := range entries {
+		info, err := os.Stat(e.path)
+		if err != nil {
+			return err
+		}
+
+		hdr := &tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     e.name,
+			Mode:     int64(e.mode),
+			Size:     info.Size(),
+			ModTime:  mtime,
+		}
+
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+
+		if err := copyFile(tw, e.path); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+
+	return gw.Close()
+}
+
+func writeZip(w io.Writer, entries []archiveEntry, mtime time.Time) error {
+	zw := zip.NewWriter(w)
+
+	for _, e := range entries {
+		hdr := &zip.FileHeader{
+			Name:     e.name,
+			Method:   zip.Deflate,
+			Modified: mtime,
+		}
+		hdr.SetMode(e.mode)
+
+		fw, err := zw.CreateHeader(hdr)
+		if err != nil {
+			return err
+		}
+
+		if err := copyFile(fw, e.path); err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+func copyFile(w io.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// extractFile returns the content of a file in a tar.gz or zip archive.
+func extractFile(data []byte, format, name string) ([]byte, error) {
+	switch format {
+	case "tar.gz":
+		gr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+
+		tr := tar.NewReader(gr)
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			if hdr.Typeflag == tar.TypeReg && hdr.Name == name {
+				return ioutil.ReadAll(tr)
+			}
+		}
+
+	case "zip":
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, f := range zr.File {
+			if f.Name == name {
+				rc, err := f.Open()
+				if err != nil {
+					return nil, err
+				}
+				defer rc.Close()
+
+				return ioutil.ReadAll(rc)
+			}
+		}
+
+	default:
+		return nil, fmt.Errorf("unknown archive format: %s", format)
+	}
+
+	return nil, fmt.Errorf("%s not found in archive", name)
+}
@@ -0,0 +1,385 @@
+package command
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/moorara/cherry/internal/spec"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArchiveTime(t *testing.T) {
+	tests := []struct {
+		name          string
+		env           string
+		expectedTime  time.Time
+		expectedError string
+	}{
+		{
+			name:         "Default",
+			env:          "",
+			expectedTime: defaultArchiveTime,
+		},
+		{
+			name:         "SourceDateEpoch",
+			env:          "1600000000",
+			expectedTime: time.Unix(1600000000, 0).UTC(),
+		},
+		{
+			name:         "BeforeDefault",
+			env:          "0",
+			expectedTime: defaultArchiveTime,
+		},
+		{
+			name:          "Invalid",
+			env:           "yesterday",
+			expectedError: `invalid SOURCE_DATE_EPOCH "yesterday"`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			defer os.Setenv("SOURCE_DATE_EPOCH", os.Getenv("SOURCE_DATE_EPOCH"))
+			os.Setenv("SOURCE_DATE_EPOCH", tc.env)
+
+			mtime, err := archiveTime()
+
+			if tc.expectedError != "" {
+				assert.Contains(t, err.Error(), tc.expectedError)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedTime, mtime)
+			}
+		})
+	}
+}
+
+func TestArchiveFiles(t *testing.T) {
+	files := map[string]string{
+		"LICENSE":          "MIT",
+		"README.md":        "# App",
+		"main.go":          "package main",
+		"docs/guide.md":    "# Guide",
+		"docs/api/rest.md": "# REST",
+		"scripts/run.sh":   "#!/bin/sh",
+	}
+
+	tests := []struct {
+		name          string
+		patterns      []string
+		expectedNames []string
+		expectedError string
+	}{
+		{
+			name:          "Default",
+			expectedNames: []string{"LICENSE", "README.md"},
+		},
+		{
+			name:          "Glob",
+			patterns:      []string{"docs/*.md", "README*"},
+			expectedNames: []string{"LICENSE", "README.md", "docs/guide.md"},
+		},
+		{
+			name:          "Directory",
+			patterns:      []string{"docs", "scripts/run.sh"},
+			expectedNames: []string{"LICENSE", "README.md", "docs/api/rest.md", "docs/guide.md", "scripts/run.sh"},
+		},
+		{
+			name:          "NoMatch",
+			patterns:      []string{"CHANGELOG.md"},
+			expectedError: "no file matching CHANGELOG.md",
+		},
+		{
+			name:          "OutsideDirectory",
+			patterns:      []string{"../NOTICE"},
+			expectedError: "is not in",
+		},
+	}
+
+	root, err := ioutil.TempDir("", "cherry-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	dir := filepath.Join(root, "app")
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+	}
+	assert.NoError(t, os.Chmod(filepath.Join(dir, "scripts", "run.sh"), 0700))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(root, "NOTICE"), []byte("Notice"), 0644))
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			entries, err := archiveFiles(dir, tc.patterns)
+
+			if tc.expectedError != "" {
+				assert.Contains(t, err.Error(), tc.expectedError)
+			} else {
+				assert.NoError(t, err)
+
+				names := []string{}
+				for _, e := range entries {
+					names = append(names, e.name)
+					if e.name == "scripts/run.sh" {
+						assert.Equal(t, os.FileMode(0755), e.mode)
+					} else {
+						assert.Equal(t, os.FileMode(0644), e.mode)
+					}
+				}
+				assert.ElementsMatch(t, tc.expectedNames, names)
+			}
+		})
+	}
+}
+
+func TestCreateArchive(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cherry-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"app":       "binary",
+		"README.md": "# App",
+		"LICENSE":   "MIT",
+	}
+
+	for name, content := range files {
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0600))
+	}
+
+	entries := []archiveEntry{
+		{name: "app", path: filepath.Join(dir, "app"), mode: 0755},
+		{name: "README.md", path: filepath.Join(dir, "README.md"), mode: 0644},
+		{name: "LICENSE", path: filepath.Join(dir, "LICENSE"), mode: 0644},
+	}
+
+	tests := []struct {
+		name   string
+		format string
+		read   func(t *testing.T, path string) ([]string, []os.FileMode, []time.Time)
+	}{
+		{
+			name:   "TarGz",
+			format: "tar.gz",
+			read: func(t *testing.T, path string) ([]string, []os.FileMode, []time.Time) {
+				f, err := os.Open(path)
+				assert.NoError(t, err)
+				defer f.Close()
+
+				gr, err := gzip.NewReader(f)
+				assert.NoError(t, err)
+
+				var names []string
+				var modes []os.FileMode
+				var mtimes []time.Time
+
+				tr := tar.NewReader(gr)
+				for {
+					hdr, err := tr.Next()
+					if err == io.EOF {
+						break
+					}
+					assert.NoError(t, err)
+					assert.Equal(t, 0, hdr.Uid)
+					assert.Equal(t, 0, hdr.Gid)
+					assert.Empty(t, hdr.Uname)
+					assert.Empty(t, hdr.Gname)
+
+					content, err := ioutil.ReadAll(tr)
+					assert.NoError(t, err)
+					assert.Equal(t, files[hdr.Name], string(content))
+
+					names = append(names, hdr.Name)
+					modes = append(modes, os.FileMode(hdr.Mode))
+					mtimes = append(mtimes, hdr.ModTime.UTC())
+				}
+
+				return names, modes, mtimes
+			},
+		},
+		{
+			name:   "Zip",
+			format: "zip",
+			read: func(t *testing.T, path string) ([]string, []os.FileMode, []time.Time) {
+				zr, err := zip.OpenReader(path)
+				assert.NoError(t, err)
+				defer zr.Close()
+
+				var names []string
+				var modes []os.FileMode
+				var mtimes []time.Time
+
+				for _, f := range zr.File {
+					rc, err := f.Open()
+					assert.NoError(t, err)
+					content, err := ioutil.ReadAll(rc)
+					assert.NoError(t, err)
+					rc.Close()
+					assert.Equal(t, files[f.Name], string(content))
+
+					names = append(names, f.Name)
+					modes = append(modes, f.Mode())
+					mtimes = append(mtimes, f.Modified.UTC())
+				}
+
+				return names, modes, mtimes
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(dir, "app."+tc.format)
+			err := createArchive(path, tc.format, entries, defaultArchiveTime)
+			assert.NoError(t, err)
+
+			names, modes, mtimes := tc.read(t, path)
+			assert.Equal(t, []string{"LICENSE", "README.md", "app"}, names)
+			assert.Equal(t, []os.FileMode{0644, 0644, 0755}, modes)
+			assert.Equal(t, []time.Time{defaultArchiveTime, defaultArchiveTime, defaultArchiveTime}, mtimes)
+
+			// Creating the archive again from files modified later results in the same archive
+			first, err := ioutil.ReadFile(path)
+			assert.NoError(t, err)
+
+			later := time.Now().Add(time.Hour)
+			for name := range files {
+				assert.NoError(t, os.Chtimes(filepath.Join(dir, name), later, later))
+			}
+
+			err = createArchive(path, tc.format, entries, defaultArchiveTime)
+			assert.NoError(t, err)
+
+			second, err := ioutil.ReadFile(path)
+			assert.NoError(t, err)
+			assert.True(t, bytes.Equal(first, second))
+		})
+	}
+
+	t.Run("UnknownFormat", func(t *testing.T) {
+		path := filepath.Join(dir, "app.rar")
+		err := createArchive(path, "rar", entries, defaultArchiveTime)
+		assert.EqualError(t, err, `unknown archive format "rar"`)
+
+		_, err = os.Stat(path)
+		assert.True(t, os.IsNotExist(err))
+	})
+}
+
+func TestBuildArchive(t *testing.T) {
+	tests := []struct {
+		name            string
+		binaryFile      string
+		binFile         string
+		platform        spec.Platform
+		expectedArchive string
+		expectedNames   []string
+	}{
+		{
+			name:            "CrossCompile",
+			binaryFile:      "bin/app",
+			binFile:         "bin/app-linux-amd64",
+			platform:        spec.Platform{OS: "linux", Arch: "amd64"},
+			expectedArchive: "bin/app_1.2.3_linux_amd64.tar.gz",
+			expectedNames:   []string{"app"},
+		},
+		{
+			name:            "Template",
+			binaryFile:      "bin/app_{{.OS}}_{{.Arch}}{{.Ext}}",
+			binFile:         "bin/app_linux_amd64",
+			platform:        spec.Platform{OS: "linux", Arch: "amd64"},
+			expectedArchive: "bin/app_1.2.3_linux_amd64.tar.gz",
+			expectedNames:   []string{"app"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "cherry-")
+			assert.NoError(t, err)
+			defer os.RemoveAll(dir)
+
+			assert.NoError(t, os.Mkdir(filepath.Join(dir, "bin"), 0755))
+			assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, filepath.FromSlash(tc.binFile)), []byte("binary"), 0755))
+
+			c := &buildCommand{
+				spec: spec.Spec{
+					Build: spec.Build{
+						CrossCompile: true,
+						BinaryFile:   tc.binaryFile,
+						Archives:     spec.Archives{Enabled: true}.WithDefaults(),
+					},
+				},
+			}
+
+			archive, err := c.archive(dir, tc.platform, filepath.FromSlash(tc.binFile), "1.2.3", nil, defaultArchiveTime)
+			assert.NoError(t, err)
+			assert.Equal(t, filepath.FromSlash(tc.expectedArchive), archive)
+
+			f, err := os.Open(filepath.Join(dir, archive))
+			assert.NoError(t, err)
+			defer f.Close()
+
+			gr, err := gzip.NewReader(f)
+			assert.NoError(t, err)
+
+			var names []string
+			tr := tar.NewReader(gr)
+			for {
+				hdr, err := tr.Next()
+				if err == io.EOF {
+					break
+				}
+				assert.NoError(t, err)
+				names = append(names, hdr.Name)
+			}
+
+			assert.Equal(t, tc.expectedNames, names)
+		})
+	}
+}
+
+func TestExtractFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cherry-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "cherry"), []byte("binary"), 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "LICENSE"), []byte("MIT"), 0644))
+
+	entries := []archiveEntry{
+		{name: "cherry", path: filepath.Join(dir, "cherry"), mode: 0755},
+		{name: "LICENSE", path: filepath.Join(dir, "LICENSE"), mode: 0644},
+	}
+
+	for _, format := range []string{"tar.gz", "zip"} {
+		t.Run(format, func(t *testing.T) {
+			path := filepath.Join(dir, "cherry."+format)
+			assert.NoError(t, createArchive(path, format, entries, defaultArchiveTime))
+
+			data, err := ioutil.ReadFile(path)
+			assert.NoError(t, err)
+
+			content, err := extractFile(data, format, "cherry")
+			assert.NoError(t, err)
+			assert.Equal(t, "binary", string(content))
+
+			_, err = extractFile(data, format, "cherry.exe")
+			assert.EqualError(t, err, "cherry.exe not found in archive")
+		})
+	}
+
+	t.Run("UnknownFormat", func(t *testing.T) {
+		_, err := extractFile(nil, "rar", "cherry")
+		assert.EqualError(t, err, "unknown archive format: rar")
+	})
+}
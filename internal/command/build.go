@@ -3,25 +3,32 @@ package command
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"html/template"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"time"
 
 	"github.com/mitchellh/cli"
+	"github.com/moorara/cherry/internal/minisign"
 	"github.com/moorara/cherry/internal/spec"
 	"github.com/moorara/cherry/pkg/semver"
+	"github.com/moorara/cherry/pkg/versioning"
 )
 
 const (
-	buildFlagErr = 301
-	buildOSErr   = 302
-	buildGitErr  = 303
-	buildGoErr   = 304
-	buildTimeout = 5 * time.Minute
+	buildFlagErr    = 301
+	buildOSErr      = 302
+	buildGitErr     = 303
+	buildGoErr      = 304
+	buildSemVerErr  = 305
+	buildSpecErr    = 306
+	buildArchiveErr = 307
+	buildTimeout    = 5 * time.Minute
 
 	buildSynopsis = `build artifacts`
 	buildHelp     = `
@@ -34,12 +41,17 @@ const (
 		-main-file:        path to main.go file                              (default: {{.Build.MainFile}})
 		-binary-file:      path for binary files                             (default: {{.Build.BinaryFile}})
 		-version-package:  relative path to package containing version info  (default: {{.Build.VersionPackage}})
+		-parallel:         maximum number of platforms built concurrently    (default: {{.Build.Parallel}})
+		-archive:          package the binaries in tar.gz or zip archives    (default: {{.Build.Archives.Enabled}})
+		-project:          name of a project in the spec file for building it instead of the repository
 
 	Examples:
 
 		cherry build
 		cherry build -cross-compile
+		cherry build -cross-compile -parallel 2
 		cherry -main-file cmd/my-app/main.go -binary-file build/my-app
+		cherry build -project api
 	`
 )
 
@@ -48,6 +60,8 @@ type buildCommand struct {
 	ui        cli.Ui
 	spec      spec.Spec
 	artifacts []string
+	// version, if set, is used instead of the semantic version resolved from git.
+	version *semver.SemVer
 }
 
 // NewBuildCommand creates a build command.
@@ -74,7 +88,10 @@ func (c *buildCommand) Help() string {
 
 // Run runs the actual command with the given command-line arguments.
 func (c *buildCommand) Run(args []string) int {
+	var projectName string
+
 	fs := c.spec.Build.FlagSet()
+	fs.StringVar(&projectName, "project", "", "")
 	fs.Usage = func() {
 		c.ui.Output(c.Help())
 	}
@@ -83,12 +100,19 @@ func (c *buildCommand) Run(args []string) int {
 		return buildFlagErr
 	}
 
+	if c.spec.Build.Parallel < 1 {
+		c.ui.Error(fmt.Sprintf("Invalid value %d for -parallel: must be a positive number.", c.spec.Build.Parallel))
+		return buildFlagErr
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), buildTimeout)
 	defer cancel()
 
 	// Run preflight checks
 
 	var dir string
+	var project spec.Project
+	var repo versioning.Repository
 
 	{
 		// c.ui.Output("◉ Running preflight checks ...")
@@ -99,163 +123,63 @@ func (c *buildCommand) Run(args []string) int {
 			c.ui.Error(fmt.Sprintf("Error on getting the current working directory: %s", err))
 			return buildOSErr
 		}
-	}
 
-	{
-		var stdout, stderr bytes.Buffer
-		cmd := exec.CommandContext(ctx, "git", "version")
-		cmd.Dir = dir
-		cmd.Stdout = &stdout
-		cmd.Stderr = &stderr
-		if err := cmd.Run(); err != nil {
-			c.ui.Error(fmt.Sprintf("Error on checking git: %s %s", err, strings.Trim(stderr.String(), "\n")))
-			return buildGitErr
+		// A project is built in its own directory and versioned by its own tags
+		if projectName != "" {
+			var ok bool
+			if project, ok = c.spec.Project(projectName); !ok {
+				c.ui.Error(fmt.Sprintf("Project %s not found in the spec file.", projectName))
+				return buildSpecErr
+			}
+			dir = filepath.Join(dir, project.Path)
 		}
 
-		stdout.Reset()
-		stderr.Reset()
-		cmd = exec.CommandContext(ctx, "go", "version")
-		cmd.Dir = dir
-		cmd.Stdout = &stdout
-		cmd.Stderr = &stderr
-		if err := cmd.Run(); err != nil {
-			c.ui.Error(fmt.Sprintf("Error on checking go: %s %s", err, strings.Trim(stderr.String(), "\n")))
-			return buildGoErr
+		// The .git directory is read directly if the git binary is not available
+		repo, err = versioning.Open(dir)
+		if err != nil {
+			c.ui.Error(fmt.Sprintf("Error on opening git repository: %s", err))
+			return buildGitErr
 		}
 	}
 
-	// Get git information
+	// Check go and get the compiler information
 
-	var gitSHA, gitShortSHA, gitBranch string
+	var goVersion string
 
 	{
 		var stdout, stderr bytes.Buffer
-		cmd := exec.CommandContext(ctx, "git", "rev-parse", "HEAD")
-		cmd.Dir = dir
-		cmd.Stdout = &stdout
-		cmd.Stderr = &stderr
-		if err := cmd.Run(); err != nil {
-			c.ui.Error(fmt.Sprintf("Error on running git rev-parse HEAD: %s %s", err, strings.Trim(stderr.String(), "\n")))
-			return buildGitErr
-		}
-		gitSHA = strings.Trim(stdout.String(), "\n")
-		gitShortSHA = gitSHA[:7]
-
-		stdout.Reset()
-		stderr.Reset()
-		cmd = exec.CommandContext(ctx, "git", "rev-parse", "--abbrev-ref", "HEAD")
+		cmd := exec.CommandContext(ctx, "go", "version")
 		cmd.Dir = dir
 		cmd.Stdout = &stdout
 		cmd.Stderr = &stderr
 		if err := cmd.Run(); err != nil {
-			c.ui.Error(fmt.Sprintf("Error on running git rev-parse --abbrev-ref HEAD: %s %s", err, strings.Trim(stderr.String(), "\n")))
-			return buildGitErr
+			c.ui.Error(fmt.Sprintf("Error on checking go: %s %s", err, strings.Trim(stderr.String(), "\n")))
+			return buildGoErr
 		}
-		gitBranch = strings.Trim(stdout.String(), "\n")
+		goVersion = regexp.MustCompile(`go\d+\.\d+(\.\d+)?`).FindString(stdout.String())
 	}
 
 	// Resolve the current semantic version
 
 	var version semver.SemVer
+	var info versioning.Info
 
 	{
-		var stdout, stderr bytes.Buffer
-		cmd := exec.CommandContext(ctx, "git", "status", "--porcelain")
-		cmd.Dir = dir
-		cmd.Stdout = &stdout
-		cmd.Stderr = &stderr
-		if err := cmd.Run(); err != nil {
-			c.ui.Error(fmt.Sprintf("Error on running git status --porcelain: %s %s", err, strings.Trim(stderr.String(), "\n")))
-			return buildGitErr
-		}
-		gitStatusClean := len(stdout.String()) == 0
-
-		stdout.Reset()
-		stderr.Reset()
-		cmd = exec.CommandContext(ctx, "git", "rev-list", "--count", "HEAD")
-		cmd.Dir = dir
-		cmd.Stdout = &stdout
-		cmd.Stderr = &stderr
-		if err := cmd.Run(); err != nil {
-			c.ui.Error(fmt.Sprintf("Error on running git rev-list --count HEAD: %s %s", err, strings.Trim(stderr.String(), "\n")))
-			return buildGitErr
-		}
-		gitCommitCount := strings.Trim(stdout.String(), "\n")
-
-		stdout.Reset()
-		stderr.Reset()
-		cmd = exec.CommandContext(ctx, "git", "describe", "--tags", "HEAD")
-		cmd.Dir = dir
-		cmd.Stdout = &stdout
-		cmd.Stderr = &stderr
-		if err := cmd.Run(); err != nil {
-			// 128 is returned when there is no git tag
-			if exiterr, ok := err.(*exec.ExitError); !ok || exiterr.ExitCode() != 128 {
-				c.ui.Error(fmt.Sprintf("Error on running git describe --tags HEAD: %s %s", err, strings.Trim(stderr.String(), "\n")))
-				return buildGitErr
+		var err error
+		version, info, err = versioning.Resolve(ctx, repo, versionOptions(c.spec, project))
+		if err != nil {
+			c.ui.Error(fmt.Sprintf("Error on resolving semantic version: %s", err))
+			if errors.Is(err, versioning.ErrInvalidTag) {
+				return buildSemVerErr
 			}
+			return buildGitErr
 		}
-		gitDescribe := strings.Trim(stdout.String(), "\n")
-
-		releaseRE := regexp.MustCompile(`^v?([0-9]+)\.([0-9]+)\.([0-9]+)$`)
-		prereleaseRE := regexp.MustCompile(`^(v?([0-9]+)\.([0-9]+)\.([0-9]+))-([0-9]+)-g([0-9a-f]+)$`)
-
-		if len(gitDescribe) == 0 {
-			// No git tag and no previous semantic version -> using the default initial semantic version
-
-			version = semver.SemVer{
-				Major: 0, Minor: 1, Patch: 0,
-				Prerelease: []string{gitCommitCount},
-			}
 
-			if gitStatusClean {
-				version.AddPrerelease(gitShortSHA)
-			} else {
-				version.AddPrerelease("dev")
-			}
-		} else if subs := releaseRE.FindStringSubmatch(gitDescribe); len(subs) == 4 {
-			// The tag points to the HEAD commit
-			// Example: v0.2.7 --> subs = []string{"v0.2.7", "0", "2", "7"}
-
-			version, _ = semver.Parse(subs[0])
-
-			if !gitStatusClean {
-				version = version.Next()
-				version.AddPrerelease("0", "dev")
-			}
-		} else if subs := prereleaseRE.FindStringSubmatch(gitDescribe); len(subs) == 7 {
-			// The tag is the most recent tag reachable from the HEAD commit
-			// Example: v0.2.7-10-gabcdeff --> subs = []string{"v0.2.7-10-gabcdeff", "v0.2.7", "0", "2", "7", "10", "abcdeff"}
-
-			version, _ = semver.Parse(subs[1])
-			version = version.Next()
-			version.AddPrerelease(subs[5])
-
-			if gitStatusClean {
-				version.AddPrerelease(subs[6])
-			} else {
-				version.AddPrerelease("dev")
-			}
+		if c.version != nil {
+			version = *c.version
 		}
 	}
 
-	// Get go compiler information
-
-	var goVersion string
-
-	{
-		var stdout, stderr bytes.Buffer
-		cmd := exec.CommandContext(ctx, "go", "version")
-		cmd.Dir = dir
-		cmd.Stdout = &stdout
-		cmd.Stderr = &stderr
-		if err := cmd.Run(); err != nil {
-			c.ui.Error(fmt.Sprintf("Error on running go version: %s %s", err, strings.Trim(stderr.String(), "\n")))
-			return buildGoErr
-		}
-		goVersion = regexp.MustCompile(`go\d+\.\d+(\.\d+)?`).FindString(stdout.String())
-	}
-
 	// Resolve the full import path to the version package
 
 	var versionPkg string
@@ -285,56 +209,226 @@ func (c *buildCommand) Run(args []string) int {
 		}
 
 		versionFlag := fmt.Sprintf("-X %s.Version=%s", versionPkg, version)
-		commitFlag := fmt.Sprintf("-X %s.Commit=%s", versionPkg, gitShortSHA)
-		branchFlag := fmt.Sprintf("-X %s.Branch=%s", versionPkg, gitBranch)
+		commitFlag := fmt.Sprintf("-X %s.Commit=%s", versionPkg, info.ShortCommit())
+		branchFlag := fmt.Sprintf("-X %s.Branch=%s", versionPkg, info.Branch)
 		goVersionFlag := fmt.Sprintf("-X %s.GoVersion=%s", versionPkg, goVersion)
 		buildToolFlag := fmt.Sprintf("-X %s.BuildTool=%s", versionPkg, buildTool)
 		buildTimeFlag := fmt.Sprintf("-X %s.BuildTime=%s", versionPkg, buildTime)
 		ldFlags = fmt.Sprintf("%s %s %s %s %s %s", versionFlag, commitFlag, branchFlag, goVersionFlag, buildToolFlag, buildTimeFlag)
+
+		// The public key is embedded for verifying the signatures of release artifacts (i.e. for self-updating)
+		if c.spec.Release.Signing.PublicKey != "" {
+			key, err := minisign.ParsePublicKey(c.spec.Release.Signing.PublicKey)
+			if err != nil {
+				c.ui.Error(fmt.Sprintf("Error on parsing public key: %s", err))
+				return buildSpecErr
+			}
+			ldFlags += fmt.Sprintf(" -X %s.PublicKey=%s", versionPkg, key)
+		}
 	}
 
-	// Build binaries
+	// Resolve the target platforms and their binary files
+
+	var platforms []spec.Platform
+	var binFiles []string
+
 	{
 		if !c.spec.Build.CrossCompile {
-			err := c.build(ctx, dir, ldFlags, c.spec.Build.BinaryFile)
-			if err != nil {
-				c.ui.Error(fmt.Sprintf("Error on building binary: %s", err))
+			// The binary is built for the target platform of the go compiler
+			var stdout, stderr bytes.Buffer
+			cmd := exec.CommandContext(ctx, "go", "env", "GOOS", "GOARCH")
+			cmd.Dir = dir
+			cmd.Stdout = &stdout
+			cmd.Stderr = &stderr
+			if err := cmd.Run(); err != nil {
+				c.ui.Error(fmt.Sprintf("Error on running go env: %s %s", err, strings.Trim(stderr.String(), "\n")))
 				return buildGoErr
 			}
 
-			c.artifacts = append(c.artifacts, c.spec.Build.BinaryFile)
+			vals := strings.Fields(stdout.String())
+			if len(vals) != 2 {
+				c.ui.Error(fmt.Sprintf("Error on running go env: unexpected output %q", stdout.String()))
+				return buildGoErr
+			}
+			platforms = []spec.Platform{{OS: vals[0], Arch: vals[1]}}
 		} else {
-			// Cross-compiling
-			for _, platform := range c.spec.Build.Platforms {
-				vals := strings.Split(platform, "-")
-				if err := os.Setenv("GOOS", vals[0]); err != nil {
-					c.ui.Error(fmt.Sprintf("Error on setting environment variable GOOS: %s", err))
-					return buildOSErr
+			var err error
+			platforms, err = c.spec.Build.ParsePlatforms()
+			if err != nil {
+				c.ui.Error(fmt.Sprintf("Error on parsing platforms: %s", err))
+				return buildSpecErr
+			}
+
+			var stdout, stderr bytes.Buffer
+			cmd := exec.CommandContext(ctx, "go", "tool", "dist", "list")
+			cmd.Dir = dir
+			cmd.Stdout = &stdout
+			cmd.Stderr = &stderr
+			if err := cmd.Run(); err != nil {
+				c.ui.Error(fmt.Sprintf("Error on running go tool dist list: %s %s", err, strings.Trim(stderr.String(), "\n")))
+				return buildGoErr
+			}
+
+			distList := strings.Fields(stdout.String())
+			for _, platform := range platforms {
+				if !platform.SupportedBy(distList) {
+					c.ui.Error(fmt.Sprintf("Platform %s is not supported by %s.", platform, goVersion))
+					return buildSpecErr
+				}
+			}
+		}
+
+		seen := map[string]spec.Platform{}
+		for _, platform := range platforms {
+			binFile, err := c.spec.Build.BinaryPath(platform)
+			if err != nil {
+				c.ui.Error(fmt.Sprintf("Error on resolving binary file: %s", err))
+				return buildSpecErr
+			}
+
+			if other, ok := seen[binFile]; ok {
+				c.ui.Error(fmt.Sprintf("Binary file %s is the same for platforms %s and %s.", binFile, other, platform))
+				return buildSpecErr
+			}
+
+			seen[binFile] = platform
+			binFiles = append(binFiles, binFile)
+		}
+	}
+
+	// Build binaries
+	// When cross-compiling, platforms are built concurrently
+	// and GOOS and GOARCH are set for each go build command, so the environment of the process is not changed
+
+	{
+		type result struct {
+			index  int
+			output string
+			err    error
+		}
+
+		// Builds in progress are cancelled on the first failure
+		buildCtx, cancelBuild := context.WithCancel(ctx)
+		defer cancelBuild()
+
+		doneCh := make(chan result, len(platforms))
+		slotCh := make(chan struct{}, c.spec.Build.Parallel)
+
+		for i, platform := range platforms {
+			go func(i int, platform spec.Platform) {
+				select {
+				case slotCh <- struct{}{}:
+					defer func() { <-slotCh }()
+				case <-buildCtx.Done():
+					doneCh <- result{i, "", buildCtx.Err()}
+					return
 				}
-				if err := os.Setenv("GOARCH", vals[1]); err != nil {
-					c.ui.Error(fmt.Sprintf("Error on setting environment variable GOARCH: %s", err))
-					return buildOSErr
+
+				var env []string
+				if c.spec.Build.CrossCompile {
+					env = platform.Env()
 				}
 
-				binFile := fmt.Sprintf("%s-%s", c.spec.Build.BinaryFile, platform)
-				err := c.build(ctx, dir, ldFlags, binFile)
-				if err != nil {
-					c.ui.Error(fmt.Sprintf("Error on building binary: %s", err))
-					return buildGoErr
+				output, err := c.build(buildCtx, dir, ldFlags, binFiles[i], env...)
+				doneCh <- result{i, output, err}
+			}(i, platform)
+		}
+
+		// The output of each build is reported once the build is finished
+		var buildErr error
+		for range platforms {
+			r := <-doneCh
+
+			if r.err != nil {
+				// The builds failing after the first failure are cancelled
+				if buildErr == nil {
+					buildErr = r.err
+					cancelBuild()
+					c.ui.Error(fmt.Sprintf("Error on building binary for %s: %s %s", platforms[r.index], r.err, r.output))
 				}
+				continue
+			}
+
+			c.ui.Info(fmt.Sprintf("🍒 %s", filepath.Join(project.Path, binFiles[r.index])))
+			if r.output != "" {
+				c.ui.Output(r.output)
+			}
+		}
+
+		if buildErr != nil {
+			return buildGoErr
+		}
+	}
+
+	// Package binaries in archives
+
+	if !c.spec.Build.Archives.Enabled {
+		// The artifacts are kept in the same order as the platforms
+		for _, binFile := range binFiles {
+			c.artifacts = append(c.artifacts, filepath.Join(project.Path, binFile))
+		}
+	} else {
+		mtime, err := archiveTime()
+		if err != nil {
+			c.ui.Error(fmt.Sprintf("Error on creating archives: %s", err))
+			return buildArchiveErr
+		}
+
+		files, err := archiveFiles(dir, c.spec.Build.Archives.Files)
+		if err != nil {
+			c.ui.Error(fmt.Sprintf("Error on finding files for archives: %s", err))
+			return buildArchiveErr
+		}
 
-				c.artifacts = append(c.artifacts, binFile)
+		for i, platform := range platforms {
+			archive, err := c.archive(dir, platform, binFiles[i], version.String(), files, mtime)
+			if err != nil {
+				c.ui.Error(fmt.Sprintf("Error on creating archive for %s: %s", platform, err))
+				return buildArchiveErr
 			}
 
-			os.Unsetenv("GOOS")
-			os.Unsetenv("GOARCH")
+			artifact := filepath.Join(project.Path, archive)
+			c.artifacts = append(c.artifacts, artifact)
+			c.ui.Info(fmt.Sprintf("📦 %s", artifact))
 		}
 	}
 
 	return 0
 }
 
-func (c *buildCommand) build(ctx context.Context, dir, ldFlags, binFile string) error {
+// archive packages a binary built for a platform with additional files and returns the path of the archive.
+// The archive is created next to the binary and the binary is named without the platform in it
+// (i.e. bin/app-linux-amd64 or bin/app_{{.OS}}_{{.Arch}}{{.Ext}} --> app).
+func (c *buildCommand) archive(dir string, platform spec.Platform, binFile, version string, files []archiveEntry, mtime time.Time) (string, error) {
+	binName := c.spec.Build.BinaryName(platform)
+	name := strings.TrimSuffix(binName, platform.Ext())
+
+	archiveName, err := c.spec.Build.Archives.ArchiveName(name, version, platform)
+	if err != nil {
+		return "", err
+	}
+
+	entries := []archiveEntry{
+		{name: binName, path: filepath.Join(dir, binFile), mode: 0755},
+	}
+
+	for _, f := range files {
+		if f.name != binName {
+			entries = append(entries, f)
+		}
+	}
+
+	archive := filepath.Join(filepath.Dir(binFile), archiveName)
+	format := c.spec.Build.Archives.ArchiveFormat(platform)
+	if err := createArchive(filepath.Join(dir, archive), format, entries, mtime); err != nil {
+		return "", err
+	}
+
+	return archive, nil
+}
+
+// build runs go build with additional environment variables (i.e. GOOS=linux) and returns the combined output of the command.
+func (c *buildCommand) build(ctx context.Context, dir, ldFlags, binFile string, env ...string) (string, error) {
 	args := []string{"build"}
 	if ldFlags != "" {
 		args = append(args, "-ldflags", ldFlags)
@@ -344,16 +438,16 @@ func (c *buildCommand) build(ctx context.Context, dir, ldFlags, binFile string)
 	}
 	args = append(args, c.spec.Build.MainFile)
 
-	var stdout, stderr bytes.Buffer
+	var output bytes.Buffer
 	cmd := exec.CommandContext(ctx, "go", args...)
 	cmd.Dir = dir
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("%s %s", err, strings.Trim(stderr.String(), "\n"))
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
 	}
 
-	c.ui.Info(fmt.Sprintf("🍒 %s", binFile))
+	err := cmd.Run()
 
-	return nil
+	return strings.Trim(output.String(), "\n"), err
 }
@@ -0,0 +1,253 @@
+package command
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/cli"
+	"github.com/moorara/cherry/internal/changelog"
+	"github.com/moorara/cherry/internal/git"
+	"github.com/moorara/cherry/internal/spec"
+	"github.com/moorara/cherry/pkg/semver"
+)
+
+const (
+	changelogFlagErr   = 601
+	changelogOSErr     = 602
+	changelogGitErr    = 603
+	changelogGitHubErr = 604
+	changelogTimeout   = 30 * time.Second
+
+	changelogSynopsis = `preview change log`
+	changelogHelp     = `
+	Use this command for previewing the change log generated from the git history.
+	Commits following Conventional Commits are grouped by their types.
+	If the change log source is github in the spec file, merged pull requests and closed issues are grouped by their labels.
+	For the github source, CHERRY_GITHUB_TOKEN environment variable is used if set.
+
+	Flags:
+
+		-all:  generate the change log for all releases  (default: false)
+
+	Examples:
+
+		cherry changelog
+		cherry changelog -all
+	`
+)
+
+// changelogCommand implements cli.Command interface.
+type changelogCommand struct {
+	ui   cli.Ui
+	spec spec.Spec
+}
+
+// NewChangelogCommand creates a changelog command.
+func NewChangelogCommand(ui cli.Ui, s spec.Spec) (cli.Command, error) {
+	return &changelogCommand{
+		ui:   ui,
+		spec: s,
+	}, nil
+}
+
+// Synopsis returns a short one-line synopsis of the command.
+func (c *changelogCommand) Synopsis() string {
+	return changelogSynopsis
+}
+
+// Help returns a long help text including usage, description, and list of flags for the command.
+func (c *changelogCommand) Help() string {
+	return changelogHelp
+}
+
+// Run runs the actual command with the given command-line arguments.
+func (c *changelogCommand) Run(args []string) int {
+	var all bool
+
+	fs := flag.NewFlagSet("changelog", flag.ContinueOnError)
+	fs.BoolVar(&all, "all", false, "")
+	fs.Usage = func() {
+		c.ui.Output(c.Help())
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return changelogFlagErr
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), changelogTimeout)
+	defer cancel()
+
+	// Run preflight checks
+
+	var dir string
+
+	{
+		var err error
+		dir, err = os.Getwd()
+		if err != nil {
+			c.ui.Error(fmt.Sprintf("Error on getting the current working directory: %s", err))
+			return changelogOSErr
+		}
+
+		if _, err := git.Run(ctx, dir, "version"); err != nil {
+			c.ui.Error(fmt.Sprintf("Error on checking git: %s", err))
+			return changelogGitErr
+		}
+	}
+
+	// Get the repository web url for linking releases and commits
+
+	g := &changelog.Generator{}
+	var repoDomain, repoOwner, repoName string
+
+	if url, err := git.Run(ctx, dir, "remote", "get-url", "--push", "origin"); err == nil {
+		var ok bool
+		if repoDomain, repoOwner, repoName, ok = parseGitRemoteURL(url); ok {
+			g.RepoURL = fmt.Sprintf("https://%s/%s/%s", repoDomain, repoOwner, repoName)
+		}
+	}
+
+	github := c.spec.Release.Changelog.Source == "github"
+	if github && repoOwner == "" {
+		c.ui.Error("Error on getting the GitHub repository: invalid git remote url")
+		return changelogGitErr
+	}
+
+	// Get all release tags reachable from HEAD sorted from the newest to the oldest
+
+	type tag struct {
+		name    string
+		version semver.SemVer
+	}
+
+	var tags []tag
+
+	{
+		out, err := git.Run(ctx, dir, "tag", "--merged", "HEAD")
+		if err != nil {
+			c.ui.Error(fmt.Sprintf("Error on listing git tags: %s", err))
+			return changelogGitErr
+		}
+
+		for _, name := range strings.Fields(out) {
+			if v, err := semver.Parse(name); err == nil {
+				tags = append(tags, tag{name: name, version: v})
+			}
+		}
+
+		sort.Slice(tags, func(i, j int) bool {
+			return tags[i].version.GreaterThan(tags[j].version)
+		})
+	}
+
+	// Generate the change log
+
+	var releases []changelog.Release
+
+	{
+		var lastTag string
+		if len(tags) > 0 {
+			lastTag = tags[0].name
+		}
+
+		releases = append(releases, changelog.Release{
+			PreviousTag: lastTag,
+		})
+
+		if all {
+			for i, t := range tags {
+				var previousTag string
+				if i+1 < len(tags) {
+					previousTag = tags[i+1].name
+				}
+
+				date, err := gitTagDate(ctx, dir, t.name)
+				if err != nil {
+					c.ui.Error(fmt.Sprintf("Error on getting git tag date: %s", err))
+					return changelogGitErr
+				}
+
+				releases = append(releases, changelog.Release{
+					Tag:         t.name,
+					PreviousTag: previousTag,
+					Date:        date,
+				})
+			}
+		}
+
+		if github {
+			// Fetch the pull requests and issues once and assign each one to the release it belongs to
+			var since time.Time
+			if !all && lastTag != "" {
+				var err error
+				since, err = gitTagDate(ctx, dir, lastTag)
+				if err != nil {
+					c.ui.Error(fmt.Sprintf("Error on getting git tag date: %s", err))
+					return changelogGitErr
+				}
+			}
+
+			client, err := newHTTPClient(c.spec.Release.CAFile)
+			if err != nil {
+				c.ui.Error(fmt.Sprintf("Error on loading certificate authorities: %s", err))
+				return changelogOSErr
+			}
+
+			apiURL, _ := githubURLs(repoDomain, c.spec.Release.GitHub)
+
+			source := &changelog.GitHubSource{
+				Client:        client,
+				APIURL:        apiURL,
+				Token:         os.Getenv("CHERRY_GITHUB_TOKEN"),
+				Owner:         repoOwner,
+				Repo:          repoName,
+				ExcludeLabels: c.spec.Release.Changelog.ExcludeLabels,
+			}
+
+			items, err := source.Items(ctx, since, time.Time{})
+			if err != nil {
+				c.ui.Error(fmt.Sprintf("Error on getting GitHub pull requests and issues: %s", err))
+				return changelogGitHubErr
+			}
+
+			for _, item := range items {
+				// Releases are sorted from the newest to the oldest, so the oldest release closed after the item is the one
+				i := 0
+				for j := len(releases) - 1; j > 0; j-- {
+					if !item.ClosedAt.After(releases[j].Date) {
+						i = j
+						break
+					}
+				}
+				releases[i].Items = append(releases[i].Items, item)
+			}
+		} else {
+			for i := range releases {
+				to := releases[i].Tag
+				if to == "" {
+					to = "HEAD"
+				}
+
+				commits, err := gitCommits(ctx, dir, releases[i].PreviousTag, to)
+				if err != nil {
+					c.ui.Error(fmt.Sprintf("Error on getting git commits: %s", err))
+					return changelogGitErr
+				}
+				releases[i].Commits = filterReleaseCommits(commits)
+			}
+		}
+	}
+
+	if all {
+		c.ui.Output(g.Document(releases))
+	} else {
+		c.ui.Output(g.Section(releases[0]))
+	}
+
+	return 0
+}
@@ -0,0 +1,147 @@
+package command
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	checksumsFile       = "checksums.txt"
+	checksumsSHA512File = "checksums-sha512.txt"
+)
+
+// writeChecksums writes the checksums of files in the format of sha256sum and sha512sum commands (<hex>  <name>).
+// The files are named by their base names, so the checksums can be verified in the directory the files are downloaded to.
+func writeChecksums(path string, newHash func() hash.Hash, files []string) error {
+	var buf bytes.Buffer
+
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+
+		h := newHash()
+		_, err = io.Copy(h, f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(&buf, "%x  %s\n", h.Sum(nil), filepath.Base(file))
+	}
+
+	return ioutil.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// writeArtifactChecksums writes the checksums files for artifacts in their common directory and returns the paths of the checksums files.
+// The SHA-256 checksums are always written and the SHA-512 checksums are written in a separate file if requested.
+func writeArtifactChecksums(artifacts []string, withSHA512 bool) ([]string, error) {
+	dir := commonDir(artifacts)
+
+	path := filepath.Join(dir, checksumsFile)
+	if err := writeChecksums(path, sha256.New, artifacts); err != nil {
+		return nil, err
+	}
+	files := []string{path}
+
+	if withSHA512 {
+		path := filepath.Join(dir, checksumsSHA512File)
+		if err := writeChecksums(path, sha512.New, artifacts); err != nil {
+			return nil, err
+		}
+		files = append(files, path)
+	}
+
+	return files, nil
+}
+
+// parseChecksums parses checksums in the format of sha256sum and sha512sum commands and returns the checksums by file names.
+func parseChecksums(data []byte) (map[string]string, error) {
+	checksums := map[string]string{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+
+		// Example: <hex>  app (text mode) or <hex> *app (binary mode)
+		i := strings.Index(line, " ")
+		if i < 0 || i+2 > len(line) || (line[i+1] != ' ' && line[i+1] != '*') {
+			return nil, fmt.Errorf("invalid checksum line %q", line)
+		}
+
+		sum, name := line[:i], line[i+2:]
+		if _, err := hex.DecodeString(sum); err != nil || (len(sum) != 2*sha256.Size && len(sum) != 2*sha512.Size) {
+			return nil, fmt.Errorf("invalid checksum %q for %s", sum, name)
+		}
+
+		checksums[name] = strings.ToLower(sum)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return checksums, nil
+}
+
+// verifyChecksum verifies the content of a file against its SHA-256 or SHA-512 checksum.
+func verifyChecksum(checksums map[string]string, name string, data []byte) error {
+	sum, ok := checksums[name]
+	if !ok {
+		return fmt.Errorf("no checksum found for %s", name)
+	}
+
+	var actual string
+	if len(sum) == 2*sha512.Size {
+		h := sha512.Sum512(data)
+		actual = hex.EncodeToString(h[:])
+	} else {
+		h := sha256.Sum256(data)
+		actual = hex.EncodeToString(h[:])
+	}
+
+	if actual != sum {
+		return fmt.Errorf("checksum mismatch for %s: expected %s, got %s", name, sum, actual)
+	}
+
+	return nil
+}
+
+// commonDir returns the deepest directory containing all of the given files.
+func commonDir(files []string) string {
+	if len(files) == 0 {
+		return "."
+	}
+
+	dir := filepath.Dir(files[0])
+	for _, file := range files[1:] {
+		for {
+			rel, err := filepath.Rel(dir, file)
+			if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				break
+			}
+
+			parent := filepath.Dir(dir)
+			if parent == dir {
+				break
+			}
+			dir = parent
+		}
+	}
+
+	return dir
+}
@@ -0,0 +1,219 @@
+package command
+
+import (
+	"crypto/sha256"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	// echo -n "hello" | sha256sum
+	helloSHA256 = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+	// echo -n "hello" | sha512sum
+	helloSHA512 = "9b71d224bd62f3785d96d46ad3ea3d73319bfbc2890caadae2dff72519673ca72323c3d99ba5c11d7c7acc6e14b8c5da0c4663475c2e5c3adef46f73bcdec043"
+)
+
+func TestWriteArtifactChecksums(t *testing.T) {
+	tests := []struct {
+		name          string
+		withSHA512    bool
+		expectedFiles []string
+		expectedSums  map[string]string
+	}{
+		{
+			name:          "SHA256",
+			withSHA512:    false,
+			expectedFiles: []string{"bin/checksums.txt"},
+			expectedSums: map[string]string{
+				"bin/checksums.txt": helloSHA256 + "  app-linux-amd64\n" + helloSHA256 + "  app-windows-amd64.exe\n",
+			},
+		},
+		{
+			name:          "SHA512",
+			withSHA512:    true,
+			expectedFiles: []string{"bin/checksums.txt", "bin/checksums-sha512.txt"},
+			expectedSums: map[string]string{
+				"bin/checksums.txt":        helloSHA256 + "  app-linux-amd64\n" + helloSHA256 + "  app-windows-amd64.exe\n",
+				"bin/checksums-sha512.txt": helloSHA512 + "  app-linux-amd64\n" + helloSHA512 + "  app-windows-amd64.exe\n",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "cherry-")
+			assert.NoError(t, err)
+			defer os.RemoveAll(dir)
+
+			artifacts := []string{
+				filepath.Join(dir, "bin", "app-linux-amd64"),
+				filepath.Join(dir, "bin", "app-windows-amd64.exe"),
+			}
+
+			assert.NoError(t, os.Mkdir(filepath.Join(dir, "bin"), 0755))
+			for _, artifact := range artifacts {
+				assert.NoError(t, ioutil.WriteFile(artifact, []byte("hello"), 0755))
+			}
+
+			files, err := writeArtifactChecksums(artifacts, tc.withSHA512)
+			assert.NoError(t, err)
+
+			expectedFiles := []string{}
+			for _, f := range tc.expectedFiles {
+				expectedFiles = append(expectedFiles, filepath.Join(dir, filepath.FromSlash(f)))
+			}
+			assert.Equal(t, expectedFiles, files)
+
+			for f, expectedSums := range tc.expectedSums {
+				data, err := ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(f)))
+				assert.NoError(t, err)
+				assert.Equal(t, expectedSums, string(data))
+			}
+		})
+	}
+
+	t.Run("MissingArtifact", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "cherry-")
+		assert.NoError(t, err)
+		defer os.RemoveAll(dir)
+
+		err = writeChecksums(filepath.Join(dir, checksumsFile), sha256.New, []string{filepath.Join(dir, "app")})
+		assert.Error(t, err)
+	})
+}
+
+func TestParseChecksums(t *testing.T) {
+	tests := []struct {
+		name              string
+		data              string
+		expectedChecksums map[string]string
+		expectedError     string
+	}{
+		{
+			name:              "Empty",
+			data:              "",
+			expectedChecksums: map[string]string{},
+		},
+		{
+			name: "TextAndBinaryModes",
+			data: helloSHA256 + "  app-linux-amd64\n" + helloSHA512 + " *app-windows-amd64.exe\n\n",
+			expectedChecksums: map[string]string{
+				"app-linux-amd64":       helloSHA256,
+				"app-windows-amd64.exe": helloSHA512,
+			},
+		},
+		{
+			name:          "NoName",
+			data:          helloSHA256 + "\n",
+			expectedError: "invalid checksum line",
+		},
+		{
+			name:          "InvalidSeparator",
+			data:          helloSHA256 + " app\n",
+			expectedError: "invalid checksum line",
+		},
+		{
+			name:          "InvalidHex",
+			data:          "xyz  app\n",
+			expectedError: `invalid checksum "xyz" for app`,
+		},
+		{
+			name:          "InvalidLength",
+			data:          helloSHA256[:40] + "  app\n",
+			expectedError: "invalid checksum",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			checksums, err := parseChecksums([]byte(tc.data))
+
+			if tc.expectedError != "" {
+				assert.Contains(t, err.Error(), tc.expectedError)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedChecksums, checksums)
+			}
+		})
+	}
+}
+
+func TestVerifyChecksum(t *testing.T) {
+	checksums := map[string]string{
+		"app-linux-amd64":       helloSHA256,
+		"app-windows-amd64.exe": helloSHA512,
+	}
+
+	tests := []struct {
+		name          string
+		fileName      string
+		data          string
+		expectedError string
+	}{
+		{
+			name:     "SHA256",
+			fileName: "app-linux-amd64",
+			data:     "hello",
+		},
+		{
+			name:     "SHA512",
+			fileName: "app-windows-amd64.exe",
+			data:     "hello",
+		},
+		{
+			name:          "Mismatch",
+			fileName:      "app-linux-amd64",
+			data:          "hello!",
+			expectedError: "checksum mismatch for app-linux-amd64",
+		},
+		{
+			name:          "NotFound",
+			fileName:      "app-darwin-amd64",
+			data:          "hello",
+			expectedError: "no checksum found for app-darwin-amd64",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := verifyChecksum(checksums, tc.fileName, []byte(tc.data))
+
+			if tc.expectedError != "" {
+				assert.Contains(t, err.Error(), tc.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCommonDir(t *testing.T) {
+	tests := []struct {
+		name        string
+		files       []string
+		expectedDir string
+	}{
+		{"None", nil, "."},
+		{"One", []string{"bin/app"}, "bin"},
+		{"SameDir", []string{"bin/app-linux-amd64", "bin/app-darwin-amd64"}, "bin"},
+		{"NestedDirs", []string{"bin/linux/app", "bin/darwin/app", "bin/app.exe"}, "bin"},
+		{"DifferentDirs", []string{"bin/app", "out/app"}, "."},
+		{"SimilarNames", []string{"bin/app", "binary/app"}, "."},
+		{"Project", []string{"services/api/bin/api-linux-amd64", "services/api/bin/api-darwin-amd64"}, "services/api/bin"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var files []string
+			for _, f := range tc.files {
+				files = append(files, filepath.FromSlash(f))
+			}
+
+			assert.Equal(t, filepath.FromSlash(tc.expectedDir), commonDir(files))
+		})
+	}
+}
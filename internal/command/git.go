@@ -0,0 +1,176 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/moorara/cherry/internal/changelog"
+	"github.com/moorara/cherry/internal/git"
+	"github.com/moorara/cherry/internal/spec"
+	"github.com/moorara/cherry/pkg/versioning"
+)
+
+var (
+	// Example: git@github.com:moorara/cherry.git --> subs = []string{"git@github.com:moorara/cherry.git", "github.com", "moorara", "cherry", ".git"}
+	gitSSHRemoteRE = regexp.MustCompile(`^git@((?:[A-Za-z0-9][0-9A-Za-z-]*\.)+[A-Za-z]{2,}):([A-Za-z][0-9A-Za-z-]+[0-9A-Za-z])/([A-Za-z][0-9A-Za-z-]+[0-9A-Za-z])(.git)?$`)
+	// Example: https://github.com/moorara/cherry.git --> subs = []string{"https://github.com/moorara/cherry.git", "github.com", "moorara", "cherry", ".git"}
+	// Example: https://gitea.example.com:3000/moorara/cherry --> subs = []string{"https://gitea.example.com:3000/moorara/cherry", "gitea.example.com:3000", "moorara", "cherry", ""}
+	gitHTTPSRemoteRE = regexp.MustCompile(`^https://((?:[A-Za-z0-9][0-9A-Za-z-]*\.)+[A-Za-z]{2,}(?::[0-9]+)?)/([A-Za-z][0-9A-Za-z-]+[0-9A-Za-z])/([A-Za-z][0-9A-Za-z-]+[0-9A-Za-z])(.git)?$`)
+)
+
+// Example: release/1.4 --> subs = []string{"release/1.4", "1", "4"}
+var maintenanceBranchRE = regexp.MustCompile(`^release/(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)$`)
+
+// formatCommand returns a command with its arguments as it would be typed in a shell.
+func formatCommand(name string, args []string) string {
+	parts := []string{name}
+	for _, arg := range args {
+		if arg == "" || strings.ContainsAny(arg, " \t\n\"'") {
+			arg = strconv.Quote(arg)
+		}
+		parts = append(parts, arg)
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// versionOptions returns the options for resolving the semantic version of a project from its version tags (i.e. services/api/v1.2.3).
+// For the repository itself (zero project), the version tags of all projects are excluded.
+func versionOptions(s spec.Spec, project spec.Project) versioning.Options {
+	opts := versioning.Options{
+		TagPrefix: project.TagPrefix,
+	}
+
+	if project.TagPrefix == "" {
+		for _, p := range s.Projects {
+			opts.Exclude = append(opts.Exclude, p.TagPrefix+"*")
+		}
+	}
+
+	return opts
+}
+
+// maintenanceVersionOptions returns the options for resolving the semantic version of a project
+// only from the version tags in a release line (i.e. v1.4.* or 1.4.* after the tag prefix of the project).
+func maintenanceVersionOptions(s spec.Spec, project spec.Project, major, minor uint) versioning.Options {
+	opts := versionOptions(s, project)
+	opts.Match = []string{
+		fmt.Sprintf("v%d.%d.*", major, minor),
+		fmt.Sprintf("%d.%d.*", major, minor),
+	}
+
+	return opts
+}
+
+// parseGitRemoteURL returns the domain, owner, and name of a repository from a git remote url.
+func parseGitRemoteURL(url string) (string, string, string, bool) {
+	if subs := gitSSHRemoteRE.FindStringSubmatch(url); len(subs) == 4 || len(subs) == 5 {
+		// Git remote url is using SSH protocol
+		return subs[1], subs[2], subs[3], true
+	} else if subs := gitHTTPSRemoteRE.FindStringSubmatch(url); len(subs) == 4 || len(subs) == 5 {
+		// Git remote url is using HTTPS protocol
+		return subs[1], subs[2], subs[3], true
+	}
+
+	return "", "", "", false
+}
+
+// parseMaintenanceBranch returns the major and minor versions of the release line for a maintenance branch (i.e. release/1.4).
+func parseMaintenanceBranch(branch string) (uint, uint, bool) {
+	subs := maintenanceBranchRE.FindStringSubmatch(branch)
+	if len(subs) != 3 {
+		return 0, 0, false
+	}
+
+	major, _ := strconv.ParseUint(subs[1], 10, 64)
+	minor, _ := strconv.ParseUint(subs[2], 10, 64)
+
+	return uint(major), uint(minor), true
+}
+
+// gitCommits returns the non-merge commits reachable from revision to and not reachable from revision from.
+// If from is empty, all commits reachable from revision to are returned.
+// If paths are given, only the commits changing the paths are returned.
+// The commits are sorted from the newest to the oldest.
+func gitCommits(ctx context.Context, dir, from, to string, paths ...string) ([]changelog.Commit, error) {
+	revRange := to
+	if from != "" {
+		revRange = from + ".." + to
+	}
+
+	args := []string{"log", "--no-merges", "--format=%H%x00%B%x1e", revRange}
+	if len(paths) > 0 {
+		args = append(append(args, "--"), paths...)
+	}
+
+	out, err := git.Run(ctx, dir, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	commits := []changelog.Commit{}
+	for _, entry := range strings.Split(out, "\x1e") {
+		entry = strings.TrimLeft(entry, "\n")
+		if entry == "" {
+			continue
+		}
+
+		// Example: <sha>\x00<message> --> vals = []string{"<sha>", "<message>"}
+		if vals := strings.SplitN(entry, "\x00", 2); len(vals) == 2 {
+			commits = append(commits, changelog.Commit{
+				Hash:    vals[0],
+				Message: strings.TrimSpace(vals[1]),
+			})
+		}
+	}
+
+	return commits, nil
+}
+
+// gitTagDate returns the commit date of a git tag.
+func gitTagDate(ctx context.Context, dir, tag string) (time.Time, error) {
+	out, err := git.Run(ctx, dir, "log", "-1", "--format=%cI", tag)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Parse(time.RFC3339, out)
+}
+
+// gitDefaultBranch returns the default branch of the origin remote repository.
+func gitDefaultBranch(ctx context.Context, dir string) (string, error) {
+	// The HEAD of origin is known locally if the repository is cloned
+	if out, err := git.Run(ctx, dir, "symbolic-ref", "--short", "refs/remotes/origin/HEAD"); err == nil {
+		return strings.TrimPrefix(out, "origin/"), nil
+	}
+
+	// Example: ref: refs/heads/main\tHEAD
+	out, err := git.Run(ctx, dir, "ls-remote", "--symref", "origin", "HEAD")
+	if err != nil {
+		return "", err
+	}
+
+	for _, line := range strings.Split(out, "\n") {
+		if fields := strings.Fields(line); len(fields) == 3 && fields[0] == "ref:" && fields[2] == "HEAD" {
+			return strings.TrimPrefix(fields[1], "refs/heads/"), nil
+		}
+	}
+
+	return "", fmt.Errorf("no default branch found for origin")
+}
+
+// filterReleaseCommits removes the commits created by the release command.
+func filterReleaseCommits(commits []changelog.Commit) []changelog.Commit {
+	filtered := []changelog.Commit{}
+	for _, commit := range commits {
+		if !strings.HasPrefix(commit.Message, "Releasing ") || strings.Contains(commit.Message, "\n") {
+			filtered = append(filtered, commit)
+		}
+	}
+
+	return filtered
+}
@@ -0,0 +1,141 @@
+package command
+
+import (
+	"testing"
+
+	"github.com/moorara/cherry/internal/spec"
+	"github.com/moorara/cherry/pkg/versioning"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMaintenanceBranch(t *testing.T) {
+	tests := []struct {
+		name          string
+		branch        string
+		expectedMajor uint
+		expectedMinor uint
+		expectedOK    bool
+	}{
+		{
+			name:       "Main",
+			branch:     "main",
+			expectedOK: false,
+		},
+		{
+			name:          "ReleaseLine",
+			branch:        "release/1.4",
+			expectedMajor: 1,
+			expectedMinor: 4,
+			expectedOK:    true,
+		},
+		{
+			name:          "ZeroMajor",
+			branch:        "release/0.12",
+			expectedMajor: 0,
+			expectedMinor: 12,
+			expectedOK:    true,
+		},
+		{
+			name:       "PatchVersion",
+			branch:     "release/1.4.7",
+			expectedOK: false,
+		},
+		{
+			name:       "LeadingZero",
+			branch:     "release/01.4",
+			expectedOK: false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			major, minor, ok := parseMaintenanceBranch(tc.branch)
+
+			assert.Equal(t, tc.expectedOK, ok)
+			assert.Equal(t, tc.expectedMajor, major)
+			assert.Equal(t, tc.expectedMinor, minor)
+		})
+	}
+}
+
+func TestVersionOptions(t *testing.T) {
+	s := spec.Spec{
+		Projects: []spec.Project{
+			{Name: "api", Path: "services/api", TagPrefix: "services/api/"},
+			{Name: "cli", Path: "tools/cli", TagPrefix: "cli-"},
+		},
+	}
+
+	tests := []struct {
+		name         string
+		spec         spec.Spec
+		project      spec.Project
+		expectedOpts versioning.Options
+	}{
+		{
+			name:         "NoProject",
+			spec:         spec.Spec{},
+			project:      spec.Project{},
+			expectedOpts: versioning.Options{},
+		},
+		{
+			name:    "Repository",
+			spec:    s,
+			project: spec.Project{},
+			expectedOpts: versioning.Options{
+				Exclude: []string{"services/api/*", "cli-*"},
+			},
+		},
+		{
+			name:    "Project",
+			spec:    s,
+			project: s.Projects[0],
+			expectedOpts: versioning.Options{
+				TagPrefix: "services/api/",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedOpts, versionOptions(tc.spec, tc.project))
+		})
+	}
+}
+
+func TestMaintenanceVersionOptions(t *testing.T) {
+	s := spec.Spec{
+		Projects: []spec.Project{
+			{Name: "api", Path: "services/api", TagPrefix: "services/api/"},
+		},
+	}
+
+	tests := []struct {
+		name         string
+		project      spec.Project
+		expectedOpts versioning.Options
+	}{
+		{
+			name:    "Repository",
+			project: spec.Project{},
+			expectedOpts: versioning.Options{
+				Match:   []string{"v1.4.*", "1.4.*"},
+				Exclude: []string{"services/api/*"},
+			},
+		},
+		{
+			name:    "Project",
+			project: s.Projects[0],
+			expectedOpts: versioning.Options{
+				TagPrefix: "services/api/",
+				Match:     []string{"v1.4.*", "1.4.*"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedOpts, maintenanceVersionOptions(s, tc.project, 1, 4))
+		})
+	}
+}
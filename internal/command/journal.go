@@ -0,0 +1,47 @@
+package command
+
+import "context"
+
+// journal records the completed steps of a release with the compensating actions for undoing them.
+type journal struct {
+	entries []journalEntry
+}
+
+type journalEntry struct {
+	action string
+	undo   func(context.Context) error
+}
+
+// add records the compensating action for undoing a completed step.
+// action describes what undo does (i.e. delete tag v0.1.0).
+func (j *journal) add(action string, undo func(context.Context) error) {
+	j.entries = append(j.entries, journalEntry{
+		action: action,
+		undo:   undo,
+	})
+}
+
+// len returns the number of steps recorded.
+func (j *journal) len() int {
+	return len(j.entries)
+}
+
+// rollback undoes the recorded steps in reverse order.
+// Undoing continues when a step cannot be undone and report is called for every action with its error, if any.
+// It returns the number of steps that could not be undone.
+func (j *journal) rollback(ctx context.Context, report func(action string, err error)) int {
+	var failed int
+
+	for i := len(j.entries) - 1; i >= 0; i-- {
+		e := j.entries[i]
+		err := e.undo(ctx)
+		if err != nil {
+			failed++
+		}
+		report(e.action, err)
+	}
+
+	j.entries = nil
+
+	return failed
+}
@@ -0,0 +1,47 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJournalRollback(t *testing.T) {
+	var undone []string
+
+	undo := func(action string, err error) func(context.Context) error {
+		return func(context.Context) error {
+			undone = append(undone, action)
+			return err
+		}
+	}
+
+	j := &journal{}
+	j.add("delete draft release", undo("delete draft release", nil))
+	j.add("delete release tag", undo("delete release tag", errors.New("tag not found")))
+	j.add("reset release commit", undo("reset release commit", nil))
+	assert.Equal(t, 3, j.len())
+
+	var reported []string
+	failed := j.rollback(context.Background(), func(action string, err error) {
+		if err != nil {
+			action += ": " + err.Error()
+		}
+		reported = append(reported, action)
+	})
+
+	assert.Equal(t, 1, failed)
+	assert.Equal(t, 0, j.len())
+	assert.Equal(t, []string{
+		"reset release commit",
+		"delete release tag",
+		"delete draft release",
+	}, undone)
+	assert.Equal(t, []string{
+		"reset release commit",
+		"delete release tag: tag not found",
+		"delete draft release",
+	}, reported)
+}
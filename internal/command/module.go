@@ -0,0 +1,171 @@
+package command
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	// Example: module github.com/moorara/cherry/v2 --> subs = []string{"module github.com/moorara/cherry/v2", "github.com/moorara/cherry/v2"}
+	goModModuleRE = regexp.MustCompile(`(?m)^module\s+"?([^"\s]+)"?\s*(?://.*)?$`)
+	// Example: github.com/moorara/cherry/v2 --> subs = []string{"/v2", "2"}
+	majorSuffixRE = regexp.MustCompile(`/v([2-9]|[1-9][0-9]+)$`)
+)
+
+// readModulePath returns the module path declared in the go.mod file of a directory.
+// If there is no go.mod file, the returned error satisfies os.IsNotExist.
+func readModulePath(dir string) (string, error) {
+	data, err := ioutil.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return "", err
+	}
+
+	subs := goModModuleRE.FindSubmatch(data)
+	if len(subs) != 2 {
+		return "", errors.New("no module directive found in go.mod")
+	}
+
+	return string(subs[1]), nil
+}
+
+// modulePathForMajor returns the module path required by Go for a major version.
+// Major versions 2 and higher should have a /vN suffix while major versions 0 and 1 should not have any.
+// gopkg.in module paths have their own convention, so they are returned unchanged.
+func modulePathForMajor(modulePath string, major uint) string {
+	if strings.HasPrefix(modulePath, "gopkg.in/") {
+		return modulePath
+	}
+
+	base := majorSuffixRE.ReplaceAllString(modulePath, "")
+	if major < 2 {
+		return base
+	}
+
+	return fmt.Sprintf("%s/v%d", base, major)
+}
+
+// rewriteModulePath changes the module path in the go.mod file of a directory
+// and the import paths of the packages in the module in all Go files of the module.
+// It returns the paths of the files changed relative to the directory.
+// If it fails after changing some files, the paths of the files changed so far are returned with the error.
+func rewriteModulePath(dir, oldPath, newPath string) ([]string, error) {
+	changed := []string{}
+
+	goModPath := filepath.Join(dir, "go.mod")
+	data, err := ioutil.ReadFile(goModPath)
+	if err != nil {
+		return nil, err
+	}
+
+	loc := goModModuleRE.FindSubmatchIndex(data)
+	if len(loc) != 4 {
+		return nil, errors.New("no module directive found in go.mod")
+	}
+
+	data = append(data[:loc[2]:loc[2]], append([]byte(newPath), data[loc[3]:]...)...)
+	if err := ioutil.WriteFile(goModPath, data, 0644); err != nil {
+		return nil, err
+	}
+	changed = append(changed, "go.mod")
+
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			// Vendored packages and nested modules are not part of the module
+			if path != dir && (info.Name() == "vendor" || info.Name() == "testdata" || strings.HasPrefix(info.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			if path != dir {
+				if _, err := os.Stat(filepath.Join(path, "go.mod")); err == nil {
+					return filepath.SkipDir
+				}
+			}
+			return nil
+		}
+
+		if filepath.Ext(path) != ".go" {
+			return nil
+		}
+
+		ok, err := rewriteImports(path, oldPath, newPath)
+		if err != nil {
+			return err
+		}
+
+		if ok {
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+			changed = append(changed, rel)
+		}
+
+		return nil
+	})
+
+	sort.Strings(changed)
+
+	return changed, err
+}
+
+// rewriteImports changes the import paths of the packages in a module in a Go file.
+// It returns true if the file is changed.
+func rewriteImports(path, oldPath, newPath string) (bool, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, path, data, parser.ImportsOnly)
+	if err != nil {
+		return false, err
+	}
+
+	var buf bytes.Buffer
+	var last int
+
+	for _, imp := range f.Imports {
+		importPath, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			return false, err
+		}
+
+		if importPath != oldPath && !strings.HasPrefix(importPath, oldPath+"/") {
+			continue
+		}
+
+		// A package of a newer major version is not a package of this module (i.e. github.com/moorara/cherry/v2/pkg for github.com/moorara/cherry)
+		if rest := strings.TrimPrefix(importPath, oldPath); majorSuffixRE.MatchString("/" + strings.SplitN(rest+"/", "/", 3)[1]) {
+			continue
+		}
+
+		start := fset.Position(imp.Path.Pos()).Offset
+		end := fset.Position(imp.Path.End()).Offset
+
+		buf.Write(data[last:start])
+		buf.WriteString(strconv.Quote(newPath + strings.TrimPrefix(importPath, oldPath)))
+		last = end
+	}
+
+	if last == 0 {
+		return false, nil
+	}
+
+	buf.Write(data[last:])
+
+	return true, ioutil.WriteFile(path, buf.Bytes(), 0644)
+}
@@ -0,0 +1,178 @@
+package command
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadModulePath(t *testing.T) {
+	tests := []struct {
+		name          string
+		goMod         string
+		expectedPath  string
+		expectedError string
+	}{
+		{
+			name:         "Simple",
+			goMod:        "module github.com/moorara/cherry\n\ngo 1.15\n",
+			expectedPath: "github.com/moorara/cherry",
+		},
+		{
+			name:         "Quoted",
+			goMod:        "// comment\nmodule \"github.com/moorara/cherry/v2\"\n",
+			expectedPath: "github.com/moorara/cherry/v2",
+		},
+		{
+			name:         "WithComment",
+			goMod:        "module github.com/moorara/cherry // cherry\n",
+			expectedPath: "github.com/moorara/cherry",
+		},
+		{
+			name:          "NoModule",
+			goMod:         "go 1.15\n",
+			expectedError: "no module directive found in go.mod",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "cherry-")
+			assert.NoError(t, err)
+			defer os.RemoveAll(dir)
+
+			err = ioutil.WriteFile(filepath.Join(dir, "go.mod"), []byte(tc.goMod), 0644)
+			assert.NoError(t, err)
+
+			path, err := readModulePath(dir)
+
+			if tc.expectedError != "" {
+				assert.EqualError(t, err, tc.expectedError)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedPath, path)
+			}
+		})
+	}
+}
+
+func TestModulePathForMajor(t *testing.T) {
+	tests := []struct {
+		name         string
+		modulePath   string
+		major        uint
+		expectedPath string
+	}{
+		{"V0", "github.com/moorara/cherry", 0, "github.com/moorara/cherry"},
+		{"V1", "github.com/moorara/cherry", 1, "github.com/moorara/cherry"},
+		{"V1ToV2", "github.com/moorara/cherry", 2, "github.com/moorara/cherry/v2"},
+		{"V2", "github.com/moorara/cherry/v2", 2, "github.com/moorara/cherry/v2"},
+		{"V2ToV3", "github.com/moorara/cherry/v2", 3, "github.com/moorara/cherry/v3"},
+		{"V2ToV1", "github.com/moorara/cherry/v2", 1, "github.com/moorara/cherry"},
+		{"V10", "github.com/moorara/cherry/v9", 10, "github.com/moorara/cherry/v10"},
+		{"NotMajorSuffix", "github.com/moorara/v1", 2, "github.com/moorara/v1/v2"},
+		{"GopkgIn", "gopkg.in/yaml.v2", 3, "gopkg.in/yaml.v2"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedPath, modulePathForMajor(tc.modulePath, tc.major))
+		})
+	}
+}
+
+func TestRewriteModulePath(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cherry-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"go.mod": "module github.com/octocat/app\n\ngo 1.15\n\nrequire github.com/octocat/app/v3 v3.0.0\n",
+		"main.go": `package main
+
+import (
+	"fmt"
+
+	"github.com/octocat/app/internal/cmd"
+	v3 "github.com/octocat/app/v3/pkg"
+	"github.com/octocat/application"
+)
+
+func main() {
+	fmt.Println("github.com/octocat/app/internal/cmd")
+	cmd.Run(v3.X, application.Y)
+}
+`,
+		"internal/cmd/cmd.go":     "package cmd\n\nimport \"github.com/octocat/app\"\n",
+		"internal/cmd/doc.go":     "package cmd\n",
+		"vendor/lib/lib.go":       "package lib\n\nimport \"github.com/octocat/app/pkg\"\n",
+		"tools/go.mod":            "module github.com/octocat/app/tools\n",
+		"tools/tools.go":          "package tools\n\nimport \"github.com/octocat/app/pkg\"\n",
+		"internal/cmd/cmd.go.txt": "import \"github.com/octocat/app/pkg\"\n",
+	}
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+	}
+
+	changed, err := rewriteModulePath(dir, "github.com/octocat/app", "github.com/octocat/app/v2")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"go.mod", filepath.Join("internal", "cmd", "cmd.go"), "main.go"}, changed)
+
+	expected := map[string]string{
+		"go.mod": "module github.com/octocat/app/v2\n\ngo 1.15\n\nrequire github.com/octocat/app/v3 v3.0.0\n",
+		"main.go": `package main
+
+import (
+	"fmt"
+
+	"github.com/octocat/app/v2/internal/cmd"
+	v3 "github.com/octocat/app/v3/pkg"
+	"github.com/octocat/application"
+)
+
+func main() {
+	fmt.Println("github.com/octocat/app/internal/cmd")
+	cmd.Run(v3.X, application.Y)
+}
+`,
+		"internal/cmd/cmd.go": "package cmd\n\nimport \"github.com/octocat/app/v2\"\n",
+		"vendor/lib/lib.go":   "package lib\n\nimport \"github.com/octocat/app/pkg\"\n",
+		"tools/tools.go":      "package tools\n\nimport \"github.com/octocat/app/pkg\"\n",
+	}
+
+	for name, content := range expected {
+		data, err := ioutil.ReadFile(filepath.Join(dir, name))
+		assert.NoError(t, err)
+		assert.Equal(t, content, string(data), name)
+	}
+}
+
+func TestRewriteModulePathPartial(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cherry-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"go.mod":  "module github.com/octocat/app\n",
+		"a.go":    "package app\n\nimport \"github.com/octocat/app/pkg\"\n",
+		"z.go":    "package app\n\nimport (\n",
+		"zz/b.go": "package zz\n\nimport \"github.com/octocat/app/pkg\"\n",
+	}
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+	}
+
+	// The files changed before the invalid Go file are returned for restoring them
+	changed, err := rewriteModulePath(dir, "github.com/octocat/app", "github.com/octocat/app/v2")
+	assert.Error(t, err)
+	assert.Equal(t, []string{"a.go", "go.mod"}, changed)
+}
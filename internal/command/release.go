@@ -1,60 +1,100 @@
 package command
 
 import (
-	"bufio"
 	"bytes"
 	"context"
-	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
-	"io"
+	"io/ioutil"
 	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
-	"regexp"
 	"strings"
 	"time"
 
-	netURL "net/url"
-
 	"github.com/mitchellh/cli"
+	"github.com/moorara/cherry/internal/changelog"
+	"github.com/moorara/cherry/internal/git"
+	"github.com/moorara/cherry/internal/minisign"
+	"github.com/moorara/cherry/internal/provider"
 	"github.com/moorara/cherry/internal/spec"
+	"github.com/moorara/cherry/pkg/conventional"
 	"github.com/moorara/cherry/pkg/semver"
+	"github.com/moorara/cherry/pkg/versioning"
 )
 
 const (
-	releaseFlagErr       = 401
-	releaseOSErr         = 402
-	releaseGitErr        = 403
-	releaseGoErr         = 404
-	releaseChangelogErr  = 405
-	releaseGitHubErr     = 406
-	releaseGitHubPermErr = 407
-	releaseRemoteURLErr  = 408
-	releaseRemoteRepoErr = 409
-	releaseBranchErr     = 410
-	releaseStatusErr     = 411
-	releaseSemVerErr     = 412
-	releaseUploadErr     = 413
-	releaseTimeout       = 10 * time.Minute
+	releaseFlagErr         = 401
+	releaseOSErr           = 402
+	releaseGitErr          = 403
+	releaseGoErr           = 404
+	releaseChangelogErr    = 405
+	releaseProviderErr     = 406
+	releaseProviderPermErr = 407
+	releaseRemoteURLErr    = 408
+	releaseRemoteRepoErr   = 409
+	releaseBranchErr       = 410
+	releaseStatusErr       = 411
+	releaseSemVerErr       = 412
+	releaseUploadErr       = 413
+	releaseStateErr        = 414
+	releaseSpecErr         = 415
+	releaseModuleErr       = 416
+	releaseChecksumErr     = 417
+	releaseSigningErr      = 418
+	releaseTimeout         = 10 * time.Minute
+	releaseRollbackTimeout = 2 * time.Minute
 
 	releaseSynopsis = `create a new release`
 	releaseHelp     = `
 	Use this command for creating a new release.
 	This assumes your remote repository is named origin.
+	Releases can only be made from the branches matching release.branches in the spec file (i.e. main or release/*).
+	If not set, releases can only be made from the default branch of the remote repository.
+	On a maintenance branch (i.e. release/1.4), only patch releases of the same release line can be made.
 	The initial semantic version release is 0.1.0.
 
+	For a major version 2 or higher, Go requires the module path in go.mod to end in the major version (i.e. /v2).
+	A release is stopped if the module path does not match the major version being released.
+	Use -update-module flag for updating the module path and the import paths as part of the release commit.
+
+	The progress of a release is saved in .cherry/release-state.json until the release is completed.
+	If a release times out, it can be continued from the failed step using the -resume flag.
+	Otherwise, the completed steps of a failed release are rolled back.
+
 	Supported Remote Repositories:
 
-		- GitHub (github.com)
+		- GitHub (github.com)                requires CHERRY_GITHUB_TOKEN environment variable
+		- GitHub Enterprise Server           requires CHERRY_GITHUB_TOKEN environment variable
+		- GitLab (gitlab.com or self-hosted) requires CHERRY_GITLAB_TOKEN environment variable
+		- Gitea and Forgejo                  requires CHERRY_GITEA_TOKEN environment variable
+
+	The provider is detected from the domain of the remote repository (i.e. gitlab.example.com).
+	Otherwise, set release.provider in the spec file to github, gitlab, or gitea.
+
+	For GitHub Enterprise Server, the API is assumed to be at https://<host>/api/v3.
+	Otherwise, set release.github.api_url and release.github.upload_url in the spec file.
+	Set release.ca_file in the spec file for trusting a custom certificate authority.
+
+	When building the artifacts, the checksums of them are uploaded too.
+	The artifacts and checksums are signed with a minisign secret key if CHERRY_SIGNING_KEY environment variable
+	or release.signing.key_file in the spec file is set.
 
 	Flags:
 
-		-patch:    create a patch version release                       (default: true)
-		-minor:    create a minor version release                       (default: false)
-		-major:    create a major version release                       (default: false)
-		-comment:  add a comment for the release
-		-build:    build the artifacts and include them in the release  (default: false)
+		-patch:          create a patch version release                         (default: true)
+		-minor:          create a minor version release                         (default: false)
+		-major:          create a major version release                         (default: false)
+		-auto:           infer the release level from conventional commits      (default: false)
+		-prerelease:     create a pre-release with the given label (alpha, beta, rc, etc.)
+		-comment:        add a comment for the release
+		-build:          build the artifacts and include them in the release    (default: false)
+		-dry-run:        print the changes instead of making them               (default: false)
+		-resume:         continue the last release that did not complete        (default: false)
+		-project:        name of a project in the spec file for releasing it independently
+		-update-module:  update the module path in go.mod for the major version (default: false)
 
 	Examples:
 
@@ -64,7 +104,13 @@ const (
 		cherry release -minor -build
 		cherry release -major
 		cherry release -major -build
+		cherry release -major -update-module
+		cherry release -minor -prerelease rc
+		cherry release -auto
 		cherry release -comment "release comment"
+		cherry release -minor -build -dry-run
+		cherry release -resume
+		cherry release -project api
 	`
 )
 
@@ -94,14 +140,20 @@ func (c *releaseCommand) Help() string {
 
 // Run runs the actual command with the given command-line arguments.
 func (c *releaseCommand) Run(args []string) int {
-	var patch, minor, major bool
-	var comment string
+	var patch, minor, major, auto, dryRun, resume, updateModule bool
+	var prerelease, comment, projectName string
 
 	fs := c.spec.Release.FlagSet()
 	fs.BoolVar(&patch, "patch", true, "")
 	fs.BoolVar(&minor, "minor", false, "")
 	fs.BoolVar(&major, "major", false, "")
+	fs.BoolVar(&auto, "auto", false, "")
+	fs.StringVar(&prerelease, "prerelease", "", "")
 	fs.StringVar(&comment, "comment", "", "")
+	fs.BoolVar(&dryRun, "dry-run", false, "")
+	fs.BoolVar(&resume, "resume", false, "")
+	fs.StringVar(&projectName, "project", "", "")
+	fs.BoolVar(&updateModule, "update-module", false, "")
 	fs.Usage = func() {
 		c.ui.Output(c.Help())
 	}
@@ -110,6 +162,21 @@ func (c *releaseCommand) Run(args []string) int {
 		return releaseFlagErr
 	}
 
+	// The release level cannot be both inferred and set explicitly
+	if auto {
+		var levelFlags []string
+		fs.Visit(func(f *flag.Flag) {
+			if f.Name == "patch" || f.Name == "minor" || f.Name == "major" {
+				levelFlags = append(levelFlags, "-"+f.Name)
+			}
+		})
+
+		if len(levelFlags) > 0 {
+			c.ui.Error(fmt.Sprintf("The -auto flag cannot be used with %s flag.", strings.Join(levelFlags, ", ")))
+			return releaseFlagErr
+		}
+	}
+
 	var version semver.Version
 	switch {
 	case major:
@@ -125,17 +192,13 @@ func (c *releaseCommand) Run(args []string) int {
 	ctx, cancel := context.WithTimeout(context.Background(), releaseTimeout)
 	defer cancel()
 
-	transport := &http.Transport{}
-	client := &http.Client{
-		Transport: transport,
-	}
-
-	// Get remote repository information
-
 	// Run preflight checks
 
-	var dir, githubToken string
+	var dir, githubToken, githubAPIURL string
 	var repoOwner, repoName string
+	var client *http.Client
+	var signKey minisign.PrivateKey
+	var sign bool
 
 	{
 		c.ui.Output("◉ Running preflight checks ...")
@@ -147,198 +210,307 @@ func (c *releaseCommand) Run(args []string) int {
 			return releaseOSErr
 		}
 
-		githubToken = os.Getenv("CHERRY_GITHUB_TOKEN")
-		if githubToken == "" {
-			c.ui.Error("CHERRY_GITHUB_TOKEN environment variable not set.")
-			return releaseGitHubErr
+		client, err = newHTTPClient(c.spec.Release.CAFile)
+		if err != nil {
+			c.ui.Error(fmt.Sprintf("Error on loading certificate authorities: %s", err))
+			return releaseOSErr
+		}
+
+		// The artifacts are signed if a signing key is set
+		if c.spec.Release.Build {
+			signKey, sign, err = signingKey(c.spec.Release.Signing)
+			if err != nil {
+				c.ui.Error(fmt.Sprintf("Error on reading signing key: %s", err))
+				return releaseSigningErr
+			}
 		}
 	}
 
-	{
-		var stdout, stderr bytes.Buffer
-		cmd := exec.CommandContext(ctx, "git", "version")
-		cmd.Dir = dir
-		cmd.Stdout = &stdout
-		cmd.Stderr = &stderr
-		if err := cmd.Run(); err != nil {
-			c.ui.Error(fmt.Sprintf("Error on checking git: %s %s", err, strings.Trim(stderr.String(), "\n")))
-			return buildGitErr
+	// A project in a monorepo is versioned by its own tags (i.e. services/api/v1.2.3) and has its own change log
+
+	var project spec.Project
+	var projectPaths []string
+
+	if projectName != "" {
+		var ok bool
+		if project, ok = c.spec.Project(projectName); !ok {
+			c.ui.Error(fmt.Sprintf("Project %s not found in the spec file.", projectName))
+			return releaseSpecErr
 		}
+		projectPaths = []string{project.Path}
+	}
 
-		stdout.Reset()
-		stderr.Reset()
-		cmd = exec.CommandContext(ctx, "go", "version")
-		cmd.Dir = dir
-		cmd.Stdout = &stdout
-		cmd.Stderr = &stderr
-		if err := cmd.Run(); err != nil {
-			c.ui.Error(fmt.Sprintf("Error on checking go: %s %s", err, strings.Trim(stderr.String(), "\n")))
-			return releaseGoErr
+	// Load the progress of the last release if it did not complete
+
+	state := new(releaseState)
+
+	{
+		s, err := loadReleaseState(dir)
+		switch {
+		case err == nil && resume && s.Project != projectName:
+			c.ui.Error(fmt.Sprintf("The last release %s is for a different project. Use -project flag with the same project.", s.Version))
+			return releaseStateErr
+		case err == nil && resume:
+			state = s
+		case err == nil:
+			c.ui.Error(fmt.Sprintf("The last release %s did not complete. Use -resume flag to continue it or remove %s.", s.Version, releaseStateFile))
+			return releaseStateErr
+		case !os.IsNotExist(err):
+			c.ui.Error(fmt.Sprintf("Error on reading release state: %s", err))
+			return releaseStateErr
+		case resume:
+			c.ui.Error("There is no release to resume.")
+			return releaseStateErr
 		}
+	}
 
-		stdout.Reset()
-		stderr.Reset()
-		cmd = exec.CommandContext(ctx, "github_changelog_generator", "--version")
-		cmd.Dir = dir
-		cmd.Stdout = &stdout
-		cmd.Stderr = &stderr
-		if err := cmd.Run(); err != nil {
-			c.ui.Error(fmt.Sprintf("Error on checking github_changelog_generator: %s %s", err, strings.Trim(stderr.String(), "\n")))
-			return releaseChangelogErr
+	// complete records a completed step of the release for resuming it later
+	complete := func(step string) error {
+		if dryRun {
+			return nil
 		}
+
+		state.Steps = append(state.Steps, step)
+		return state.save(dir)
 	}
 
+	// A release changes the repository, so the git binary is required
+	repo := git.NewExecRepository(dir)
+
 	{
-		var repoDomain string
-		sshRE := regexp.MustCompile(`^git@([A-Za-z][0-9A-Za-z-]+[0-9A-Za-z]\.[A-Za-z]{2,}):([A-Za-z][0-9A-Za-z-]+[0-9A-Za-z])/([A-Za-z][0-9A-Za-z-]+[0-9A-Za-z])(.git)?$`)
-		httpsRE := regexp.MustCompile(`^https://([A-Za-z][0-9A-Za-z-]+[0-9A-Za-z]\.[A-Za-z]{2,})/([A-Za-z][0-9A-Za-z-]+[0-9A-Za-z])/([A-Za-z][0-9A-Za-z-]+[0-9A-Za-z])(.git)?$`)
+		if _, err := git.Run(ctx, dir, "version"); err != nil {
+			c.ui.Error(fmt.Sprintf("Error on checking git: %s", err))
+			return releaseGitErr
+		}
 
 		var stdout, stderr bytes.Buffer
-		cmd := exec.CommandContext(ctx, "git", "remote", "get-url", "--push", "origin")
+		cmd := exec.CommandContext(ctx, "go", "version")
 		cmd.Dir = dir
 		cmd.Stdout = &stdout
 		cmd.Stderr = &stderr
 		if err := cmd.Run(); err != nil {
-			c.ui.Error(fmt.Sprintf("Error on running git remote get-url --push origin: %s %s", err, strings.Trim(stderr.String(), "\n")))
+			c.ui.Error(fmt.Sprintf("Error on checking go: %s %s", err, strings.Trim(stderr.String(), "\n")))
+			return releaseGoErr
+		}
+	}
+
+	// Get remote repository information and the provider for releasing
+
+	var repoDomain string
+	var p provider.Provider
+
+	{
+		gitRemoteURL, err := git.Run(ctx, dir, "remote", "get-url", "--push", "origin")
+		if err != nil {
+			c.ui.Error(fmt.Sprintf("Error on getting the remote url: %s", err))
 			return releaseGitErr
 		}
-		gitRemoteURL := strings.Trim(stdout.String(), "\n")
-
-		if subs := sshRE.FindStringSubmatch(gitRemoteURL); len(subs) == 4 || len(subs) == 5 {
-			// Git remote url is using SSH protocol
-			// Example: git@github.com:moorara/cherry.git --> subs = []string{"git@github.com:moorara/cherry.git", "github.com", "moorara", "cherry", ".git"}
-			repoDomain = subs[1]
-			repoOwner, repoName = subs[2], subs[3]
-		} else if subs := httpsRE.FindStringSubmatch(gitRemoteURL); len(subs) == 4 || len(subs) == 5 {
-			// Git remote url is using HTTPS protocol
-			// Example: https://github.com/moorara/cherry.git --> subs = []string{"https://github.com/moorara/cherry.git", "github.com", "moorara", "cherry", ".git"}
-			repoDomain = subs[1]
-			repoOwner, repoName = subs[2], subs[3]
-		} else {
+
+		var ok bool
+		repoDomain, repoOwner, repoName, ok = parseGitRemoteURL(gitRemoteURL)
+		if !ok {
 			c.ui.Error(fmt.Sprintf("Invalid git remote url: %s", gitRemoteURL))
 			return releaseRemoteURLErr
 		}
 
-		if strings.ToLower(repoDomain) != "github.com" {
-			c.ui.Error(fmt.Sprintf("Unsupported remote repository: %s", repoDomain))
+		switch detectProvider(repoDomain, c.spec.Release) {
+		case "github":
+			githubToken = os.Getenv("CHERRY_GITHUB_TOKEN")
+			if githubToken == "" {
+				c.ui.Error("CHERRY_GITHUB_TOKEN environment variable not set.")
+				return releaseProviderErr
+			}
+
+			var githubUploadURL string
+			githubAPIURL, githubUploadURL = githubURLs(repoDomain, c.spec.Release.GitHub)
+
+			p = &provider.GitHub{
+				Client:    client,
+				APIURL:    githubAPIURL,
+				UploadURL: githubUploadURL,
+				Token:     githubToken,
+				Owner:     repoOwner,
+				Repo:      repoName,
+			}
+
+		case "gitlab":
+			gitlabToken := os.Getenv("CHERRY_GITLAB_TOKEN")
+			if gitlabToken == "" {
+				c.ui.Error("CHERRY_GITLAB_TOKEN environment variable not set.")
+				return releaseProviderErr
+			}
+
+			p = &provider.GitLab{
+				Client: client,
+				APIURL: fmt.Sprintf("https://%s/api/v4", repoDomain),
+				Token:  gitlabToken,
+				Owner:  repoOwner,
+				Repo:   repoName,
+			}
+
+		case "gitea":
+			giteaToken := os.Getenv("CHERRY_GITEA_TOKEN")
+			if giteaToken == "" {
+				c.ui.Error("CHERRY_GITEA_TOKEN environment variable not set.")
+				return releaseProviderErr
+			}
+
+			p = &provider.Gitea{
+				Client: client,
+				APIURL: fmt.Sprintf("https://%s/api/v1", repoDomain),
+				Token:  giteaToken,
+				Owner:  repoOwner,
+				Repo:   repoName,
+			}
+
+		default:
+			c.ui.Error(fmt.Sprintf("Unsupported remote repository: %s (set release.provider in the spec file)", repoDomain))
 			return releaseRemoteRepoErr
 		}
+
+		if c.spec.Release.Changelog.Source == "github" && p.Name() != "GitHub" {
+			c.ui.Error(fmt.Sprintf("The github change log source is not supported for %s repositories.", p.Name()))
+			return releaseChangelogErr
+		}
 	}
 
-	// Check GitHub permission
-	// See https://docs.github.com/en/rest/reference/repos#get-repository-permissions-for-a-user
+	// In dry-run mode, the changes to the local and remote repositories are recorded in a plan instead of being made
+
+	var plan []string
+	var released bool
+	var changelogText string
 
-	githubUser := struct {
-		ID    int    `json:"id"`
-		Login string `json:"login"`
-		Name  string `json:"name"`
-		Email string `json:"email"`
-		URL   string `json:"url"`
-	}{}
+	record := func(step string) {
+		plan = append(plan, step)
+	}
 
-	{
-		c.ui.Output("◉ Checking GitHub permission ...")
-
-		// Get the currently authenticated user
-		// See https://docs.github.com/en/rest/reference/users#get-the-authenticated-user
-		url := "https://api.github.com/user"
-		req, _ := http.NewRequest("GET", url, nil)
-		req = req.WithContext(ctx)
-		req.Header.Set("Authorization", "token "+githubToken)
-		req.Header.Set("Accept", "application/vnd.github.v3+json")
-		req.Header.Set("User-Agent", "cherry") // ref: https://docs.github.com/en/rest/overview/resources-in-the-rest-api#user-agent-required
-		req.Header.Set("Content-Type", "application/json")
-
-		res, err := client.Do(req)
-		if err != nil {
-			c.ui.Error(fmt.Sprintf("Error on getting authenticated GitHub user: %s", err))
-			return releaseGitHubErr
+	// run runs a git command changing the repository or records it in dry-run mode
+	run := func(args ...string) error {
+		if dryRun {
+			record(formatCommand("git", args))
+			return nil
 		}
-		defer res.Body.Close()
 
-		if res.StatusCode != 200 {
-			c.ui.Error(fmt.Sprintf("Error on getting authenticated GitHub user: invalid status code %d", res.StatusCode))
-			return releaseGitHubErr
-		}
+		_, err := git.Run(ctx, dir, args...)
+		return err
+	}
 
-		err = json.NewDecoder(res.Body).Decode(&githubUser)
-		if err != nil {
-			c.ui.Error(fmt.Sprintf("Error on getting authenticated GitHub user: %s", err))
-			return releaseGitHubErr
+	if dryRun {
+		p = &provider.DryRun{
+			Provider: p,
+			Record:   record,
 		}
 
-		// Check if the user has write admin access for creating release and pushing tag.
-		// See https://docs.github.com/en/rest/reference/repos#get-repository-permissions-for-a-user
-		url = fmt.Sprintf("https://api.github.com/repos/%s/%s/collaborators/%s/permission", repoOwner, repoName, githubUser.Login)
-		req, _ = http.NewRequest("GET", url, nil)
-		req = req.WithContext(ctx)
-		req.Header.Set("Authorization", "token "+githubToken)
-		req.Header.Set("Accept", "application/vnd.github.v3+json")
-		req.Header.Set("User-Agent", "cherry") // ref: https://docs.github.com/en/rest/overview/resources-in-the-rest-api#user-agent-required
-		req.Header.Set("Content-Type", "application/json")
+		// The plan is printed after all other deferred steps are recorded
+		defer func() {
+			if released {
+				c.printPlan(plan, changelogText)
+			}
+		}()
+	}
+
+	// If the release fails midway, the completed steps are undone in reverse order
 
-		res, err = client.Do(req)
-		if err != nil {
-			c.ui.Error(fmt.Sprintf("Error on checking GitHub user permission: %s", err))
-			return releaseGitHubErr
+	var commitPushed, tagPushed bool
+	j := new(journal)
+
+	defer func() {
+		if released || dryRun || j.len() == 0 {
+			return
 		}
-		defer res.Body.Close()
 
-		if res.StatusCode != 200 {
-			c.ui.Error(fmt.Sprintf("Error on checking GitHub user permission: invalid status code %d", res.StatusCode))
-			return releaseGitHubErr
+		// A release timed out is not rolled back, so it can be resumed
+		if resume || errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			c.ui.Warn(fmt.Sprintf("⏸  The release progress is saved in %s. Use -resume flag to continue it.", releaseStateFile))
+			return
 		}
 
-		githubPermission := struct {
-			Permission string `json:"permission"`
-		}{}
+		c.ui.Warn("↩️  Rolling back the release ...")
 
-		err = json.NewDecoder(res.Body).Decode(&githubPermission)
-		if err != nil {
-			c.ui.Error(fmt.Sprintf("Error on checking GitHub user permission: %s", err))
-			return releaseGitHubErr
+		// The context for releasing may be already expired
+		ctx, cancel := context.WithTimeout(context.Background(), releaseRollbackTimeout)
+		defer cancel()
+
+		failed := j.rollback(ctx, func(action string, err error) {
+			if err != nil {
+				c.ui.Error(fmt.Sprintf("    ✗ Could not %s: %s", action, err))
+			} else {
+				c.ui.Output(fmt.Sprintf("    ✓ %s", action))
+			}
+		})
+
+		if failed > 0 {
+			c.ui.Error(fmt.Sprintf("%d step(s) could not be undone and should be cleaned up manually.", failed))
+		}
+
+		if err := removeReleaseState(dir); err != nil {
+			c.ui.Error(fmt.Sprintf("Error on removing release state: %s", err))
 		}
+	}()
 
-		if githubPermission.Permission != "admin" {
-			c.ui.Error("The CHERRY_GITHUB_TOKEN does not have admin permission for releasing.")
-			return releaseGitHubPermErr
+	// Check the user permission for releasing
+
+	{
+		c.ui.Output(fmt.Sprintf("◉ Checking %s permission ...", p.Name()))
+
+		if err := p.CheckPermission(ctx); err != nil {
+			if errors.Is(err, provider.ErrPermission) {
+				c.ui.Error(fmt.Sprintf("The %s token does not have enough permission for releasing: %s", p.Name(), err))
+				return releaseProviderPermErr
+			}
+
+			c.ui.Error(fmt.Sprintf("Error on checking %s permission: %s", p.Name(), err))
+			return releaseProviderErr
 		}
 	}
 
-	// Make sure the active git branch is the master branch
+	// Make sure the active git branch is a release branch
 
 	var gitBranch string
 
+	// A maintenance branch (i.e. release/1.4) is only for releasing a major.minor release line
+	var maintenance bool
+	var lineMajor, lineMinor uint
+
 	{
-		var stdout, stderr bytes.Buffer
-		cmd := exec.CommandContext(ctx, "git", "rev-parse", "--abbrev-ref", "HEAD")
-		cmd.Dir = dir
-		cmd.Stdout = &stdout
-		cmd.Stderr = &stderr
-		if err := cmd.Run(); err != nil {
-			c.ui.Error(fmt.Sprintf("Error on running git rev-parse --abbrev-ref HEAD: %s %s", err, strings.Trim(stderr.String(), "\n")))
+		var err error
+		gitBranch, err = repo.Branch(ctx)
+		if err != nil {
+			c.ui.Error(fmt.Sprintf("Error on getting the current branch: %s", err))
 			return releaseGitErr
 		}
-		gitBranch = strings.Trim(stdout.String(), "\n")
 
-		if gitBranch != "master" {
-			c.ui.Error("Release can only be done from master branch.")
+		// If no release branch is specified, the default branch of the remote repository is the only release branch
+		releaseSpec := c.spec.Release
+		if len(releaseSpec.Branches) == 0 {
+			defaultBranch, err := gitDefaultBranch(ctx, dir)
+			if err != nil {
+				c.ui.Error(fmt.Sprintf("Error on getting the default branch: %s", err))
+				return releaseGitErr
+			}
+			releaseSpec.Branches = []string{defaultBranch}
+		}
+
+		if !releaseSpec.MatchBranch(gitBranch) {
+			c.ui.Error(fmt.Sprintf("Release can only be done from %s branch(es).", strings.Join(releaseSpec.Branches, ", ")))
+			return releaseBranchErr
+		}
+
+		lineMajor, lineMinor, maintenance = parseMaintenanceBranch(gitBranch)
+
+		if resume && gitBranch != state.Branch {
+			c.ui.Error(fmt.Sprintf("Release %s can only be resumed from %s branch.", state.Version, state.Branch))
 			return releaseBranchErr
 		}
 	}
 
 	// Make sure there is no uncommitted change and the current branch is clean
-	{
-		var stdout, stderr bytes.Buffer
-		cmd := exec.CommandContext(ctx, "git", "status", "--porcelain")
-		cmd.Dir = dir
-		cmd.Stdout = &stdout
-		cmd.Stderr = &stderr
-		if err := cmd.Run(); err != nil {
-			c.ui.Error(fmt.Sprintf("Error on running git status --porcelain: %s %s", err, strings.Trim(stderr.String(), "\n")))
+	if !resume {
+		gitStatusClean, err := repo.IsClean(ctx)
+		if err != nil {
+			c.ui.Error(fmt.Sprintf("Error on getting the status of the working directory: %s", err))
 			return releaseGitErr
 		}
-		gitStatusClean := len(stdout.String()) == 0
 
 		if !gitStatusClean {
 			c.ui.Error("Working directory is not clean and has uncommitted changes.")
@@ -347,16 +519,11 @@ func (c *releaseCommand) Run(args []string) int {
 	}
 
 	// Make sure the current branch has all the latest changes
-	{
-		c.ui.Output("⬇️  Pulling the latest changes on master branch ...")
+	if !resume {
+		c.ui.Output(fmt.Sprintf("⬇️  Pulling the latest changes on %s branch ...", gitBranch))
 
-		var stdout, stderr bytes.Buffer
-		cmd := exec.CommandContext(ctx, "git", "pull")
-		cmd.Dir = dir
-		cmd.Stdout = &stdout
-		cmd.Stderr = &stderr
-		if err := cmd.Run(); err != nil {
-			c.ui.Error(fmt.Sprintf("Error on running git pull: %s %s", err, strings.Trim(stderr.String(), "\n")))
+		if err := run("pull"); err != nil {
+			c.ui.Error(fmt.Sprintf("Error on pulling the latest changes: %s", err))
 			return releaseGitErr
 		}
 	}
@@ -364,215 +531,387 @@ func (c *releaseCommand) Run(args []string) int {
 	// Resolve the semantic version being released
 
 	var releaseSemVer semver.SemVer
-	var releaseTag string
+	var lastTag, releaseTag string
 
-	{
-		var stdout, stderr bytes.Buffer
-		cmd := exec.CommandContext(ctx, "git", "describe", "--tags", "HEAD")
-		cmd.Dir = dir
-		cmd.Stdout = &stdout
-		cmd.Stderr = &stderr
-		if err := cmd.Run(); err != nil {
-			// 128 is returned when there is no git tag
-			if exiterr, ok := err.(*exec.ExitError); !ok || exiterr.ExitCode() != 128 {
-				c.ui.Error(fmt.Sprintf("Error on running git describe --tags HEAD: %s %s", err, strings.Trim(stderr.String(), "\n")))
-				return releaseGitErr
+	if resume {
+		var err error
+		releaseSemVer, err = semver.Parse(state.Version)
+		if err != nil {
+			c.ui.Error(fmt.Sprintf("Invalid semantic version in release state: %s", err))
+			return releaseStateErr
+		}
+
+		lastTag, releaseTag = state.LastTag, state.Tag
+		changelogText = state.Changelog
+		if comment == "" {
+			comment = state.Comment
+		}
+
+		c.ui.Output(fmt.Sprintf("◉ Resuming release %s ...", releaseSemVer))
+	} else {
+		// For a project, only the tags with the project prefix are considered
+		// On a maintenance branch, only the tags in the same release line are considered
+		opts := versionOptions(c.spec, project)
+		if maintenance {
+			opts = maintenanceVersionOptions(c.spec, project, lineMajor, lineMinor)
+		}
+
+		// The last release is resolved the same way as the current semantic version by semver and build commands
+		vrepo, err := versioning.Open(dir)
+		if err != nil {
+			c.ui.Error(fmt.Sprintf("Error on opening git repository: %s", err))
+			return releaseGitErr
+		}
+
+		_, info, err := versioning.Resolve(ctx, vrepo, opts)
+		if err != nil {
+			c.ui.Error(fmt.Sprintf("Error on resolving the last release: %s", err))
+			if errors.Is(err, versioning.ErrInvalidTag) {
+				return releaseSemVerErr
 			}
+			return releaseGitErr
 		}
-		gitDescribe := strings.Trim(stdout.String(), "\n")
+		lastTag = info.Tag
 
-		if len(gitDescribe) == 0 {
+		if len(lastTag) == 0 {
 			// No git tag found -> using the default initial semantic version for the first release
 			releaseSemVer = semver.SemVer{Major: 0, Minor: 1, Patch: 0}
+			if maintenance {
+				releaseSemVer = semver.SemVer{Major: lineMajor, Minor: lineMinor, Patch: 0}
+			}
+			if prerelease != "" {
+				releaseSemVer.AddPrerelease(prerelease, "1")
+			}
 		} else {
-			lastSemVer, ok := semver.Parse(gitDescribe)
-			if !ok {
-				c.ui.Error(fmt.Sprintf("Invalid git tag for semantic version: %s", gitDescribe))
+			lastSemVer, err := semver.Parse(strings.TrimPrefix(lastTag, project.TagPrefix))
+			if err != nil {
+				c.ui.Error(fmt.Sprintf("Invalid git tag for semantic version: %s", err))
 				return releaseSemVerErr
 			}
-			releaseSemVer = lastSemVer.Next().Release(version)
+
+			// Infer the version level from the commits since the last release
+			if auto {
+				logs, err := gitCommits(ctx, dir, lastTag, "HEAD", projectPaths...)
+				if err != nil {
+					c.ui.Error(fmt.Sprintf("Error on getting git commits: %s", err))
+					return releaseGitErr
+				}
+
+				var commits []conventional.Commit
+				var ignored int
+
+				for _, log := range logs {
+					commit, err := conventional.Parse(log.Message)
+					if err != nil {
+						ignored++
+						continue
+					}
+					commits = append(commits, commit)
+				}
+
+				var reasons []string
+				version, reasons = conventional.Bump(lastSemVer, commits)
+
+				c.ui.Output(fmt.Sprintf("◉ Inferring the release level from %d commit(s) since %s ...", len(commits)+ignored, lastTag))
+				if ignored > 0 {
+					c.ui.Output(fmt.Sprintf("    %d commit(s) not following Conventional Commits are ignored", ignored))
+				}
+				for _, reason := range reasons {
+					c.ui.Output(fmt.Sprintf("    %s", reason))
+				}
+			}
+
+			if prerelease == "" {
+				releaseSemVer = lastSemVer.NextRelease(version)
+			} else {
+				releaseSemVer, err = lastSemVer.NextPrerelease(version, prerelease)
+				if err != nil {
+					c.ui.Error(fmt.Sprintf("Error on resolving the pre-release version: %s", err))
+					return releaseSemVerErr
+				}
+			}
 		}
 
-		releaseTag = "v" + releaseSemVer.String()
-	}
+		if maintenance && (releaseSemVer.Major != lineMajor || releaseSemVer.Minor != lineMinor) {
+			c.ui.Error(fmt.Sprintf("Release %s is not in %d.%d release line. Only patch releases can be done from %s branch.", releaseSemVer, lineMajor, lineMinor, gitBranch))
+			return releaseSemVerErr
+		}
 
-	// Create a new draft GitHub release
-	// See https://docs.github.com/en/rest/reference/repos#create-a-release
-
-	release := struct {
-		ID         int    `json:"id"`
-		Name       string `json:"name"`
-		TagName    string `json:"tag_name"`
-		Target     string `json:"target_commitish"`
-		Draft      bool   `json:"draft"`
-		Prerelease bool   `json:"prerelease"`
-		Body       string `json:"body"`
-		URL        string `json:"url"`
-		HTMLURL    string `json:"html_url"`
-		AssetsURL  string `json:"assets_url"`
-		UploadURL  string `json:"upload_url"`
-	}{}
+		releaseTag = project.Tag(releaseSemVer)
 
-	{
-		c.ui.Output(fmt.Sprintf("⬆️  Creating a draft release %s ...", ""))
-
-		body := new(bytes.Buffer)
-		_ = json.NewEncoder(body).Encode(struct {
-			Name       string `json:"name"`
-			TagName    string `json:"tag_name"`
-			Target     string `json:"target_commitish"`
-			Draft      bool   `json:"draft"`
-			Prerelease bool   `json:"prerelease"`
-			Body       string `json:"body"`
-		}{
-			Name:       releaseSemVer.String(),
-			TagName:    releaseTag,
-			Target:     gitBranch,
-			Draft:      true,
-			Prerelease: false,
-		})
+		state.Version = releaseSemVer.String()
+		state.LastTag = lastTag
+		state.Tag = releaseTag
+		state.Branch = gitBranch
+		state.Project = projectName
+		state.Comment = comment
+	}
 
-		url := fmt.Sprintf("https://api.github.com/repos/%s/%s/releases", repoOwner, repoName)
-		req, _ := http.NewRequest("POST", url, body)
-		req = req.WithContext(ctx)
-		req.Header.Set("Authorization", "token "+githubToken)
-		req.Header.Set("Accept", "application/vnd.github.v3+json")
-		req.Header.Set("User-Agent", "cherry") // ref: https://docs.github.com/en/rest/overview/resources-in-the-rest-api#user-agent-required
-		req.Header.Set("Content-Type", "application/json")
+	// Check the module path against the major version being released
 
-		res, err := client.Do(req)
-		if err != nil {
-			c.ui.Error(fmt.Sprintf("Error on creating a draft GitHub release: %s", err))
-			return releaseGitHubErr
+	moduleDir := filepath.Join(dir, project.Path)
+
+	if !resume {
+		modulePath, err := readModulePath(moduleDir)
+		if err != nil && !os.IsNotExist(err) {
+			c.ui.Error(fmt.Sprintf("Error on reading go.mod file: %s", err))
+			return releaseModuleErr
 		}
-		defer res.Body.Close()
 
-		if res.StatusCode != 201 {
-			c.ui.Error(fmt.Sprintf("Error on creating a draft GitHub release: invalid status code %d", res.StatusCode))
-			return releaseGitHubErr
+		// A repository without a go.mod file is not a Go module
+		if err == nil {
+			if expected := modulePathForMajor(modulePath, releaseSemVer.Major); expected != modulePath {
+				if !updateModule {
+					c.ui.Error(fmt.Sprintf("Module path %s does not match the major version %d. It should be %s. Use -update-module flag to update it.", modulePath, releaseSemVer.Major, expected))
+					return releaseModuleErr
+				}
+				c.ui.Warn(fmt.Sprintf("🔀 Module path %s will be updated to %s", modulePath, expected))
+			}
 		}
 
-		err = json.NewDecoder(res.Body).Decode(&release)
+		state.UpdateModule = updateModule
+	}
+
+	// The release of a project is named after the project (i.e. api 1.2.3)
+	releaseName := releaseSemVer.String()
+	if projectName != "" {
+		releaseName = projectName + " " + releaseName
+	}
+
+	// Create a new draft release
+
+	release := state.Release
+
+	if !state.done(stepDraft) {
+		c.ui.Output(fmt.Sprintf("⬆️  Creating a draft release %s ...", releaseName))
+
+		var err error
+		release, err = p.CreateDraft(ctx, provider.Release{
+			Name:        releaseName,
+			TagName:     releaseTag,
+			Target:      gitBranch,
+			Prerelease:  releaseSemVer.IsPrerelease(),
+			Maintenance: maintenance,
+		})
+
 		if err != nil {
-			c.ui.Error(fmt.Sprintf("Error on creating a draft GitHub release: %s", err))
-			return releaseGitHubErr
+			c.ui.Error(fmt.Sprintf("Error on creating a draft %s release: %s", p.Name(), err))
+			return releaseProviderErr
+		}
+
+		draft := release
+		j.add(fmt.Sprintf("delete draft release %s", draft.Name), func(ctx context.Context) error {
+			return p.DeleteRelease(ctx, draft)
+		})
+
+		state.Release = release
+		if err := complete(stepDraft); err != nil {
+			c.ui.Error(fmt.Sprintf("Error on saving release state: %s", err))
+			return releaseStateErr
 		}
 	}
 
 	// Generate change log
 
-	var changelogText string
-	changelogFile := "CHANGELOG.md"
+	changelogFile := filepath.Join(project.Path, "CHANGELOG.md")
 
-	{
+	if !state.done(stepChangelog) {
 		c.ui.Output("➡️  Creating/Updating change log ...")
 
-		var stdout, stderr bytes.Buffer
-		cmd := exec.CommandContext(ctx,
-			"github_changelog_generator",
-			"--token", githubToken,
-			"--user", repoOwner,
-			"--project", repoName,
-			"--no-filter-by-milestone",
-			"--exclude-labels", "question,duplicate,invalid,wontfix",
-			"--future-release", releaseTag,
-		)
-		cmd.Dir = dir
-		cmd.Stdout = &stdout
-		cmd.Stderr = &stderr
-		if err := cmd.Run(); err != nil {
-			c.ui.Error(fmt.Sprintf("Error on generating change log: %s %s %s", err, strings.Trim(stdout.String(), "\n"), strings.Trim(stderr.String(), "\n")))
-			return releaseChangelogErr
+		r := changelog.Release{
+			Tag:         releaseTag,
+			PreviousTag: lastTag,
+			Date:        time.Now(),
 		}
 
-		file, err := os.Open(filepath.Join(dir, changelogFile))
-		if err != nil {
-			c.ui.Error(fmt.Sprintf("Error on opening change log file: %s %s", err, strings.Trim(stderr.String(), "\n")))
-			return releaseChangelogErr
+		if c.spec.Release.Changelog.Source == "github" {
+			// Pull requests and issues are taken from the time of the last release
+			var since time.Time
+			if lastTag != "" {
+				var err error
+				since, err = gitTagDate(ctx, dir, lastTag)
+				if err != nil {
+					c.ui.Error(fmt.Sprintf("Error on getting git tag date: %s", err))
+					return releaseGitErr
+				}
+			}
+
+			source := &changelog.GitHubSource{
+				Client:        client,
+				APIURL:        githubAPIURL,
+				Token:         githubToken,
+				Owner:         repoOwner,
+				Repo:          repoName,
+				ExcludeLabels: c.spec.Release.Changelog.ExcludeLabels,
+			}
+
+			items, err := source.Items(ctx, since, time.Time{})
+			if err != nil {
+				c.ui.Error(fmt.Sprintf("Error on getting GitHub pull requests and issues: %s", err))
+				return releaseProviderErr
+			}
+			r.Items = items
+		} else {
+			commits, err := gitCommits(ctx, dir, lastTag, "HEAD", projectPaths...)
+			if err != nil {
+				c.ui.Error(fmt.Sprintf("Error on getting git commits: %s", err))
+				return releaseGitErr
+			}
+			r.Commits = filterReleaseCommits(commits)
 		}
-		defer file.Close()
 
-		// Regex for the start of current release
-		startRE, err := regexp.Compile(fmt.Sprintf(`^## \[%s\]`, releaseTag))
-		if err != nil {
-			c.ui.Error(fmt.Sprintf("Error on compiling regex: %s", err))
-			return releaseChangelogErr
+		g := &changelog.Generator{
+			RepoURL: fmt.Sprintf("https://%s/%s/%s", repoDomain, repoOwner, repoName),
 		}
 
-		// Regex for the end of current release
-		endRE, err := regexp.Compile(`^(##|\\\*)`)
-		if err != nil {
-			c.ui.Error(fmt.Sprintf("Error on compiling regex: %s", err))
+		section := g.Section(r)
+
+		changelogPath := filepath.Join(dir, changelogFile)
+		content, err := ioutil.ReadFile(changelogPath)
+		if err != nil && !os.IsNotExist(err) {
+			c.ui.Error(fmt.Sprintf("Error on reading change log file: %s", err))
 			return releaseChangelogErr
 		}
 
-		var saveText bool
-		scanner := bufio.NewScanner(file)
-		for scanner.Scan() {
-			line := scanner.Text()
-			if startRE.MatchString(line) {
-				saveText = true
-				continue
-			} else if endRE.MatchString(line) {
-				break
-			}
+		existed, original := err == nil, content
+		content = []byte(changelog.Update(string(content), section))
 
-			if saveText {
-				changelogText += line + "\n"
+		if dryRun {
+			record(fmt.Sprintf("update %s", changelogFile))
+		} else {
+			if err := ioutil.WriteFile(changelogPath, content, 0644); err != nil {
+				c.ui.Error(fmt.Sprintf("Error on writing change log file: %s", err))
+				return releaseChangelogErr
 			}
-		}
 
-		if err := scanner.Err(); err != nil {
-			c.ui.Error(fmt.Sprintf("Error on scanning change log: %s", err))
-			return releaseChangelogErr
+			j.add(fmt.Sprintf("restore %s", changelogFile), func(ctx context.Context) error {
+				// The change log file may be staged already
+				if _, err := git.Run(ctx, dir, "reset", "-q", "--", changelogFile); err != nil {
+					return err
+				}
+
+				if existed {
+					return ioutil.WriteFile(changelogPath, original, 0644)
+				}
+
+				if err := os.Remove(changelogPath); err != nil && !os.IsNotExist(err) {
+					return err
+				}
+				return nil
+			})
 		}
 
-		changelogText = strings.Trim(changelogText, "\n")
+		changelogText = changelog.Body(section)
+
+		state.Changelog = changelogText
+		if err := complete(stepChangelog); err != nil {
+			c.ui.Error(fmt.Sprintf("Error on saving release state: %s", err))
+			return releaseStateErr
+		}
 	}
 
-	// Create the release commit and tag
-	{
-		c.ui.Output(fmt.Sprintf("➡️  Creating release commit and tag %s ...", releaseSemVer))
+	// Create the release commit
 
-		var stdout, stderr bytes.Buffer
-		cmd := exec.CommandContext(ctx, "git", "add", changelogFile)
-		cmd.Dir = dir
-		cmd.Stdout = &stdout
-		cmd.Stderr = &stderr
-		if err := cmd.Run(); err != nil {
-			c.ui.Error(fmt.Sprintf("Error on running git add: %s %s", err, strings.Trim(stderr.String(), "\n")))
+	if !state.done(stepCommit) {
+		c.ui.Output(fmt.Sprintf("➡️  Creating release commit %s ...", releaseName))
+
+		head, err := git.Run(ctx, dir, "rev-parse", "HEAD")
+		if err != nil {
+			c.ui.Error(fmt.Sprintf("Error on getting the current commit: %s", err))
 			return releaseGitErr
 		}
 
-		stdout.Reset()
-		stderr.Reset()
-		commitMessage := fmt.Sprintf("Releasing %s", releaseSemVer)
-		cmd = exec.CommandContext(ctx, "git", "commit", "-m", commitMessage)
-		cmd.Dir = dir
-		cmd.Stdout = &stdout
-		cmd.Stderr = &stderr
-		if err := cmd.Run(); err != nil {
-			c.ui.Error(fmt.Sprintf("Error on running git commit -m: %s %s", err, strings.Trim(stderr.String(), "\n")))
+		files := []string{changelogFile}
+
+		// The module path is checked again in case the release is resumed
+		if state.UpdateModule {
+			modulePath, err := readModulePath(moduleDir)
+			if err != nil && !os.IsNotExist(err) {
+				c.ui.Error(fmt.Sprintf("Error on reading go.mod file: %s", err))
+				return releaseModuleErr
+			}
+
+			if expected := modulePathForMajor(modulePath, releaseSemVer.Major); err == nil && expected != modulePath {
+				if dryRun {
+					record(fmt.Sprintf("update module path %s to %s", modulePath, expected))
+				} else {
+					changed, err := rewriteModulePath(moduleDir, modulePath, expected)
+					for i := range changed {
+						changed[i] = filepath.Join(project.Path, changed[i])
+					}
+
+					// The files changed before a failure are restored too
+					if len(changed) > 0 {
+						j.add(fmt.Sprintf("restore module path %s", modulePath), func(ctx context.Context) error {
+							args := append([]string{"checkout", "HEAD", "--"}, changed...)
+							_, err := git.Run(ctx, dir, args...)
+							return err
+						})
+					}
+
+					if err != nil {
+						c.ui.Error(fmt.Sprintf("Error on updating module path: %s", err))
+						return releaseModuleErr
+					}
+
+					files = append(files, changed...)
+				}
+			}
+		}
+
+		if err := run(append([]string{"add"}, files...)...); err != nil {
+			c.ui.Error(fmt.Sprintf("Error on staging release files: %s", err))
 			return releaseGitErr
 		}
 
-		stdout.Reset()
-		stderr.Reset()
-		annotation := fmt.Sprintf("Version %s", releaseSemVer)
-		cmd = exec.CommandContext(ctx, "git", "tag", "-a", releaseTag, "-m", annotation)
-		cmd.Dir = dir
-		cmd.Stdout = &stdout
-		cmd.Stderr = &stderr
-		if err := cmd.Run(); err != nil {
-			c.ui.Error(fmt.Sprintf("Error on running git tag -a -m: %s %s", err, strings.Trim(stderr.String(), "\n")))
+		commitMessage := fmt.Sprintf("Releasing %s", releaseName)
+		if err := run("commit", "-m", commitMessage); err != nil {
+			c.ui.Error(fmt.Sprintf("Error on creating release commit: %s", err))
+			return releaseGitErr
+		}
+
+		j.add("reset release commit", func(ctx context.Context) error {
+			if commitPushed {
+				return errors.New("release commit is already pushed to origin")
+			}
+			_, err := git.Run(ctx, dir, "reset", "--hard", head)
+			return err
+		})
+
+		if err := complete(stepCommit); err != nil {
+			c.ui.Error(fmt.Sprintf("Error on saving release state: %s", err))
+			return releaseStateErr
+		}
+	}
+
+	// Create the release tag
+
+	if !state.done(stepTag) {
+		c.ui.Output(fmt.Sprintf("➡️  Creating release tag %s ...", releaseTag))
+
+		annotation := fmt.Sprintf("Version %s", releaseName)
+		if err := run("tag", "-a", releaseTag, "-m", annotation); err != nil {
+			c.ui.Error(fmt.Sprintf("Error on creating release tag: %s", err))
 			return releaseGitErr
 		}
+
+		j.add(fmt.Sprintf("delete tag %s", releaseTag), func(ctx context.Context) error {
+			if tagPushed {
+				return errors.New("release tag is already pushed to origin")
+			}
+			_, err := git.Run(ctx, dir, "tag", "-d", releaseTag)
+			return err
+		})
+
+		if err := complete(stepTag); err != nil {
+			c.ui.Error(fmt.Sprintf("Error on saving release state: %s", err))
+			return releaseStateErr
+		}
 	}
 
-	// Building artifacts (binaries) and uploading them to GitHub
-	// See https://developer.github.com/v3/repos/releases/#upload-a-release-asset
+	// Building artifacts (binaries) and uploading them to the draft release
 
-	if c.spec.Release.Build {
+	if c.spec.Release.Build && !state.done(stepUpload) {
 		c.ui.Output("➡️  Building artifacts ...")
 
 		bc := &buildCommand{
@@ -581,230 +920,259 @@ func (c *releaseCommand) Run(args []string) int {
 			artifacts: []string{},
 		}
 
-		code := bc.Run([]string{})
+		// In dry-run mode, there is no release tag for resolving the version being released
+		// When resuming, the release tag may not be created yet
+		if dryRun || resume {
+			bc.version = &releaseSemVer
+		}
+
+		var buildArgs []string
+		if projectName != "" {
+			buildArgs = []string{"-project", projectName}
+		}
+
+		code := bc.Run(buildArgs)
 		if code != 0 {
 			return code
 		}
 
-		c.ui.Output(fmt.Sprintf("➡️️  Uploading artifacts to release %s ...", release.Name))
+		// The checksums of the artifacts are uploaded along with them
+		checksums, err := writeArtifactChecksums(bc.artifacts, c.spec.Release.Checksums.SHA512)
+		if err != nil {
+			c.ui.Error(fmt.Sprintf("Error on writing checksums: %s", err))
+			return releaseChecksumErr
+		}
 
-		doneCh := make(chan error, len(bc.artifacts))
+		for _, checksum := range checksums {
+			c.ui.Info(fmt.Sprintf("🔑 %s", checksum))
+		}
 
-		for _, artifact := range bc.artifacts {
-			go func(artifact string) {
-				assetPath := filepath.Clean(artifact)
-				assetName := filepath.Base(assetPath)
+		assets := append(bc.artifacts, checksums...)
 
-				assetFile, err := os.Open(assetPath)
-				if err != nil {
-					doneCh <- err
-					return
-				}
-				defer assetFile.Close()
+		if sign {
+			signatures, err := signFiles(signKey, assets, time.Now())
+			if err != nil {
+				c.ui.Error(fmt.Sprintf("Error on signing artifacts: %s", err))
+				return releaseSigningErr
+			}
 
-				stat, err := assetFile.Stat()
-				if err != nil {
-					doneCh <- err
-					return
-				}
+			for _, signature := range signatures {
+				c.ui.Info(fmt.Sprintf("🔏 %s", signature))
+			}
 
-				// Read the first 512 bytes of file to determine the mime type of asset
-				buff := make([]byte, 512)
-				_, err = assetFile.Read(buff)
-				if err != nil {
-					doneCh <- err
-					return
-				}
+			assets = append(assets, signatures...)
+		}
 
-				// Determine mime type of asset
-				// http.DetectContentType will return "application/octet-stream" if it cannot determine a more specific one
-				mimeType := http.DetectContentType(buff)
+		// The artifacts are built again when resuming, so the assets uploaded before are replaced with them
+		// Otherwise, the checksums and signatures would not match the binaries uploaded before
+		if len(release.Assets) > 0 {
+			c.ui.Output(fmt.Sprintf("➡️️  Deleting %d asset(s) uploaded before ...", len(release.Assets)))
 
-				// Reset the offset back to the beginning of the file
-				_, err = assetFile.Seek(0, io.SeekStart)
-				if err != nil {
-					doneCh <- err
-					return
+			for len(release.Assets) > 0 {
+				if err := p.DeleteAsset(ctx, release, release.Assets[0]); err != nil {
+					c.ui.Error(fmt.Sprintf("Error on deleting asset %s: %s", release.Assets[0].Name, err))
+					return releaseProviderErr
 				}
-
-				re := regexp.MustCompile(`\{\?[0-9A-Za-z_,]+\}`)
-				url := re.ReplaceAllLiteralString(release.UploadURL, "")
-				url = fmt.Sprintf("%s?name=%s", url, netURL.QueryEscape(assetName))
-				req, _ := http.NewRequest("POST", url, assetFile)
-				req = req.WithContext(ctx)
-				req.Header.Set("Authorization", "token "+githubToken)
-				req.Header.Set("Accept", "application/vnd.github.v3+json")
-				req.Header.Set("User-Agent", "cherry") // ref: https://docs.github.com/en/rest/overview/resources-in-the-rest-api#user-agent-required
-				req.Header.Set("Content-Type", mimeType)
-				req.ContentLength = stat.Size()
-
-				res, err := client.Do(req)
-				if err != nil {
-					doneCh <- err
-					return
+				release.Assets = release.Assets[1:]
+
+				if !dryRun {
+					state.Release.Assets = release.Assets
+					if err := state.save(dir); err != nil {
+						c.ui.Error(fmt.Sprintf("Error on saving release state: %s", err))
+						return releaseStateErr
+					}
 				}
-				defer res.Body.Close()
+			}
+		}
 
-				if res.StatusCode != 201 {
-					doneCh <- fmt.Errorf("invalid status code %d", res.StatusCode)
-					return
-				}
+		c.ui.Output(fmt.Sprintf("➡️️  Uploading artifacts to release %s ...", release.Name))
 
-				asset := struct {
-					ID          int    `json:"id"`
-					Name        string `json:"name"`
-					Label       string `json:"label"`
-					State       string `json:"state"`
-					Size        int    `json:"size"`
-					ContentType string `json:"content_type"`
-					URL         string `json:"url"`
-					DownloadURL string `json:"browser_download_url"`
-				}{}
-
-				err = json.NewDecoder(res.Body).Decode(&asset)
-				if err != nil {
-					doneCh <- err
-					return
-				}
+		type result struct {
+			asset provider.Asset
+			err   error
+		}
 
-				doneCh <- nil
+		draft := release
+		doneCh := make(chan result, len(assets))
+
+		for _, artifact := range assets {
+			go func(artifact string) {
+				asset, err := p.UploadAsset(ctx, draft, artifact)
+				doneCh <- result{asset, err}
 			}(artifact)
 		}
 
-		for range bc.artifacts {
-			if err := <-doneCh; err != nil {
-				c.ui.Error(fmt.Sprintf("Error on uploading artifact: %s", err))
-				return releaseUploadErr
+		var uploadErr error
+		for range assets {
+			r := <-doneCh
+			if r.err != nil {
+				uploadErr = r.err
+				continue
+			}
+
+			asset := r.asset
+			release.Assets = append(release.Assets, asset)
+			j.add(fmt.Sprintf("delete asset %s", asset.Name), func(ctx context.Context) error {
+				return p.DeleteAsset(ctx, draft, asset)
+			})
+
+			if !dryRun {
+				state.Release.Assets = release.Assets
+				if err := state.save(dir); err != nil {
+					c.ui.Error(fmt.Sprintf("Error on saving release state: %s", err))
+					return releaseStateErr
+				}
 			}
 		}
+
+		if uploadErr != nil {
+			c.ui.Error(fmt.Sprintf("Error on uploading artifact: %s", uploadErr))
+			return releaseUploadErr
+		}
+
+		if err := complete(stepUpload); err != nil {
+			c.ui.Error(fmt.Sprintf("Error on saving release state: %s", err))
+			return releaseStateErr
+		}
 	}
 
-	// Enable direct push to master and defering disabling it back
+	// Enable direct push to the release branch and defering disabling it back
 
 	{
-		c.ui.Warn("🔓 Temporarily enabling push to master branch ...")
-
-		url := fmt.Sprintf("https://api.github.com/repos/%s/%s/branches/%s/protection/enforce_admins", repoOwner, repoName, gitBranch)
-		req, _ := http.NewRequest("DELETE", url, nil)
-		req = req.WithContext(ctx)
-		req.Header.Set("Authorization", "token "+githubToken)
-		req.Header.Set("Accept", "application/vnd.github.v3+json")
-		req.Header.Set("User-Agent", "cherry") // ref: https://docs.github.com/en/rest/overview/resources-in-the-rest-api#user-agent-required
-		req.Header.Set("Content-Type", "application/json")
+		c.ui.Warn(fmt.Sprintf("🔓 Temporarily enabling push to %s branch ...", gitBranch))
+
+		// The branch protection removed by an interrupted release is loaded for protecting the branch again
+		saver, saveProtections := p.(provider.ProtectionSaver)
+		if saveProtections && len(state.Protections) > 0 {
+			if err := saver.LoadProtections(state.Protections); err != nil {
+				c.ui.Error(fmt.Sprintf("Error on reading branch protections from release state: %s", err))
+				return releaseStateErr
+			}
+		}
 
-		res, err := client.Do(req)
-		if err != nil {
-			c.ui.Error(fmt.Sprintf("Error on disabling push to master: %s", err))
-			return releaseGitHubErr
+		if err := p.ProtectBranch(ctx, gitBranch, false); err != nil {
+			c.ui.Error(fmt.Sprintf("Error on disabling push to %s: %s", gitBranch, err))
+			return releaseProviderErr
 		}
-		defer res.Body.Close()
 
-		if res.StatusCode != 204 {
-			c.ui.Error(fmt.Sprintf("Error on disabling push to master: invalid status code %d", res.StatusCode))
-			return releaseGitHubErr
+		// The removed branch protection is saved, so it is not lost if the release is interrupted
+		if saveProtections {
+			protections, err := saver.SaveProtections()
+			if err != nil {
+				c.ui.Error(fmt.Sprintf("Error on saving release state: %s", err))
+				return releaseStateErr
+			}
+
+			state.Protections = protections
+			if err := state.save(dir); err != nil {
+				c.ui.Error(fmt.Sprintf("Error on saving release state: %s", err))
+				return releaseStateErr
+			}
 		}
 
-		// Make sure we re-enable the master branch protection
+		// Make sure we re-enable the branch protection
 		defer func() {
-			c.ui.Warn("🔒 Re-disabling push to master branch ...")
+			c.ui.Warn(fmt.Sprintf("🔒 Re-disabling push to %s branch ...", gitBranch))
 
-			url := fmt.Sprintf("https://api.github.com/repos/%s/%s/branches/%s/protection/enforce_admins", repoOwner, repoName, gitBranch)
-			req, _ := http.NewRequest("POST", url, nil)
-			req = req.WithContext(ctx)
-			req.Header.Set("Authorization", "token "+githubToken)
-			req.Header.Set("Accept", "application/vnd.github.v3+json")
-			req.Header.Set("User-Agent", "cherry") // ref: https://docs.github.com/en/rest/overview/resources-in-the-rest-api#user-agent-required
-			req.Header.Set("Content-Type", "application/json")
+			// The context for releasing may be already expired
+			ctx, cancel := context.WithTimeout(context.Background(), releaseRollbackTimeout)
+			defer cancel()
 
-			res, err := client.Do(req)
-			if err != nil {
-				c.ui.Error(fmt.Sprintf("Error on enabling push to master: %s", err))
+			if err := p.ProtectBranch(ctx, gitBranch, true); err != nil {
+				c.ui.Error(fmt.Sprintf("Error on enabling push to %s: %s", gitBranch, err))
+				c.ui.Error(fmt.Sprintf("❗ The %s branch is NOT protected anymore. Protect it again on %s manually.", gitBranch, p.Name()))
+				return
 			}
-			defer res.Body.Close()
 
-			if res.StatusCode != 200 {
-				c.ui.Error(fmt.Sprintf("Error on enabling push to master: invalid status code %d", res.StatusCode))
+			// The state of a completed release is already removed
+			if _, err := os.Stat(filepath.Join(dir, releaseStateFile)); saveProtections && err == nil {
+				state.Protections = nil
+				if err := state.save(dir); err != nil {
+					c.ui.Error(fmt.Sprintf("Error on saving release state: %s", err))
+				}
 			}
 		}()
 	}
 
-	// Push release commit to GitHub
-	{
-		c.ui.Info(fmt.Sprintf("⬆️  Pushing release commit %s ...", releaseSemVer))
+	// Push release commit to the remote repository
+	if !state.done(stepPush) {
+		c.ui.Info(fmt.Sprintf("⬆️  Pushing release commit %s ...", releaseName))
 
-		var stdout, stderr bytes.Buffer
-		cmd := exec.CommandContext(ctx, "git", "push")
-		cmd.Dir = dir
-		cmd.Stdout = &stdout
-		cmd.Stderr = &stderr
-		if err := cmd.Run(); err != nil {
-			c.ui.Error(fmt.Sprintf("Error on running git push: %s %s", err, strings.Trim(stderr.String(), "\n")))
+		if err := run("push"); err != nil {
+			c.ui.Error(fmt.Sprintf("Error on pushing release commit: %s", err))
 			return releaseGitErr
 		}
+		commitPushed = true
+
+		if err := complete(stepPush); err != nil {
+			c.ui.Error(fmt.Sprintf("Error on saving release state: %s", err))
+			return releaseStateErr
+		}
 	}
 
-	// Push release tag to GitHub
-	{
+	// Push release tag to the remote repository
+	if !state.done(stepPushTag) {
 		c.ui.Info(fmt.Sprintf("⬆️  Pushing release tag %s ...", releaseTag))
 
-		var stdout, stderr bytes.Buffer
-		cmd := exec.CommandContext(ctx, "git", "push", "origin", releaseTag)
-		cmd.Dir = dir
-		cmd.Stdout = &stdout
-		cmd.Stderr = &stderr
-		if err := cmd.Run(); err != nil {
-			c.ui.Error(fmt.Sprintf("Error on running git push origin: %s %s", err, strings.Trim(stderr.String(), "\n")))
+		if err := run("push", "origin", releaseTag); err != nil {
+			c.ui.Error(fmt.Sprintf("Error on pushing release tag: %s", err))
 			return releaseGitErr
 		}
+		tagPushed = true
+
+		if err := complete(stepPushTag); err != nil {
+			c.ui.Error(fmt.Sprintf("Error on saving release state: %s", err))
+			return releaseStateErr
+		}
 	}
 
-	// Publishing GitHub release
-	{
+	// Publishing the release
+	if !state.done(stepPublish) {
 		c.ui.Info(fmt.Sprintf("⬆️  Publishing release %s ...", release.Name))
 
-		body := new(bytes.Buffer)
-		_ = json.NewEncoder(body).Encode(struct {
-			Name       string `json:"name"`
-			TagName    string `json:"tag_name"`
-			Target     string `json:"target_commitish"`
-			Draft      bool   `json:"draft"`
-			Prerelease bool   `json:"prerelease"`
-			Body       string `json:"body"`
-		}{
-			Name:       releaseSemVer.String(),
-			TagName:    releaseTag,
-			Target:     gitBranch,
-			Draft:      false,
-			Prerelease: false,
-			Body:       fmt.Sprintf("%s\n\n%s", comment, changelogText),
-		})
-
-		url := fmt.Sprintf("https://api.github.com/repos/%s/%s/releases/%d", repoOwner, repoName, release.ID)
-		req, _ := http.NewRequest("PATCH", url, body)
-		req = req.WithContext(ctx)
-		req.Header.Set("Authorization", "token "+githubToken)
-		req.Header.Set("Accept", "application/vnd.github.v3+json")
-		req.Header.Set("User-Agent", "cherry") // ref: https://docs.github.com/en/rest/overview/resources-in-the-rest-api#user-agent-required
-		req.Header.Set("Content-Type", "application/json")
+		release.Body = fmt.Sprintf("%s\n\n%s", comment, changelogText)
 
-		res, err := client.Do(req)
+		var err error
+		release, err = p.Publish(ctx, release)
 		if err != nil {
-			c.ui.Error(fmt.Sprintf("Error on editing GitHub release: %s", err))
-			return releaseGitHubErr
+			c.ui.Error(fmt.Sprintf("Error on publishing %s release: %s", p.Name(), err))
+			return releaseProviderErr
 		}
-		defer res.Body.Close()
 
-		if res.StatusCode != 200 {
-			c.ui.Error(fmt.Sprintf("Error on editing GitHub release: invalid status code %d", res.StatusCode))
-			return releaseGitHubErr
+		// A published release is not rolled back
+		released = true
+
+		state.Release = release
+		if err := complete(stepPublish); err != nil {
+			c.ui.Error(fmt.Sprintf("Error on saving release state: %s", err))
+			return releaseStateErr
 		}
+	}
 
-		err = json.NewDecoder(res.Body).Decode(&release)
-		if err != nil {
-			c.ui.Error(fmt.Sprintf("Error on editing GitHub release: %s", err))
-			return releaseGitHubErr
+	released = true
+
+	if !dryRun {
+		if err := removeReleaseState(dir); err != nil {
+			c.ui.Error(fmt.Sprintf("Error on removing release state: %s", err))
+			return releaseStateErr
 		}
 	}
 
 	return 0
 }
+
+// printPlan prints the changes recorded in dry-run mode.
+func (c *releaseCommand) printPlan(plan []string, changelogText string) {
+	c.ui.Output("")
+	c.ui.Info("📝 Dry run: the following changes would be made:")
+	c.ui.Output("")
+
+	for i, step := range plan {
+		c.ui.Output(fmt.Sprintf("  %d. %s", i+1, step))
+	}
+
+	c.ui.Output("")
+	c.ui.Info("📝 Release notes:")
+	c.ui.Output("")
+	c.ui.Output(changelogText)
+}
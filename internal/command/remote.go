@@ -0,0 +1,81 @@
+package command
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/moorara/cherry/internal/spec"
+)
+
+// newHTTPClient creates an HTTP client for the remote repository APIs.
+// If caFile is set, the certificate authorities in it are trusted in addition to the system ones.
+func newHTTPClient(caFile string) (*http.Client, error) {
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+	}
+
+	if caFile != "" {
+		pem, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return nil, err
+		}
+
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in %s", caFile)
+		}
+
+		transport.TLSClientConfig = &tls.Config{
+			RootCAs: pool,
+		}
+	}
+
+	return &http.Client{
+		Transport: transport,
+	}, nil
+}
+
+// detectProvider returns the name of the provider for releasing a remote repository (github, gitlab, or gitea).
+// The provider set in the spec takes precedence over the one detected from the remote repository domain.
+// An empty string is returned if the provider cannot be determined.
+func detectProvider(domain string, s spec.Release) string {
+	if s.Provider != "" {
+		return s.Provider
+	}
+
+	switch domain = strings.ToLower(domain); {
+	case domain == "github.com" || strings.HasPrefix(domain, "github.") || s.GitHub.APIURL != "":
+		return "github"
+	case domain == "gitlab.com" || strings.HasPrefix(domain, "gitlab."):
+		return "gitlab"
+	case domain == "gitea.com" || domain == "codeberg.org" || strings.HasPrefix(domain, "gitea.") || strings.HasPrefix(domain, "forgejo."):
+		return "gitea"
+	}
+
+	return ""
+}
+
+// githubURLs returns the API and upload base URLs for a GitHub host.
+// Empty URLs mean the defaults for github.com should be used.
+// For GitHub Enterprise Server, the URLs are derived from the host (https://<host>/api/v3 and https://<host>/api/uploads) unless set in the spec.
+func githubURLs(domain string, s spec.GitHub) (string, string) {
+	apiURL, uploadURL := s.APIURL, s.UploadURL
+
+	if apiURL == "" && !strings.EqualFold(domain, "github.com") {
+		apiURL = fmt.Sprintf("https://%s/api/v3", domain)
+	}
+
+	if uploadURL == "" && strings.HasSuffix(strings.TrimRight(apiURL, "/"), "/api/v3") {
+		uploadURL = strings.TrimSuffix(strings.TrimRight(apiURL, "/"), "/v3") + "/uploads"
+	}
+
+	return apiURL, uploadURL
+}
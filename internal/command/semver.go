@@ -1,46 +1,55 @@
 package command
 
 import (
-	"bytes"
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
-	"os/exec"
-	"regexp"
-	"strings"
+	"path/filepath"
 	"time"
 
 	"github.com/mitchellh/cli"
+	"github.com/moorara/cherry/internal/spec"
 	"github.com/moorara/cherry/pkg/semver"
+	"github.com/moorara/cherry/pkg/versioning"
 )
 
 const (
-	semverFlagErr = 201
-	semverOSErr   = 202
-	semverGitErr  = 203
-	semverTimeout = 10 * time.Second
+	semverFlagErr   = 201
+	semverOSErr     = 202
+	semverGitErr    = 203
+	semverSemVerErr = 204
+	semverSpecErr   = 205
+	semverTimeout   = 10 * time.Second
 
 	semverSynopsis = `get semantic version`
 	semverHelp     = `
 	Use this command for getting the current semantic version.
 
+	Flags:
+
+		-project:  name of a project in the spec file for getting its semantic version
+
 	Examples:
 
 		cherry semver
+		cherry semver -project api
 	`
 )
 
 // semverCommand implements cli.Command interface.
 type semverCommand struct {
 	ui      cli.Ui
+	spec    spec.Spec
 	version semver.SemVer
 }
 
 // NewSemverCommand creates a semver command.
-func NewSemverCommand(ui cli.Ui) (cli.Command, error) {
+func NewSemverCommand(ui cli.Ui, s spec.Spec) (cli.Command, error) {
 	return &semverCommand{
-		ui: ui,
+		ui:   ui,
+		spec: s,
 	}, nil
 }
 
@@ -56,7 +65,10 @@ func (c *semverCommand) Help() string {
 
 // Run runs the actual command with the given command-line arguments.
 func (c *semverCommand) Run(args []string) int {
+	var projectName string
+
 	fs := flag.NewFlagSet("semver", flag.ContinueOnError)
+	fs.StringVar(&projectName, "project", "", "")
 	fs.Usage = func() {
 		c.ui.Output(c.Help())
 	}
@@ -71,6 +83,8 @@ func (c *semverCommand) Run(args []string) int {
 	// Run preflight checks
 
 	var dir string
+	var project spec.Project
+	var repo versioning.Repository
 
 	{
 		// c.ui.Output("◉ Running preflight checks ...")
@@ -81,119 +95,35 @@ func (c *semverCommand) Run(args []string) int {
 			c.ui.Error(fmt.Sprintf("Error on getting the current working directory: %s", err))
 			return semverOSErr
 		}
-	}
 
-	{
-		var stdout, stderr bytes.Buffer
-		cmd := exec.CommandContext(ctx, "git", "version")
-		cmd.Dir = dir
-		cmd.Stdout = &stdout
-		cmd.Stderr = &stderr
-		if err := cmd.Run(); err != nil {
-			c.ui.Error(fmt.Sprintf("Error on checking git: %s %s", err, strings.Trim(stderr.String(), "\n")))
-			return semverGitErr
+		// A project is versioned by its own tags
+		if projectName != "" {
+			var ok bool
+			if project, ok = c.spec.Project(projectName); !ok {
+				c.ui.Error(fmt.Sprintf("Project %s not found in the spec file.", projectName))
+				return semverSpecErr
+			}
+			dir = filepath.Join(dir, project.Path)
 		}
-	}
 
-	// Get git information
-
-	var gitStatusClean bool
-	var gitCommitCount string
-	var gitSHA, gitShortSHA string
-
-	{
-		var stdout, stderr bytes.Buffer
-		cmd := exec.CommandContext(ctx, "git", "status", "--porcelain")
-		cmd.Dir = dir
-		cmd.Stdout = &stdout
-		cmd.Stderr = &stderr
-		if err := cmd.Run(); err != nil {
-			c.ui.Error(fmt.Sprintf("Error on running git status --porcelain: %s %s", err, strings.Trim(stderr.String(), "\n")))
-			return semverGitErr
-		}
-		gitStatusClean = len(stdout.String()) == 0
-
-		stdout.Reset()
-		stderr.Reset()
-		cmd = exec.CommandContext(ctx, "git", "rev-list", "--count", "HEAD")
-		cmd.Dir = dir
-		cmd.Stdout = &stdout
-		cmd.Stderr = &stderr
-		if err := cmd.Run(); err != nil {
-			c.ui.Error(fmt.Sprintf("Error on running git rev-list --count HEAD: %s %s", err, strings.Trim(stderr.String(), "\n")))
-			return semverGitErr
-		}
-		gitCommitCount = strings.Trim(stdout.String(), "\n")
-
-		stdout.Reset()
-		stderr.Reset()
-		cmd = exec.CommandContext(ctx, "git", "rev-parse", "HEAD")
-		cmd.Dir = dir
-		cmd.Stdout = &stdout
-		cmd.Stderr = &stderr
-		if err := cmd.Run(); err != nil {
-			c.ui.Error(fmt.Sprintf("Error on running git rev-parse HEAD: %s %s", err, strings.Trim(stderr.String(), "\n")))
+		// The .git directory is read directly if the git binary is not available
+		repo, err = versioning.Open(dir)
+		if err != nil {
+			c.ui.Error(fmt.Sprintf("Error on opening git repository: %s", err))
 			return semverGitErr
 		}
-		gitSHA = strings.Trim(stdout.String(), "\n")
-		gitShortSHA = gitSHA[:7]
 	}
 
 	// Resolve the current semantic version
 	{
-		var stdout, stderr bytes.Buffer
-		cmd := exec.CommandContext(ctx, "git", "describe", "--tags", "HEAD")
-		cmd.Dir = dir
-		cmd.Stdout = &stdout
-		cmd.Stderr = &stderr
-		if err := cmd.Run(); err != nil {
-			// 128 is returned when there is no git tag
-			if exiterr, ok := err.(*exec.ExitError); !ok || exiterr.ExitCode() != 128 {
-				c.ui.Error(fmt.Sprintf("Error on running git describe --tags HEAD: %s %s", err, strings.Trim(stderr.String(), "\n")))
-				return semverGitErr
-			}
-		}
-		gitDescribe := strings.Trim(stdout.String(), "\n")
-
-		releaseRE := regexp.MustCompile(`^v?([0-9]+)\.([0-9]+)\.([0-9]+)$`)
-		prereleaseRE := regexp.MustCompile(`^(v?([0-9]+)\.([0-9]+)\.([0-9]+))-([0-9]+)-g([0-9a-f]+)$`)
-
-		if len(gitDescribe) == 0 {
-			// No git tag and no previous semantic version -> using the default initial semantic version
-
-			c.version = semver.SemVer{
-				Major: 0, Minor: 1, Patch: 0,
-				Prerelease: []string{gitCommitCount},
-			}
-
-			if gitStatusClean {
-				c.version.AddPrerelease(gitShortSHA)
-			} else {
-				c.version.AddPrerelease("dev")
-			}
-		} else if subs := releaseRE.FindStringSubmatch(gitDescribe); len(subs) == 4 {
-			// The tag points to the HEAD commit
-			// Example: v0.2.7 --> subs = []string{"v0.2.7", "0", "2", "7"}
-
-			c.version, _ = semver.Parse(subs[0])
-
-			if !gitStatusClean {
-				c.version = c.version.Next()
-				c.version.AddPrerelease("0", "dev")
-			}
-		} else if subs := prereleaseRE.FindStringSubmatch(gitDescribe); len(subs) == 7 {
-			// The tag is the most recent tag reachable from the HEAD commit
-			// Example: v0.2.7-10-gabcdeff --> subs = []string{"v0.2.7-10-gabcdeff", "v0.2.7", "0", "2", "7", "10", "abcdeff"}
-
-			c.version, _ = semver.Parse(subs[1])
-			c.version = c.version.Next()
-			c.version.AddPrerelease(subs[5])
-
-			if gitStatusClean {
-				c.version.AddPrerelease(subs[6])
-			} else {
-				c.version.AddPrerelease("dev")
+		var err error
+		c.version, _, err = versioning.Resolve(ctx, repo, versionOptions(c.spec, project))
+		if err != nil {
+			c.ui.Error(fmt.Sprintf("Error on resolving semantic version: %s", err))
+			if errors.Is(err, versioning.ErrInvalidTag) {
+				return semverSemVerErr
 			}
+			return semverGitErr
 		}
 
 		c.ui.Output(c.version.String())
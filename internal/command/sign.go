@@ -0,0 +1,98 @@
+package command
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/moorara/cherry/internal/minisign"
+	"github.com/moorara/cherry/internal/spec"
+)
+
+const signatureExt = ".sig"
+
+// signingKey returns the minisign secret key for signing release artifacts.
+// The key is read from CHERRY_SIGNING_KEY environment variable or the key file in the spec.
+// If no key is set, false is returned.
+func signingKey(s spec.Signing) (minisign.PrivateKey, bool, error) {
+	text := os.Getenv("CHERRY_SIGNING_KEY")
+	if text == "" && s.KeyFile != "" {
+		data, err := ioutil.ReadFile(s.KeyFile)
+		if err != nil {
+			return minisign.PrivateKey{}, false, err
+		}
+		text = string(data)
+	}
+
+	if text == "" {
+		return minisign.PrivateKey{}, false, nil
+	}
+
+	key, err := minisign.ParsePrivateKey(text)
+	if err != nil {
+		return minisign.PrivateKey{}, false, err
+	}
+
+	// Signing with a key other than the one signatures are verified with results in unusable signatures
+	if s.PublicKey != "" {
+		pub, err := minisign.ParsePublicKey(s.PublicKey)
+		if err != nil {
+			return minisign.PrivateKey{}, false, err
+		}
+
+		if !equalPublicKeys(pub, key.Public()) {
+			return minisign.PrivateKey{}, false, fmt.Errorf("signing key %s does not match public key %s", key.ID, pub.ID)
+		}
+	}
+
+	return key, true, nil
+}
+
+// signFiles writes a signature file next to each file (i.e. app-linux-amd64.sig) and returns the paths of the signature files.
+// The trusted comment of a signature has the signing time and the file name in the same format as minisign.
+func signFiles(key minisign.PrivateKey, files []string, now time.Time) ([]string, error) {
+	sigFiles := []string{}
+
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		comment := fmt.Sprintf("timestamp:%d\tfile:%s", now.Unix(), filepath.Base(file))
+		sig, err := minisign.Sign(key, bytes.NewReader(data), comment)
+		if err != nil {
+			return nil, err
+		}
+
+		sigFile := file + signatureExt
+		if err := ioutil.WriteFile(sigFile, sig, 0644); err != nil {
+			return nil, err
+		}
+
+		sigFiles = append(sigFiles, sigFile)
+	}
+
+	return sigFiles, nil
+}
+
+// readPublicKey returns a minisign public key from a public key file or the base64 encoding of a public key.
+func readPublicKey(s string) (minisign.PublicKey, error) {
+	if s == "" {
+		return minisign.PublicKey{}, errors.New("no public key")
+	}
+
+	if data, err := ioutil.ReadFile(s); err == nil {
+		s = string(data)
+	}
+
+	return minisign.ParsePublicKey(s)
+}
+
+func equalPublicKeys(a, b minisign.PublicKey) bool {
+	return a.ID == b.ID && bytes.Equal(a.Key, b.Key)
+}
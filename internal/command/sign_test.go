@@ -0,0 +1,177 @@
+package command
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/moorara/cherry/internal/minisign"
+	"github.com/moorara/cherry/internal/spec"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSigningKey(t *testing.T) {
+	pub, priv, err := minisign.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	otherPub, _, err := minisign.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	dir, err := ioutil.TempDir("", "cherry-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	keyFile := filepath.Join(dir, "minisign.key")
+	assert.NoError(t, ioutil.WriteFile(keyFile, priv.Encode(), 0600))
+
+	tests := []struct {
+		name          string
+		env           string
+		signing       spec.Signing
+		expectedOK    bool
+		expectedError string
+	}{
+		{
+			name:       "NoKey",
+			signing:    spec.Signing{},
+			expectedOK: false,
+		},
+		{
+			name:       "FromEnv",
+			env:        string(priv.Encode()),
+			signing:    spec.Signing{},
+			expectedOK: true,
+		},
+		{
+			name:       "FromFile",
+			signing:    spec.Signing{KeyFile: keyFile, PublicKey: pub.String()},
+			expectedOK: true,
+		},
+		{
+			name:          "MissingFile",
+			signing:       spec.Signing{KeyFile: filepath.Join(dir, "missing.key")},
+			expectedError: "no such file or directory",
+		},
+		{
+			name:          "InvalidKey",
+			env:           pub.String(),
+			signing:       spec.Signing{},
+			expectedError: "invalid secret key: unknown format",
+		},
+		{
+			name:          "MismatchedPublicKey",
+			signing:       spec.Signing{KeyFile: keyFile, PublicKey: otherPub.String()},
+			expectedError: "does not match public key " + otherPub.ID.String(),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			os.Setenv("CHERRY_SIGNING_KEY", tc.env)
+			defer os.Unsetenv("CHERRY_SIGNING_KEY")
+
+			key, ok, err := signingKey(tc.signing)
+
+			if tc.expectedError != "" {
+				assert.Contains(t, err.Error(), tc.expectedError)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedOK, ok)
+				if ok {
+					assert.Equal(t, priv, key)
+				}
+			}
+		})
+	}
+}
+
+func TestSignFiles(t *testing.T) {
+	pub, priv, err := minisign.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	dir, err := ioutil.TempDir("", "cherry-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	files := []string{
+		filepath.Join(dir, "app-linux-amd64"),
+		filepath.Join(dir, "checksums.txt"),
+	}
+
+	for _, file := range files {
+		assert.NoError(t, ioutil.WriteFile(file, []byte("hello"), 0644))
+	}
+
+	now := time.Unix(1600000000, 0)
+	sigFiles, err := signFiles(priv, files, now)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{files[0] + ".sig", files[1] + ".sig"}, sigFiles)
+
+	for i, file := range files {
+		sig, err := ioutil.ReadFile(sigFiles[i])
+		assert.NoError(t, err)
+
+		comment, err := minisign.Verify(pub, bytes.NewReader([]byte("hello")), sig)
+		assert.NoError(t, err)
+		assert.Equal(t, "timestamp:1600000000\tfile:"+filepath.Base(file), comment)
+	}
+
+	t.Run("MissingFile", func(t *testing.T) {
+		_, err := signFiles(priv, []string{filepath.Join(dir, "missing")}, now)
+		assert.Error(t, err)
+	})
+}
+
+func TestReadPublicKey(t *testing.T) {
+	pub, _, err := minisign.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	dir, err := ioutil.TempDir("", "cherry-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	pubFile := filepath.Join(dir, "minisign.pub")
+	assert.NoError(t, ioutil.WriteFile(pubFile, pub.Encode(), 0644))
+
+	tests := []struct {
+		name          string
+		s             string
+		expectedError string
+	}{
+		{
+			name:          "Empty",
+			s:             "",
+			expectedError: "no public key",
+		},
+		{
+			name: "Base64",
+			s:    pub.String(),
+		},
+		{
+			name: "File",
+			s:    pubFile,
+		},
+		{
+			name:          "Invalid",
+			s:             filepath.Join(dir, "missing.pub"),
+			expectedError: "invalid public key",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			key, err := readPublicKey(tc.s)
+
+			if tc.expectedError != "" {
+				assert.Contains(t, err.Error(), tc.expectedError)
+			} else {
+				assert.NoError(t, err)
+				assert.True(t, equalPublicKeys(pub, key))
+			}
+		})
+	}
+}
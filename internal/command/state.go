@@ -0,0 +1,96 @@
+package command
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/moorara/cherry/internal/provider"
+)
+
+// releaseStateFile is the path of the file keeping the progress of a release relative to the repository.
+const releaseStateFile = ".cherry/release-state.json"
+
+// The steps of a release that are recorded once completed.
+const (
+	stepDraft     = "draft"
+	stepChangelog = "changelog"
+	stepCommit    = "commit"
+	stepTag       = "tag"
+	stepUpload    = "upload"
+	stepPush      = "push"
+	stepPushTag   = "push-tag"
+	stepPublish   = "publish"
+)
+
+// releaseState is the progress of a release saved for resuming it later.
+type releaseState struct {
+	Version      string           `json:"version"`
+	LastTag      string           `json:"lastTag"`
+	Tag          string           `json:"tag"`
+	Branch       string           `json:"branch"`
+	Project      string           `json:"project,omitempty"`
+	Release      provider.Release `json:"release"`
+	Comment      string           `json:"comment"`
+	Changelog    string           `json:"changelog"`
+	UpdateModule bool             `json:"updateModule,omitempty"`
+	Steps        []string         `json:"steps"`
+	// Protections are the branch protections removed for pushing to the release branch (see provider.ProtectionSaver).
+	Protections json.RawMessage `json:"protections,omitempty"`
+}
+
+// done determines whether or not a step is completed.
+func (s *releaseState) done(step string) bool {
+	for _, st := range s.Steps {
+		if st == step {
+			return true
+		}
+	}
+
+	return false
+}
+
+// loadReleaseState reads the state of a release from a repository.
+// If there is no state file, the returned error satisfies os.IsNotExist.
+func loadReleaseState(dir string) (*releaseState, error) {
+	data, err := ioutil.ReadFile(filepath.Join(dir, releaseStateFile))
+	if err != nil {
+		return nil, err
+	}
+
+	s := new(releaseState)
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// save writes the state of a release to a repository.
+func (s *releaseState) save(dir string) error {
+	path := filepath.Join(dir, releaseStateFile)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+// removeReleaseState removes the state of a release from a repository.
+func removeReleaseState(dir string) error {
+	path := filepath.Join(dir, releaseStateFile)
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	// The state directory is removed only if it is empty
+	_ = os.Remove(filepath.Dir(path))
+
+	return nil
+}
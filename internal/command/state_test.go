@@ -0,0 +1,53 @@
+package command
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/moorara/cherry/internal/provider"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReleaseState(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cherry-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	_, err = loadReleaseState(dir)
+	assert.True(t, os.IsNotExist(err))
+
+	s := &releaseState{
+		Version: "0.2.0",
+		LastTag: "v0.1.0",
+		Tag:     "v0.2.0",
+		Branch:  "master",
+		Release: provider.Release{
+			ID:      "1",
+			Name:    "0.2.0",
+			TagName: "v0.2.0",
+			Draft:   true,
+			Assets: []provider.Asset{
+				{ID: "2", Name: "cherry-linux-amd64"},
+			},
+		},
+		Changelog: "changes",
+		Steps:     []string{stepDraft, stepChangelog},
+	}
+
+	assert.True(t, s.done(stepDraft))
+	assert.False(t, s.done(stepCommit))
+
+	assert.NoError(t, s.save(dir))
+
+	loaded, err := loadReleaseState(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, s, loaded)
+
+	assert.NoError(t, removeReleaseState(dir))
+	assert.NoError(t, removeReleaseState(dir))
+
+	_, err = os.Stat(filepath.Join(dir, ".cherry"))
+	assert.True(t, os.IsNotExist(err))
+}
@@ -1,11 +1,13 @@
 package command
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
-	"io"
+	"io/ioutil"
 	"net/http"
 	"os"
 	"os/exec"
@@ -13,33 +15,56 @@ import (
 	"time"
 
 	"github.com/mitchellh/cli"
+	"github.com/moorara/cherry/internal/httputil"
+	"github.com/moorara/cherry/internal/minisign"
+	"github.com/moorara/cherry/internal/spec"
+	"github.com/moorara/cherry/pkg/semver"
 )
 
 const (
-	updateFlagErr   = 501
-	updateGitHubErr = 502
-	updateFileErr   = 503
-	updateTimeout   = time.Minute
+	updateFlagErr      = 501
+	updateGitHubErr    = 502
+	updateFileErr      = 503
+	updateSemVerErr    = 504
+	updateChecksumErr  = 505
+	updateSignatureErr = 506
+	updateTimeout      = time.Minute
 
 	updateSynopsis = `update cherry`
 	updateHelp     = `
 	Use this command for updating cherry to the latest release.
+	By default, only the releases compatible with the current major version are considered.
+	The binary is downloaded by itself or in an archive, depending on how the release is packaged.
+	The downloaded binary is verified against the checksums of the release before replacing the current one.
+	Releases without checksums are installed with a warning.
+	Its signature is also verified if a public key is embedded in the current binary.
+
+	Flags:
+
+		-constraint:  a semantic version constraint for the release  (default: ^<current major>.x)
 
 	Examples:
 
 		cherry update
+		cherry update -constraint "~1.4"
+		cherry update -constraint "*"
 	`
 )
 
+// errAssetNotFound is returned when a release does not have an asset.
+var errAssetNotFound = errors.New("asset not found")
+
 // updateCommand implements cli.Command interface.
 type updateCommand struct {
-	ui cli.Ui
+	ui   cli.Ui
+	spec spec.Spec
 }
 
 // NewUpdateCommand creates an update command.
-func NewUpdateCommand(ui cli.Ui) (cli.Command, error) {
+func NewUpdateCommand(ui cli.Ui, s spec.Spec) (cli.Command, error) {
 	return &updateCommand{
-		ui: ui,
+		ui:   ui,
+		spec: s,
 	}, nil
 }
 
@@ -55,7 +80,10 @@ func (c *updateCommand) Help() string {
 
 // Run runs the actual command with the given command-line arguments.
 func (c *updateCommand) Run(args []string) int {
+	var constraintText string
+
 	fs := flag.NewFlagSet("update", flag.ContinueOnError)
+	fs.StringVar(&constraintText, "constraint", "", "")
 	fs.Usage = func() {
 		c.ui.Output(c.Help())
 	}
@@ -64,6 +92,22 @@ func (c *updateCommand) Run(args []string) int {
 		return updateFlagErr
 	}
 
+	// Only the releases with the same major version are compatible with the current version
+	currentSemVer, currentErr := semver.Parse(c.spec.ToolVersion)
+	if constraintText == "" {
+		if currentErr == nil {
+			constraintText = fmt.Sprintf("^%d.x", currentSemVer.Major)
+		} else {
+			constraintText = "*"
+		}
+	}
+
+	constraint, err := semver.NewConstraint(constraintText)
+	if err != nil {
+		c.ui.Error(fmt.Sprintf("Invalid version constraint: %s", err))
+		return updateFlagErr
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), updateTimeout)
 	defer cancel()
 
@@ -109,10 +153,10 @@ func (c *updateCommand) Run(args []string) int {
 		}
 	}
 
-	// Get the latest release of Cherry from GitHub
-	// See https://docs.github.com/en/rest/reference/repos#get-the-latest-release
+	// Get the latest compatible release of Cherry from GitHub
+	// See https://docs.github.com/en/rest/reference/repos#list-releases
 
-	release := struct {
+	type githubRelease struct {
 		ID         int    `json:"id"`
 		Name       string `json:"name"`
 		TagName    string `json:"tag_name"`
@@ -134,65 +178,161 @@ func (c *updateCommand) Run(args []string) int {
 			URL         string `json:"url"`
 			DownloadURL string `json:"browser_download_url"`
 		} `json:"assets"`
-	}{}
+	}
+
+	var release githubRelease
 
 	{
-		c.ui.Output(fmt.Sprintf("⬇ Finding the latest release of Cherry ..."))
+		c.ui.Output(fmt.Sprintf("⬇ Finding the latest release of Cherry matching %s ...", constraint))
 
-		url := "https://api.github.com/repos/moorara/cherry/releases/latest"
-		req, _ := http.NewRequest("GET", url, nil)
-		req = req.WithContext(ctx)
-		req.Header.Set("Authorization", "token "+githubToken)
-		req.Header.Set("Accept", "application/vnd.github.v3+json")
-		req.Header.Set("User-Agent", "cherry") // ref: https://docs.github.com/en/rest/overview/resources-in-the-rest-api#user-agent-required
-		req.Header.Set("Content-Type", "application/json")
+		releases := []githubRelease{}
+
+		header := http.Header{}
+		header.Set("Authorization", "token "+githubToken)
+		header.Set("Accept", "application/vnd.github.v3+json")
+		header.Set("User-Agent", "cherry") // ref: https://docs.github.com/en/rest/overview/resources-in-the-rest-api#user-agent-required
+
+		// The pages of releases are followed using the Link header until there is no next page
+		url := "https://api.github.com/repos/moorara/cherry/releases?per_page=100"
+		err := httputil.ListPages(ctx, client, url, header, func(data []byte) (bool, error) {
+			page := []githubRelease{}
+			if err := json.Unmarshal(data, &page); err != nil {
+				return false, err
+			}
+
+			releases = append(releases, page...)
+			return true, nil
+		})
 
-		res, err := client.Do(req)
 		if err != nil {
-			c.ui.Error(fmt.Sprintf("Error on getting the latest release of Cherry from GitHub: %s", err))
+			c.ui.Error(fmt.Sprintf("Error on getting the releases of Cherry from GitHub: %s", err))
 			return updateGitHubErr
 		}
-		defer res.Body.Close()
 
-		if res.StatusCode != 200 {
-			c.ui.Error(fmt.Sprintf("Error on getting the latest release of Cherry from GitHub: invalid status code %d", res.StatusCode))
-			return updateGitHubErr
+		var latest, compatible semver.SemVer
+		var latestFound, compatibleFound bool
+
+		for _, r := range releases {
+			if r.Draft || r.Prerelease {
+				continue
+			}
+
+			v, err := semver.Parse(r.TagName)
+			if err != nil {
+				continue
+			}
+
+			if !latestFound || v.GreaterThan(latest) {
+				latest, latestFound = v, true
+			}
+
+			if constraint.Check(v) && (!compatibleFound || v.GreaterThan(compatible)) {
+				compatible, compatibleFound = v, true
+				release = r
+			}
 		}
 
-		err = json.NewDecoder(res.Body).Decode(&release)
-		if err != nil {
-			c.ui.Error(fmt.Sprintf("Error on getting the latest release of Cherry from GitHub: %s", err))
-			return updateGitHubErr
+		// Explain why the latest release is not going to be installed
+		if latestFound && (!compatibleFound || latest.GreaterThan(compatible)) {
+			_, errs := constraint.Validate(latest)
+			for _, err := range errs {
+				c.ui.Warn(fmt.Sprintf("Skipping Cherry %s: %s", latest, err))
+			}
+		}
+
+		if !compatibleFound {
+			c.ui.Error(fmt.Sprintf("No release of Cherry found matching %s", constraint))
+			return updateSemVerErr
+		}
+
+		if currentErr == nil && !compatible.GreaterThan(currentSemVer) {
+			c.ui.Info(fmt.Sprintf("🍒 Cherry %s is already up-to-date", currentSemVer))
+			return 0
 		}
 	}
 
-	// Download the binary for Cherry from GitHub
+	// Download the binary for Cherry from GitHub and verify its checksum and signature
 
-	var resBody io.ReadCloser
+	var binary []byte
 
 	{
 		c.ui.Output(fmt.Sprintf("⬇ Downloading Cherry %s ...", release.TagName))
 
-		assetName := fmt.Sprintf("cherry-%s-%s", runtime.GOOS, runtime.GOARCH)
-		url := fmt.Sprintf("https://github.com/moorara/cherry/releases/download/%s/%s", release.TagName, assetName)
-		req, _ := http.NewRequest("GET", url, nil)
-		req = req.WithContext(ctx)
-		req.Header.Set("Authorization", "token "+githubToken)
-		req.Header.Set("User-Agent", "cherry") // ref: https://docs.github.com/en/rest/overview/resources-in-the-rest-api#user-agent-required
+		platform := spec.Platform{OS: runtime.GOOS, Arch: runtime.GOARCH}
 
-		res, err := client.Do(req)
+		var assets []string
+		for _, a := range release.Assets {
+			assets = append(assets, a.Name)
+		}
+
+		version, err := semver.Parse(release.TagName)
+		if err != nil {
+			c.ui.Error(fmt.Sprintf("Invalid semantic version for release %s: %s", release.TagName, err))
+			return updateSemVerErr
+		}
+
+		assetName, format, err := updateAsset(assets, version.String(), platform)
+		if err != nil {
+			c.ui.Error(fmt.Sprintf("Error on finding the Cherry binary in release %s: %s", release.TagName, err))
+			return updateGitHubErr
+		}
+
+		asset, err := c.download(ctx, client, githubToken, release.TagName, assetName)
 		if err != nil {
 			c.ui.Error(fmt.Sprintf("Error on downloading the latest Cherry binary from GitHub: %s", err))
 			return updateGitHubErr
 		}
-		defer res.Body.Close()
 
-		if res.StatusCode != 200 {
-			c.ui.Error(fmt.Sprintf("Error on downloading the latest Cherry binary from GitHub: invalid status code %d", res.StatusCode))
+		// The releases made before publishing checksums do not have them
+		data, err := c.download(ctx, client, githubToken, release.TagName, checksumsFile)
+		switch {
+		case errors.Is(err, errAssetNotFound):
+			c.ui.Warn(fmt.Sprintf("No checksums are published for Cherry %s, so the checksum of the new binary is not verified.", release.TagName))
+		case err != nil:
+			c.ui.Error(fmt.Sprintf("Error on downloading the checksums of Cherry %s from GitHub: %s", release.TagName, err))
 			return updateGitHubErr
+		default:
+			checksums, err := parseChecksums(data)
+			if err != nil {
+				c.ui.Error(fmt.Sprintf("Error on reading the checksums of Cherry %s: %s", release.TagName, err))
+				return updateChecksumErr
+			}
+
+			if err := verifyChecksum(checksums, assetName, asset); err != nil {
+				c.ui.Error(fmt.Sprintf("Error on verifying the Cherry binary: %s", err))
+				return updateChecksumErr
+			}
 		}
 
-		resBody = res.Body
+		// The signature is verified using the public key embedded in the current binary
+		if c.spec.ToolPublicKey == "" {
+			c.ui.Warn("No public key is embedded in this binary, so the signature of the new binary is not verified.")
+		} else {
+			key, err := minisign.ParsePublicKey(c.spec.ToolPublicKey)
+			if err != nil {
+				c.ui.Error(fmt.Sprintf("Error on parsing the embedded public key: %s", err))
+				return updateSignatureErr
+			}
+
+			sig, err := c.download(ctx, client, githubToken, release.TagName, assetName+signatureExt)
+			if err != nil {
+				c.ui.Error(fmt.Sprintf("Error on downloading the signature of the latest Cherry binary from GitHub: %s", err))
+				return updateGitHubErr
+			}
+
+			if _, err := minisign.Verify(key, bytes.NewReader(asset), sig); err != nil {
+				c.ui.Error(fmt.Sprintf("Error on verifying the signature of the Cherry binary: %s", err))
+				return updateSignatureErr
+			}
+		}
+
+		binary = asset
+		if format != "" {
+			if binary, err = extractFile(asset, format, "cherry"+platform.Ext()); err != nil {
+				c.ui.Error(fmt.Sprintf("Error on extracting the Cherry binary from %s: %s", assetName, err))
+				return updateFileErr
+			}
+		}
 	}
 
 	// Write the new binary to disk
@@ -209,7 +349,7 @@ func (c *updateCommand) Run(args []string) int {
 			return updateFileErr
 		}
 
-		_, err = io.Copy(file, resBody)
+		_, err = file.Write(binary)
 		if err != nil {
 			c.ui.Error(fmt.Sprintf("Error on writing to %s: %s", binPath, err))
 			return updateFileErr
@@ -220,3 +360,58 @@ func (c *updateCommand) Run(args []string) int {
 
 	return 0
 }
+
+// download downloads an asset of a release of Cherry from GitHub.
+func (c *updateCommand) download(ctx context.Context, client *http.Client, githubToken, tag, assetName string) ([]byte, error) {
+	url := fmt.Sprintf("https://github.com/moorara/cherry/releases/download/%s/%s", tag, assetName)
+	req, _ := http.NewRequest("GET", url, nil)
+	req = req.WithContext(ctx)
+	req.Header.Set("Authorization", "token "+githubToken)
+	req.Header.Set("User-Agent", "cherry") // ref: https://docs.github.com/en/rest/overview/resources-in-the-rest-api#user-agent-required
+
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == 404 {
+		return nil, fmt.Errorf("%w: %s", errAssetNotFound, assetName)
+	}
+
+	if res.StatusCode != 200 {
+		return nil, fmt.Errorf("invalid status code %d for %s", res.StatusCode, assetName)
+	}
+
+	return ioutil.ReadAll(res.Body)
+}
+
+// updateAsset returns the name of the release asset with the Cherry binary for a platform
+// and the archive format if the binary is packaged in an archive.
+// The binary (i.e. cherry-linux-amd64) is preferred over the archive (i.e. cherry_1.2.3_linux_amd64.tar.gz).
+func updateAsset(assets []string, version string, p spec.Platform) (string, string, error) {
+	names := map[string]bool{}
+	for _, name := range assets {
+		names[name] = true
+	}
+
+	binName := fmt.Sprintf("cherry-%s%s", p, p.Ext())
+	if names[binName] {
+		return binName, "", nil
+	}
+
+	archives := spec.Archives{}.WithDefaults()
+	for _, format := range []string{"tar.gz", "zip"} {
+		archives.Format = format
+		name, err := archives.ArchiveName("cherry", version, p)
+		if err != nil {
+			return "", "", err
+		}
+
+		if names[name] {
+			return name, format, nil
+		}
+	}
+
+	return "", "", fmt.Errorf("no binary or archive found for %s (i.e. %s)", p, binName)
+}
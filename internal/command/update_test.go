@@ -0,0 +1,66 @@
+package command
+
+import (
+	"testing"
+
+	"github.com/moorara/cherry/internal/spec"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateAsset(t *testing.T) {
+	tests := []struct {
+		name           string
+		assets         []string
+		platform       spec.Platform
+		expectedName   string
+		expectedFormat string
+		expectedError  string
+	}{
+		{
+			name:         "Binary",
+			assets:       []string{"checksums.txt", "cherry-linux-amd64", "cherry_1.2.3_linux_amd64.tar.gz"},
+			platform:     spec.Platform{OS: "linux", Arch: "amd64"},
+			expectedName: "cherry-linux-amd64",
+		},
+		{
+			name:         "WindowsBinary",
+			assets:       []string{"cherry-windows-amd64.exe"},
+			platform:     spec.Platform{OS: "windows", Arch: "amd64"},
+			expectedName: "cherry-windows-amd64.exe",
+		},
+		{
+			name:           "TarGzArchive",
+			assets:         []string{"checksums.txt", "cherry_1.2.3_darwin_amd64.tar.gz", "cherry_1.2.3_linux_amd64.tar.gz"},
+			platform:       spec.Platform{OS: "linux", Arch: "amd64"},
+			expectedName:   "cherry_1.2.3_linux_amd64.tar.gz",
+			expectedFormat: "tar.gz",
+		},
+		{
+			name:           "ZipArchive",
+			assets:         []string{"cherry_1.2.3_windows_amd64.zip"},
+			platform:       spec.Platform{OS: "windows", Arch: "amd64"},
+			expectedName:   "cherry_1.2.3_windows_amd64.zip",
+			expectedFormat: "zip",
+		},
+		{
+			name:          "NotFound",
+			assets:        []string{"cherry-darwin-amd64", "cherry_1.2.3_darwin_amd64.tar.gz"},
+			platform:      spec.Platform{OS: "linux", Arch: "amd64"},
+			expectedError: "no binary or archive found for linux-amd64",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			name, format, err := updateAsset(tc.assets, "1.2.3", tc.platform)
+
+			if tc.expectedError != "" {
+				assert.Contains(t, err.Error(), tc.expectedError)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedName, name)
+				assert.Equal(t, tc.expectedFormat, format)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package command
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/mitchellh/cli"
+	"github.com/moorara/cherry/internal/minisign"
+	"github.com/moorara/cherry/internal/spec"
+)
+
+const (
+	verifyFlagErr      = 701
+	verifyFileErr      = 702
+	verifySignatureErr = 703
+
+	verifySynopsis = `verify signatures of artifacts`
+	verifyHelp     = `
+	Use this command for verifying the minisign signatures of release artifacts.
+	The signature of a file is read from the file with the same name and .sig extension (i.e. app-linux-amd64.sig).
+
+	Flags:
+
+		-public-key:  a minisign public key or path to a public key file  (default: public key in the spec file)
+
+	Examples:
+
+		cherry verify bin/app-linux-amd64
+		cherry verify -public-key minisign.pub bin/*
+		cherry verify -public-key RWQf6LRCGA9i53mlYecO4IzT51TGPpvWucNSCh1CBM0QTaLn73Y7GFO3 checksums.txt
+	`
+)
+
+// verifyCommand implements cli.Command interface.
+type verifyCommand struct {
+	ui   cli.Ui
+	spec spec.Spec
+}
+
+// NewVerifyCommand creates a verify command.
+func NewVerifyCommand(ui cli.Ui, s spec.Spec) (cli.Command, error) {
+	return &verifyCommand{
+		ui:   ui,
+		spec: s,
+	}, nil
+}
+
+// Synopsis returns a short one-line synopsis of the command.
+func (c *verifyCommand) Synopsis() string {
+	return verifySynopsis
+}
+
+// Help returns a long help text including usage, description, and list of flags for the command.
+func (c *verifyCommand) Help() string {
+	return verifyHelp
+}
+
+// Run runs the actual command with the given command-line arguments.
+func (c *verifyCommand) Run(args []string) int {
+	var publicKey string
+
+	fs := flag.NewFlagSet("verify", flag.ContinueOnError)
+	fs.StringVar(&publicKey, "public-key", c.spec.Release.Signing.PublicKey, "")
+	fs.Usage = func() {
+		c.ui.Output(c.Help())
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return verifyFlagErr
+	}
+
+	// The signature files are skipped, so all artifacts in a directory can be verified using a glob (i.e. bin/*)
+	var files []string
+	for _, file := range fs.Args() {
+		if !strings.HasSuffix(file, signatureExt) {
+			files = append(files, file)
+		}
+	}
+
+	if len(files) == 0 {
+		c.ui.Error("No file to verify.")
+		return verifyFlagErr
+	}
+
+	key, err := readPublicKey(publicKey)
+	if err != nil {
+		c.ui.Error(fmt.Sprintf("Error on reading public key: %s", err))
+		return verifyFlagErr
+	}
+
+	// All files are verified and the first failure determines the exit code
+
+	code := 0
+
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			c.ui.Error(fmt.Sprintf("Error on reading %s: %s", file, err))
+			if code == 0 {
+				code = verifyFileErr
+			}
+			continue
+		}
+
+		sig, err := ioutil.ReadFile(file + signatureExt)
+		if err != nil {
+			c.ui.Error(fmt.Sprintf("Error on reading the signature of %s: %s", file, err))
+			if code == 0 {
+				code = verifyFileErr
+			}
+			continue
+		}
+
+		comment, err := minisign.Verify(key, bytes.NewReader(data), sig)
+		if err != nil {
+			c.ui.Error(fmt.Sprintf("Error on verifying %s: %s", file, err))
+			if code == 0 {
+				code = verifySignatureErr
+			}
+			continue
+		}
+
+		c.ui.Info(fmt.Sprintf("✔ %s (%s)", file, comment))
+	}
+
+	return code
+}
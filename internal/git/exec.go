@@ -0,0 +1,108 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// execRepository implements Repository by running the git binary.
+type execRepository struct {
+	dir string
+}
+
+// NewExecRepository creates a repository running the git binary in a directory.
+func NewExecRepository(dir string) Repository {
+	return &execRepository{
+		dir: dir,
+	}
+}
+
+// Head returns the full hash of the HEAD commit.
+func (r *execRepository) Head(ctx context.Context) (string, error) {
+	return Run(ctx, r.dir, "rev-parse", "HEAD")
+}
+
+// Branch returns the name of the current branch or HEAD if detached.
+func (r *execRepository) Branch(ctx context.Context) (string, error) {
+	return Run(ctx, r.dir, "rev-parse", "--abbrev-ref", "HEAD")
+}
+
+// IsClean determines whether or not the working tree has no staged, unstaged, or untracked changes.
+func (r *execRepository) IsClean(ctx context.Context) (bool, error) {
+	out, err := Run(ctx, r.dir, "status", "--porcelain")
+	if err != nil {
+		return false, err
+	}
+
+	return len(out) == 0, nil
+}
+
+// CommitCount returns the number of commits reachable from a revision.
+func (r *execRepository) CommitCount(ctx context.Context, rev string) (int, error) {
+	out, err := Run(ctx, r.dir, "rev-list", "--count", rev)
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.Atoi(out)
+}
+
+// Describe returns the most recent tag reachable from a revision.
+func (r *execRepository) Describe(ctx context.Context, rev string, opts DescribeOptions) (Description, error) {
+	args := []string{"describe", "--tags", "--long", "--abbrev=40"}
+	for _, pattern := range opts.Match {
+		args = append(args, "--match", pattern)
+	}
+	for _, pattern := range opts.Exclude {
+		args = append(args, "--exclude", pattern)
+	}
+	args = append(args, rev)
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = r.dir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		// 128 is returned when there is no git tag, but also for an invalid revision or repository
+		// The revision is checked instead of the error message, since the message depends on the git version and locale
+		if exiterr, ok := err.(*exec.ExitError); ok && exiterr.ExitCode() == 128 {
+			if _, verr := Run(ctx, r.dir, "rev-parse", "--verify", "--quiet", rev+"^{commit}"); verr == nil {
+				return Description{}, ErrNoTag
+			}
+		}
+		return Description{}, fmt.Errorf("git %s: %s %s", strings.Join(args, " "), err, strings.Trim(stderr.String(), "\n"))
+	}
+
+	return parseDescribe(strings.Trim(stdout.String(), "\n"))
+}
+
+// parseDescribe parses the output of git describe --long.
+// Tags may have - in their names, so the output is parsed from the end.
+// Example: v0.2.7-10-gabcdeff --> Description{Tag: "v0.2.7", Distance: 10, Commit: "abcdeff"}
+func parseDescribe(out string) (Description, error) {
+	i := strings.LastIndex(out, "-g")
+	if i == -1 {
+		return Description{}, fmt.Errorf("unexpected git describe output: %s", out)
+	}
+
+	j := strings.LastIndex(out[:i], "-")
+	if j == -1 {
+		return Description{}, fmt.Errorf("unexpected git describe output: %s", out)
+	}
+
+	distance, err := strconv.Atoi(out[j+1 : i])
+	if err != nil {
+		return Description{}, fmt.Errorf("unexpected git describe output: %s", out)
+	}
+
+	return Description{
+		Tag:      out[:j],
+		Distance: distance,
+		Commit:   out[i+2:],
+	}, nil
+}
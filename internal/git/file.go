@@ -0,0 +1,386 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// fileRepository implements Repository by reading the .git directory directly.
+// It does not require the git binary, but it does not support the SHA-256 object format,
+// clean/smudge filters (i.e. core.autocrlf), and the global excludes file for untracked files.
+type fileRepository struct {
+	workDir   string // the top-level directory of the working tree
+	gitDir    string // the .git directory of the working tree (i.e. .git/worktrees/<name> for linked worktrees)
+	commonDir string // the directory with objects and refs shared between worktrees
+	objects   *objectStore
+	shallow   map[string]bool
+
+	mu      sync.Mutex
+	commits map[string]*commit
+}
+
+// NewFileRepository creates a repository reading the .git directory of a working tree.
+// dir can be any directory inside the working tree.
+func NewFileRepository(dir string) (Repository, error) {
+	return openFileRepository(dir)
+}
+
+func openFileRepository(dir string) (*fileRepository, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	// Find the top-level directory of the working tree
+	workDir := dir
+	for {
+		if _, err := os.Stat(filepath.Join(workDir, ".git")); err == nil {
+			break
+		}
+
+		parent := filepath.Dir(workDir)
+		if parent == workDir {
+			return nil, fmt.Errorf("not a git repository: %s", dir)
+		}
+		workDir = parent
+	}
+
+	gitDir := filepath.Join(workDir, ".git")
+
+	// Example: gitdir: /path/to/repo/.git/worktrees/feature
+	if info, err := os.Stat(gitDir); err == nil && !info.IsDir() {
+		data, err := ioutil.ReadFile(gitDir)
+		if err != nil {
+			return nil, err
+		}
+
+		line := strings.TrimSpace(string(data))
+		if !strings.HasPrefix(line, "gitdir: ") {
+			return nil, fmt.Errorf("invalid .git file: %s", gitDir)
+		}
+
+		gitDir = strings.TrimPrefix(line, "gitdir: ")
+		if !filepath.IsAbs(gitDir) {
+			gitDir = filepath.Join(workDir, gitDir)
+		}
+	}
+
+	commonDir := gitDir
+	if data, err := ioutil.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+		commonDir = strings.TrimSpace(string(data))
+		if !filepath.IsAbs(commonDir) {
+			commonDir = filepath.Join(gitDir, commonDir)
+		}
+	}
+
+	if data, err := ioutil.ReadFile(filepath.Join(commonDir, "config")); err == nil && bytes.Contains(bytes.ToLower(data), []byte("objectformat = sha256")) {
+		return nil, errors.New("sha256 object format is not supported")
+	}
+
+	// A shallow clone does not have the parents of the commits listed in the shallow file
+	shallow := map[string]bool{}
+	if data, err := ioutil.ReadFile(filepath.Join(commonDir, "shallow")); err == nil {
+		for _, line := range strings.Fields(string(data)) {
+			shallow[line] = true
+		}
+	}
+
+	return &fileRepository{
+		workDir:   workDir,
+		gitDir:    gitDir,
+		commonDir: commonDir,
+		objects:   newObjectStore(filepath.Join(commonDir, "objects")),
+		shallow:   shallow,
+		commits:   map[string]*commit{},
+	}, nil
+}
+
+// readRef returns the content of a reference which is either a hash or a symbolic reference (i.e. ref: refs/heads/main).
+func (r *fileRepository) readRef(name string) (string, bool, error) {
+	// HEAD is specific to a worktree
+	dir := r.commonDir
+	if name == "HEAD" {
+		dir = r.gitDir
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+	if err == nil {
+		return strings.TrimSpace(string(data)), true, nil
+	}
+	if !os.IsNotExist(err) {
+		return "", false, err
+	}
+
+	refs, err := r.packedRefs()
+	if err != nil {
+		return "", false, err
+	}
+
+	hash, ok := refs[name]
+	return hash, ok, nil
+}
+
+// resolveRef follows a reference to the hash it points to.
+func (r *fileRepository) resolveRef(name string) (string, bool, error) {
+	for i := 0; i < 10; i++ {
+		val, ok, err := r.readRef(name)
+		if err != nil || !ok {
+			return "", ok, err
+		}
+
+		if !strings.HasPrefix(val, "ref: ") {
+			return val, true, nil
+		}
+		name = strings.TrimPrefix(val, "ref: ")
+	}
+
+	return "", false, fmt.Errorf("too many levels of symbolic references: %s", name)
+}
+
+// packedRefs returns the references in the packed-refs file.
+func (r *fileRepository) packedRefs() (map[string]string, error) {
+	refs := map[string]string{}
+
+	data, err := ioutil.ReadFile(filepath.Join(r.commonDir, "packed-refs"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return refs, nil
+		}
+		return nil, err
+	}
+
+	// Example: <hash> refs/tags/v0.1.0
+	// Peeled tags are followed by ^<hash> which is ignored since tags are peeled when read
+	for _, line := range strings.Split(string(data), "\n") {
+		if fields := strings.Fields(line); len(fields) == 2 && !strings.HasPrefix(line, "#") && !strings.HasPrefix(line, "^") {
+			refs[fields[1]] = fields[0]
+		}
+	}
+
+	return refs, nil
+}
+
+// tags returns a map of tag names to the commits they point to.
+func (r *fileRepository) tags() (map[string]string, error) {
+	refs, err := r.packedRefs()
+	if err != nil {
+		return nil, err
+	}
+
+	tags := map[string]string{}
+	for name, hash := range refs {
+		if strings.HasPrefix(name, "refs/tags/") {
+			tags[strings.TrimPrefix(name, "refs/tags/")] = hash
+		}
+	}
+
+	// Loose references take precedence over packed references
+	tagsDir := filepath.Join(r.commonDir, "refs", "tags")
+	err = filepath.Walk(tagsDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == tagsDir {
+				return nil
+			}
+			return err
+		}
+
+		if info.IsDir() {
+			return nil
+		}
+
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(tagsDir, path)
+		if err != nil {
+			return err
+		}
+
+		tags[filepath.ToSlash(rel)] = strings.TrimSpace(string(data))
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Annotated tags are peeled to the commits they point to
+	for name, hash := range tags {
+		commit, err := r.peel(hash)
+		if err != nil {
+			return nil, err
+		}
+
+		if commit == "" {
+			// Tags that do not point to a commit cannot describe a commit
+			delete(tags, name)
+		} else {
+			tags[name] = commit
+		}
+	}
+
+	return tags, nil
+}
+
+// peel follows tag objects to the commit they point to.
+// It returns an empty hash if the object is not a commit.
+func (r *fileRepository) peel(hash string) (string, error) {
+	for i := 0; i < 10; i++ {
+		typ, data, err := r.objects.read(hash)
+		if err != nil {
+			return "", err
+		}
+
+		switch typ {
+		case objCommit:
+			return hash, nil
+		case objTag:
+			// Example: object <hash>\ntype commit\ntag v0.1.0\n...
+			if !bytes.HasPrefix(data, []byte("object ")) || len(data) < 47 {
+				return "", fmt.Errorf("invalid tag object: %s", hash)
+			}
+			hash = string(data[7:47])
+		default:
+			return "", nil
+		}
+	}
+
+	return "", fmt.Errorf("too many levels of tag objects: %s", hash)
+}
+
+// resolve returns the commit hash of a revision.
+// The supported revisions are HEAD, full hashes, and full or short reference names.
+func (r *fileRepository) resolve(rev string) (string, error) {
+	if len(rev) == 40 {
+		if _, err := hex.DecodeString(rev); err == nil {
+			return r.peel(rev)
+		}
+	}
+
+	for _, name := range []string{rev, "refs/" + rev, "refs/tags/" + rev, "refs/heads/" + rev, "refs/remotes/" + rev} {
+		hash, ok, err := r.resolveRef(name)
+		if err != nil {
+			return "", err
+		}
+		if ok {
+			return r.peel(hash)
+		}
+	}
+
+	return "", fmt.Errorf("unknown revision: %s", rev)
+}
+
+// commit returns a parsed commit object.
+func (r *fileRepository) commit(hash string) (*commit, error) {
+	r.mu.Lock()
+	c, ok := r.commits[hash]
+	r.mu.Unlock()
+	if ok {
+		return c, nil
+	}
+
+	typ, data, err := r.objects.read(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	if typ != objCommit {
+		return nil, fmt.Errorf("object %s is not a commit", hash)
+	}
+
+	c = &commit{
+		hash: hash,
+	}
+
+	// The headers end at the first empty line
+	for _, line := range strings.Split(string(data), "\n") {
+		if line == "" {
+			break
+		}
+
+		switch {
+		case strings.HasPrefix(line, "parent "):
+			if !r.shallow[hash] {
+				c.parents = append(c.parents, strings.TrimPrefix(line, "parent "))
+			}
+		case strings.HasPrefix(line, "committer "):
+			// Example: committer Milad <milad@example.com> 1600000000 -0400
+			if fields := strings.Fields(line); len(fields) >= 3 {
+				if sec, err := strconv.ParseInt(fields[len(fields)-2], 10, 64); err == nil {
+					c.time = time.Unix(sec, 0)
+				}
+			}
+		}
+	}
+
+	r.mu.Lock()
+	r.commits[hash] = c
+	r.mu.Unlock()
+
+	return c, nil
+}
+
+// Head returns the full hash of the HEAD commit.
+func (r *fileRepository) Head(ctx context.Context) (string, error) {
+	return r.resolve("HEAD")
+}
+
+// Branch returns the name of the current branch or HEAD if detached.
+func (r *fileRepository) Branch(ctx context.Context) (string, error) {
+	val, ok, err := r.readRef("HEAD")
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", errors.New("HEAD not found")
+	}
+
+	// Example: ref: refs/heads/main
+	if strings.HasPrefix(val, "ref: refs/heads/") {
+		return strings.TrimPrefix(val, "ref: refs/heads/"), nil
+	}
+
+	return "HEAD", nil
+}
+
+// CommitCount returns the number of commits reachable from a revision.
+func (r *fileRepository) CommitCount(ctx context.Context, rev string) (int, error) {
+	hash, err := r.resolve(rev)
+	if err != nil {
+		return 0, err
+	}
+
+	commits, err := ancestors(r, hash)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(commits), nil
+}
+
+// Describe returns the most recent tag reachable from a revision.
+func (r *fileRepository) Describe(ctx context.Context, rev string, opts DescribeOptions) (Description, error) {
+	hash, err := r.resolve(rev)
+	if err != nil {
+		return Description{}, err
+	}
+
+	tags, err := r.tags()
+	if err != nil {
+		return Description{}, err
+	}
+
+	return describe(r, hash, tags, opts)
+}
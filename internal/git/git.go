@@ -0,0 +1,72 @@
+// Package git provides access to git repositories.
+// The default implementation runs the git binary and a pure-Go implementation reads the .git directory directly.
+package git
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// ErrNoTag is returned by Describe when there is no tag reachable from a revision.
+var ErrNoTag = errors.New("no tag found")
+
+// DescribeOptions are the options for describing a revision.
+type DescribeOptions struct {
+	// Match only considers the tags matching at least one of these glob patterns (i.e. v[0-9]*).
+	Match []string
+	// Exclude does not consider the tags matching any of these glob patterns (i.e. services/api/*).
+	Exclude []string
+}
+
+// Description is the most recent tag reachable from a revision.
+type Description struct {
+	// Tag is the name of the tag.
+	Tag string
+	// Distance is the number of commits since the tagged commit.
+	Distance int
+	// Commit is the full hash of the commit described.
+	Commit string
+}
+
+// Repository is the read-only queries on a git repository.
+type Repository interface {
+	// Head returns the full hash of the HEAD commit.
+	Head(ctx context.Context) (string, error)
+	// Branch returns the name of the current branch or HEAD if detached.
+	Branch(ctx context.Context) (string, error)
+	// IsClean determines whether or not the working tree has no staged, unstaged, or untracked changes.
+	IsClean(ctx context.Context) (bool, error)
+	// CommitCount returns the number of commits reachable from a revision.
+	CommitCount(ctx context.Context, rev string) (int, error)
+	// Describe returns the most recent tag reachable from a revision.
+	// If there is no such tag, ErrNoTag is returned.
+	Describe(ctx context.Context, rev string, opts DescribeOptions) (Description, error)
+}
+
+// Open returns a repository for a directory inside a git working tree.
+// The git binary is used if available, otherwise the .git directory is read directly.
+func Open(dir string) (Repository, error) {
+	if _, err := exec.LookPath("git"); err == nil {
+		return NewExecRepository(dir), nil
+	}
+
+	return NewFileRepository(dir)
+}
+
+// Run runs a git command in a directory and returns its output without the trailing new line.
+func Run(ctx context.Context, dir string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s: %s %s", strings.Join(args, " "), err, strings.Trim(stderr.String(), "\n"))
+	}
+
+	return strings.Trim(stdout.String(), "\n"), nil
+}
@@ -0,0 +1,386 @@
+package git
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testRepo is a git repository created by the git binary for comparing the implementations.
+type testRepo struct {
+	t    *testing.T
+	dir  string
+	home string
+}
+
+func newTestRepo(t *testing.T) *testRepo {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git binary not found")
+	}
+
+	root, err := ioutil.TempDir("", "cherry-")
+	assert.NoError(t, err)
+
+	r := &testRepo{
+		t:    t,
+		dir:  filepath.Join(root, "repo"),
+		home: root,
+	}
+
+	assert.NoError(t, os.Mkdir(r.dir, 0755))
+	r.git("init", "-q")
+	r.git("checkout", "-q", "-b", "main")
+
+	return r
+}
+
+func (r *testRepo) cleanup() {
+	os.RemoveAll(r.home)
+}
+
+func (r *testRepo) git(args ...string) string {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = r.dir
+	// The global configurations of the user running the tests are not used
+	cmd.Env = append(os.Environ(),
+		"HOME="+r.home,
+		"XDG_CONFIG_HOME="+r.home,
+		"GIT_CONFIG_NOSYSTEM=1",
+		"GIT_AUTHOR_NAME=Octocat",
+		"GIT_AUTHOR_EMAIL=octocat@example.com",
+		"GIT_COMMITTER_NAME=Octocat",
+		"GIT_COMMITTER_EMAIL=octocat@example.com",
+	)
+
+	out, err := cmd.CombinedOutput()
+	if !assert.NoError(r.t, err, string(out)) {
+		r.t.FailNow()
+	}
+
+	return strings.TrimSpace(string(out))
+}
+
+func (r *testRepo) write(name, content string) {
+	path := filepath.Join(r.dir, filepath.FromSlash(name))
+	assert.NoError(r.t, os.MkdirAll(filepath.Dir(path), 0755))
+	assert.NoError(r.t, ioutil.WriteFile(path, []byte(content), 0644))
+}
+
+func (r *testRepo) commit(message string) {
+	r.git("add", "-A")
+	r.git("commit", "-q", "--allow-empty", "-m", message)
+}
+
+// assertSame verifies the exec and file implementations return the same results.
+func (r *testRepo) assertSame(rev string, opts DescribeOptions) {
+	ctx := context.Background()
+
+	execRepo := NewExecRepository(r.dir)
+	fileRepo, err := NewFileRepository(r.dir)
+	assert.NoError(r.t, err)
+
+	execHead, execErr := execRepo.Head(ctx)
+	fileHead, fileErr := fileRepo.Head(ctx)
+	assert.NoError(r.t, execErr)
+	assert.NoError(r.t, fileErr)
+	assert.Equal(r.t, execHead, fileHead, "Head")
+
+	execBranch, execErr := execRepo.Branch(ctx)
+	fileBranch, fileErr := fileRepo.Branch(ctx)
+	assert.NoError(r.t, execErr)
+	assert.NoError(r.t, fileErr)
+	assert.Equal(r.t, execBranch, fileBranch, "Branch")
+
+	execClean, execErr := execRepo.IsClean(ctx)
+	fileClean, fileErr := fileRepo.IsClean(ctx)
+	assert.NoError(r.t, execErr)
+	assert.NoError(r.t, fileErr)
+	assert.Equal(r.t, execClean, fileClean, "IsClean")
+
+	execCount, execErr := execRepo.CommitCount(ctx, rev)
+	fileCount, fileErr := fileRepo.CommitCount(ctx, rev)
+	assert.NoError(r.t, execErr)
+	assert.NoError(r.t, fileErr)
+	assert.Equal(r.t, execCount, fileCount, "CommitCount")
+
+	execDesc, execErr := execRepo.Describe(ctx, rev, opts)
+	fileDesc, fileErr := fileRepo.Describe(ctx, rev, opts)
+	assert.Equal(r.t, execErr, fileErr, "Describe")
+	assert.Equal(r.t, execDesc, fileDesc, "Describe")
+}
+
+func TestRepositories(t *testing.T) {
+	r := newTestRepo(t)
+	defer r.cleanup()
+
+	ctx := context.Background()
+	noOpts := DescribeOptions{}
+
+	t.Run("NoTag", func(t *testing.T) {
+		r.t = t
+		r.write("README.md", "# app\n")
+		r.commit("Initial commit")
+		r.assertSame("HEAD", noOpts)
+
+		_, err := NewExecRepository(r.dir).Describe(ctx, "HEAD", noOpts)
+		assert.Equal(t, ErrNoTag, err)
+
+		// No tag is detected regardless of the language of git messages
+		os.Setenv("LC_ALL", "de_DE.UTF-8")
+		_, err = NewExecRepository(r.dir).Describe(ctx, "HEAD", noOpts)
+		os.Unsetenv("LC_ALL")
+		assert.Equal(t, ErrNoTag, err)
+
+		// An invalid revision is not mistaken for no tag
+		_, err = NewExecRepository(r.dir).Describe(ctx, "invalid", noOpts)
+		assert.Error(t, err)
+		assert.NotEqual(t, ErrNoTag, err)
+	})
+
+	t.Run("AnnotatedTag", func(t *testing.T) {
+		r.t = t
+		r.write("main.go", "package main\n")
+		r.commit("Add main")
+		r.git("tag", "-a", "v0.1.0", "-m", "Version 0.1.0")
+		r.assertSame("HEAD", noOpts)
+
+		desc, err := NewExecRepository(r.dir).Describe(ctx, "HEAD", noOpts)
+		assert.NoError(t, err)
+		assert.Equal(t, "v0.1.0", desc.Tag)
+		assert.Equal(t, 0, desc.Distance)
+	})
+
+	t.Run("Distance", func(t *testing.T) {
+		r.t = t
+		r.write("main.go", "package main\n\nfunc main() {}\n")
+		r.commit("Update main")
+		r.write("docs/guide.md", "guide\n")
+		r.commit("Add guide")
+		r.assertSame("HEAD", noOpts)
+		r.assertSame("v0.1.0", noOpts)
+	})
+
+	t.Run("LightweightTag", func(t *testing.T) {
+		r.t = t
+		r.git("tag", "v0.2.0-rc.1")
+		r.commit("Empty commit")
+		r.assertSame("HEAD", noOpts)
+	})
+
+	t.Run("MergeCommit", func(t *testing.T) {
+		r.t = t
+		r.git("checkout", "-q", "-b", "feature")
+		r.write("feature.go", "package main\n")
+		r.commit("Add feature")
+		r.git("tag", "services/api/v1.0.0")
+		r.git("checkout", "-q", "main")
+		r.write("fix.go", "package main\n")
+		r.commit("Add fix")
+		r.git("merge", "-q", "--no-ff", "-m", "Merge feature", "feature")
+		r.assertSame("HEAD", noOpts)
+		r.assertSame("HEAD", DescribeOptions{Match: []string{"services/api/v[0-9]*"}})
+		r.assertSame("HEAD", DescribeOptions{Exclude: []string{"services/api/*"}})
+		r.assertSame("HEAD", DescribeOptions{Match: []string{"cli-v[0-9]*"}})
+	})
+
+	t.Run("Status", func(t *testing.T) {
+		r.t = t
+		r.write(".gitignore", "*.log\n/bin/\n!keep.log\n")
+		r.commit("Add gitignore")
+		r.assertSame("HEAD", noOpts)
+
+		// Ignored files
+		r.write("debug.log", "debug\n")
+		r.write("bin/app", "binary\n")
+		r.write("empty/.gitkeep.log", "")
+		r.assertSame("HEAD", noOpts)
+
+		// Untracked files
+		r.write("keep.log", "keep\n")
+		r.assertSame("HEAD", noOpts)
+		assert.NoError(t, os.Remove(filepath.Join(r.dir, "keep.log")))
+		r.write("docs/new.md", "new\n")
+		r.assertSame("HEAD", noOpts)
+		assert.NoError(t, os.Remove(filepath.Join(r.dir, "docs", "new.md")))
+		r.assertSame("HEAD", noOpts)
+
+		// Unstaged changes
+		r.write("main.go", "package main\n\nfunc main() { }\n")
+		r.assertSame("HEAD", noOpts)
+		r.git("checkout", "--", "main.go")
+		r.assertSame("HEAD", noOpts)
+		assert.NoError(t, os.Chmod(filepath.Join(r.dir, "main.go"), 0755))
+		r.assertSame("HEAD", noOpts)
+		assert.NoError(t, os.Chmod(filepath.Join(r.dir, "main.go"), 0644))
+		assert.NoError(t, os.Remove(filepath.Join(r.dir, "fix.go")))
+		r.assertSame("HEAD", noOpts)
+		r.git("checkout", "--", "fix.go")
+
+		// Staged changes
+		r.write("staged.go", "package main\n")
+		r.git("add", "staged.go")
+		r.assertSame("HEAD", noOpts)
+		r.git("rm", "-q", "--cached", "staged.go")
+		assert.NoError(t, os.Remove(filepath.Join(r.dir, "staged.go")))
+		r.assertSame("HEAD", noOpts)
+	})
+
+	t.Run("PackedObjects", func(t *testing.T) {
+		r.t = t
+		// Similar contents are stored as deltas in the pack file
+		for i := 0; i < 5; i++ {
+			r.write("main.go", "package main\n\n"+strings.Repeat("// comment\n", 100*(i+1))+"func main() {}\n")
+			r.commit("Update main")
+		}
+		r.git("tag", "-a", "v0.2.0", "-m", "Version 0.2.0")
+		r.commit("Empty commit")
+		r.git("gc", "-q", "--aggressive")
+		r.assertSame("HEAD", noOpts)
+
+		r.write("main.go", "package main\n")
+		r.assertSame("HEAD", noOpts)
+		r.git("checkout", "--", "main.go")
+	})
+
+	t.Run("DetachedHead", func(t *testing.T) {
+		r.t = t
+		r.git("checkout", "-q", "v0.1.0")
+		r.assertSame("HEAD", noOpts)
+		r.git("checkout", "-q", "main")
+	})
+
+	t.Run("Subdirectory", func(t *testing.T) {
+		r.t = t
+		repo, err := NewFileRepository(filepath.Join(r.dir, "docs"))
+		assert.NoError(t, err)
+
+		head, err := repo.Head(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, r.git("rev-parse", "HEAD"), head)
+	})
+
+	t.Run("Worktree", func(t *testing.T) {
+		r.t = t
+		worktree := filepath.Join(r.home, "worktree")
+		r.git("worktree", "add", "-q", "-b", "hotfix", worktree, "v0.2.0")
+
+		repo, err := NewFileRepository(worktree)
+		assert.NoError(t, err)
+
+		branch, err := repo.Branch(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, "hotfix", branch)
+
+		clean, err := repo.IsClean(ctx)
+		assert.NoError(t, err)
+		assert.True(t, clean)
+
+		desc, err := repo.Describe(ctx, "HEAD", noOpts)
+		assert.NoError(t, err)
+		assert.Equal(t, "v0.2.0", desc.Tag)
+	})
+
+	t.Run("IndexVersion4", func(t *testing.T) {
+		r.t = t
+		r.git("update-index", "--index-version", "4")
+		r.assertSame("HEAD", noOpts)
+		r.write("docs/guide.md", "changed\n")
+		r.assertSame("HEAD", noOpts)
+		r.git("checkout", "--", "docs/guide.md")
+	})
+
+	t.Run("ShallowClone", func(t *testing.T) {
+		r.t = t
+		shallow := filepath.Join(r.home, "shallow")
+		r.git("clone", "-q", "--depth", "3", "--no-single-branch", "file://"+r.dir, shallow)
+
+		dir := r.dir
+		defer func() {
+			r.dir = dir
+		}()
+
+		r.dir = shallow
+		r.git("fetch", "-q", "--depth", "3", "origin", "tag", "v0.2.0")
+		r.assertSame("HEAD", noOpts)
+	})
+
+	t.Run("NotRepository", func(t *testing.T) {
+		r.t = t
+		_, err := NewFileRepository(string(filepath.Separator))
+		assert.Error(t, err)
+	})
+}
+
+func TestParseDescribe(t *testing.T) {
+	tests := []struct {
+		name                string
+		out                 string
+		expectedDescription Description
+		expectedError       string
+	}{
+		{
+			name:                "Release",
+			out:                 "v0.2.7-10-gabcdeff",
+			expectedDescription: Description{Tag: "v0.2.7", Distance: 10, Commit: "abcdeff"},
+		},
+		{
+			name:                "Prerelease",
+			out:                 "services/api/v0.3.0-rc.1-0-gabcdeff",
+			expectedDescription: Description{Tag: "services/api/v0.3.0-rc.1", Distance: 0, Commit: "abcdeff"},
+		},
+		{
+			name:          "Invalid",
+			out:           "v0.2.7",
+			expectedError: "unexpected git describe output: v0.2.7",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			desc, err := parseDescribe(tc.out)
+
+			if tc.expectedError != "" {
+				assert.EqualError(t, err, tc.expectedError)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedDescription, desc)
+			}
+		})
+	}
+}
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		name          string
+		pattern       string
+		path          string
+		pathname      bool
+		expectedMatch bool
+	}{
+		{"Star", "v[0-9]*", "v1.2.3", false, true},
+		{"StarSlash", "services/*", "services/api/v1.2.3", false, true},
+		{"StarSlashPathname", "services/*", "services/api/v1.2.3", true, false},
+		{"Class", "v[0-9]*", "version", false, false},
+		{"NegatedClass", "[!a-z]*", "1.log", true, true},
+		{"Question", "v?.0.0", "v1.0.0", false, true},
+		{"LeadingDoubleStar", "**/build", "a/b/build", true, true},
+		{"LeadingDoubleStarRoot", "**/build", "build", true, true},
+		{"TrailingDoubleStar", "docs/**", "docs/a/b.md", true, true},
+		{"MiddleDoubleStar", "a/**/b", "a/x/y/b", true, true},
+		{"MiddleDoubleStarNone", "a/**/b", "a/b", true, true},
+		{"Escape", `\*.md`, "*.md", true, true},
+		{"EscapeNoMatch", `\*.md`, "a.md", true, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedMatch, matchGlob(tc.pattern, tc.path, tc.pathname))
+		})
+	}
+}
@@ -0,0 +1,197 @@
+package git
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// commit is a node in the commit graph of a repository.
+type commit struct {
+	hash    string
+	parents []string
+	time    time.Time
+}
+
+// commitGraph is the commit graph of a repository.
+type commitGraph interface {
+	commit(hash string) (*commit, error)
+}
+
+// ancestors returns the commits reachable from a commit including itself.
+func ancestors(g commitGraph, hash string) (map[string]bool, error) {
+	visited := map[string]bool{}
+	queue := []string{hash}
+
+	for len(queue) > 0 {
+		h := queue[0]
+		queue = queue[1:]
+
+		if visited[h] {
+			continue
+		}
+		visited[h] = true
+
+		c, err := g.commit(h)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, p := range c.parents {
+			if !visited[p] {
+				queue = append(queue, p)
+			}
+		}
+	}
+
+	return visited, nil
+}
+
+// describe returns the tag with the fewest commits since the tagged commit amongst the tags reachable from a commit.
+// tags are a map of tag names to the commits they point to.
+// Between the tags with the same distance, the tag on the most recent commit and then the greatest name is chosen.
+func describe(g commitGraph, hash string, tags map[string]string, opts DescribeOptions) (Description, error) {
+	names := make([]string, 0, len(tags))
+	for name := range tags {
+		if matchTag(name, opts) {
+			names = append(names, name)
+		}
+	}
+
+	// Sorting makes the choice between tags on the same commit deterministic
+	sort.Sort(sort.Reverse(sort.StringSlice(names)))
+
+	if len(names) == 0 {
+		return Description{}, ErrNoTag
+	}
+
+	reachable, err := ancestors(g, hash)
+	if err != nil {
+		return Description{}, err
+	}
+
+	var best Description
+	var bestTime time.Time
+	found := false
+
+	for _, name := range names {
+		tagged := tags[name]
+		if !reachable[tagged] {
+			continue
+		}
+
+		// All commits reachable from the tagged commit are also reachable from the described commit
+		tagReachable, err := ancestors(g, tagged)
+		if err != nil {
+			return Description{}, err
+		}
+		distance := len(reachable) - len(tagReachable)
+
+		c, err := g.commit(tagged)
+		if err != nil {
+			return Description{}, err
+		}
+
+		if !found || distance < best.Distance || (distance == best.Distance && c.time.After(bestTime)) {
+			best = Description{Tag: name, Distance: distance, Commit: hash}
+			bestTime = c.time
+			found = true
+		}
+	}
+
+	if !found {
+		return Description{}, ErrNoTag
+	}
+
+	return best, nil
+}
+
+// matchTag determines whether or not a tag is considered by the describe options.
+func matchTag(name string, opts DescribeOptions) bool {
+	for _, pattern := range opts.Exclude {
+		if matchGlob(pattern, name, false) {
+			return false
+		}
+	}
+
+	if len(opts.Match) == 0 {
+		return true
+	}
+
+	for _, pattern := range opts.Match {
+		if matchGlob(pattern, name, false) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchGlob determines whether or not a name matches a glob pattern as git does.
+// If pathname is true, wildcards do not match / and ** matches any number of directories.
+func matchGlob(pattern, name string, pathname bool) bool {
+	re, err := regexp.Compile("^" + globToRegexp(pattern, pathname) + "$")
+	if err != nil {
+		return false
+	}
+
+	return re.MatchString(name)
+}
+
+// globToRegexp converts a glob pattern to a regular expression.
+func globToRegexp(pattern string, pathname bool) string {
+	var b strings.Builder
+
+	any, one := ".*", "."
+	if pathname {
+		any, one = "[^/]*", "[^/]"
+	}
+
+	for i := 0; i < len(pattern); i++ {
+		ch := pattern[i]
+		switch {
+		case pathname && strings.HasPrefix(pattern[i:], "**/") && (i == 0 || pattern[i-1] == '/'):
+			// Example: **/foo or a/**/b
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case pathname && pattern[i:] == "**" && (i == 0 || pattern[i-1] == '/'):
+			// Example: a/**
+			b.WriteString(".*")
+			i++
+		case ch == '*':
+			b.WriteString(any)
+		case ch == '?':
+			b.WriteString(one)
+		case ch == '[':
+			j := i + 1
+			if j < len(pattern) && (pattern[j] == '!' || pattern[j] == '^') {
+				j++
+			}
+			if j < len(pattern) && pattern[j] == ']' {
+				j++
+			}
+			for j < len(pattern) && pattern[j] != ']' {
+				j++
+			}
+			if j >= len(pattern) {
+				b.WriteString(`\[`)
+				continue
+			}
+
+			class := pattern[i+1 : j]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + strings.Replace(class, `\`, `\\`, -1) + "]")
+			i = j
+		case ch == '\\' && i+1 < len(pattern):
+			i++
+			b.WriteString(regexp.QuoteMeta(string(pattern[i])))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(ch)))
+		}
+	}
+
+	return b.String()
+}
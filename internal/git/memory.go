@@ -0,0 +1,152 @@
+package git
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// MemoryRepository is an in-memory Repository for testing.
+// The zero value is an empty repository with a detached HEAD.
+type MemoryRepository struct {
+	commits map[string]*commit
+	tags    map[string]string
+	head    string
+	branch  string
+	dirty   bool
+}
+
+// NewMemoryRepository creates an empty in-memory repository on a branch.
+func NewMemoryRepository(branch string) *MemoryRepository {
+	return &MemoryRepository{
+		branch: branch,
+	}
+}
+
+// Commit adds a commit on top of HEAD and moves HEAD to it.
+// It returns the hash of the new commit.
+func (r *MemoryRepository) Commit(message string) string {
+	var parents []string
+	if r.head != "" {
+		parents = []string{r.head}
+	}
+
+	return r.Merge(message, parents...)
+}
+
+// Merge adds a commit with the given parents and moves HEAD to it.
+// It returns the hash of the new commit.
+func (r *MemoryRepository) Merge(message string, parents ...string) string {
+	if r.commits == nil {
+		r.commits = map[string]*commit{}
+	}
+
+	// Commits are one second apart, so their order is deterministic
+	n := len(r.commits)
+	sum := sha1.Sum([]byte(strconv.Itoa(n) + "\x00" + message))
+	hash := hex.EncodeToString(sum[:])
+
+	r.commits[hash] = &commit{
+		hash:    hash,
+		parents: parents,
+		time:    time.Unix(int64(n), 0),
+	}
+	r.head = hash
+
+	return hash
+}
+
+// Tag adds a tag pointing to the HEAD commit.
+func (r *MemoryRepository) Tag(name string) {
+	if r.tags == nil {
+		r.tags = map[string]string{}
+	}
+
+	r.tags[name] = r.head
+}
+
+// Checkout moves HEAD to a commit on a branch.
+func (r *MemoryRepository) Checkout(branch, hash string) {
+	r.branch = branch
+	r.head = hash
+}
+
+// SetDirty sets whether or not the working tree has changes.
+func (r *MemoryRepository) SetDirty(dirty bool) {
+	r.dirty = dirty
+}
+
+func (r *MemoryRepository) commit(hash string) (*commit, error) {
+	c, ok := r.commits[hash]
+	if !ok {
+		return nil, fmt.Errorf("commit %s not found", hash)
+	}
+
+	return c, nil
+}
+
+func (r *MemoryRepository) resolve(rev string) (string, error) {
+	if rev == "HEAD" {
+		if r.head == "" {
+			return "", fmt.Errorf("revision %s not found", rev)
+		}
+		return r.head, nil
+	}
+
+	if hash, ok := r.tags[rev]; ok {
+		return hash, nil
+	}
+
+	if _, ok := r.commits[rev]; ok {
+		return rev, nil
+	}
+
+	return "", fmt.Errorf("revision %s not found", rev)
+}
+
+// Head returns the full hash of the HEAD commit.
+func (r *MemoryRepository) Head(ctx context.Context) (string, error) {
+	return r.resolve("HEAD")
+}
+
+// Branch returns the name of the current branch or HEAD if detached.
+func (r *MemoryRepository) Branch(ctx context.Context) (string, error) {
+	if r.branch == "" {
+		return "HEAD", nil
+	}
+
+	return r.branch, nil
+}
+
+// IsClean determines whether or not the working tree has no staged, unstaged, or untracked changes.
+func (r *MemoryRepository) IsClean(ctx context.Context) (bool, error) {
+	return !r.dirty, nil
+}
+
+// CommitCount returns the number of commits reachable from a revision.
+func (r *MemoryRepository) CommitCount(ctx context.Context, rev string) (int, error) {
+	hash, err := r.resolve(rev)
+	if err != nil {
+		return 0, err
+	}
+
+	commits, err := ancestors(r, hash)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(commits), nil
+}
+
+// Describe returns the most recent tag reachable from a revision.
+func (r *MemoryRepository) Describe(ctx context.Context, rev string, opts DescribeOptions) (Description, error) {
+	hash, err := r.resolve(rev)
+	if err != nil {
+		return Description{}, err
+	}
+
+	return describe(r, hash, r.tags, opts)
+}
@@ -0,0 +1,69 @@
+package git
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryRepository(t *testing.T) {
+	ctx := context.Background()
+	r := NewMemoryRepository("main")
+
+	_, err := r.Head(ctx)
+	assert.Error(t, err)
+
+	first := r.Commit("Initial commit")
+	r.Tag("v0.1.0")
+	r.Commit("Add feature")
+	r.Tag("services/api/v1.0.0")
+	r.Commit("Fix bug")
+
+	head, err := r.Head(ctx)
+	assert.NoError(t, err)
+
+	branch, err := r.Branch(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "main", branch)
+
+	clean, err := r.IsClean(ctx)
+	assert.NoError(t, err)
+	assert.True(t, clean)
+
+	count, err := r.CommitCount(ctx, "HEAD")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	desc, err := r.Describe(ctx, "HEAD", DescribeOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, Description{Tag: "services/api/v1.0.0", Distance: 1, Commit: head}, desc)
+
+	desc, err = r.Describe(ctx, "HEAD", DescribeOptions{Exclude: []string{"services/api/*"}})
+	assert.NoError(t, err)
+	assert.Equal(t, Description{Tag: "v0.1.0", Distance: 2, Commit: head}, desc)
+
+	_, err = r.Describe(ctx, "HEAD", DescribeOptions{Match: []string{"cli-v[0-9]*"}})
+	assert.Equal(t, ErrNoTag, err)
+
+	// A branch merged back has both lines of history
+	r.Checkout("release/0.1", first)
+	hotfix := r.Commit("Hotfix")
+	r.Tag("v0.1.1")
+	r.Checkout("main", head)
+	r.Merge("Merge release/0.1", head, hotfix)
+	r.SetDirty(true)
+
+	count, err = r.CommitCount(ctx, "HEAD")
+	assert.NoError(t, err)
+	assert.Equal(t, 5, count)
+
+	desc, err = r.Describe(ctx, "HEAD", DescribeOptions{Match: []string{"v[0-9]*"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "v0.1.1", desc.Tag)
+	assert.Equal(t, 3, desc.Distance)
+
+	clean, err = r.IsClean(ctx)
+	assert.NoError(t, err)
+	assert.False(t, clean)
+}
@@ -0,0 +1,430 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// The types of git objects.
+const (
+	objCommit   = 1
+	objTree     = 2
+	objBlob     = 3
+	objTag      = 4
+	objOfsDelta = 6
+	objRefDelta = 7
+)
+
+var errObjectNotFound = errors.New("object not found")
+
+// objectStore reads loose and packed git objects.
+type objectStore struct {
+	dirs []string
+
+	once  sync.Once
+	packs []*packFile
+	err   error
+}
+
+// newObjectStore creates an object store for an objects directory and its alternates.
+func newObjectStore(objectsDir string) *objectStore {
+	dirs := []string{objectsDir}
+
+	// Example: objects/info/alternates --> /path/to/other/.git/objects
+	if data, err := ioutil.ReadFile(filepath.Join(objectsDir, "info", "alternates")); err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			if !filepath.IsAbs(line) {
+				line = filepath.Join(objectsDir, line)
+			}
+			dirs = append(dirs, line)
+		}
+	}
+
+	return &objectStore{
+		dirs: dirs,
+	}
+}
+
+// read returns the type and content of an object.
+func (s *objectStore) read(hash string) (int, []byte, error) {
+	if len(hash) != 40 {
+		return 0, nil, fmt.Errorf("invalid object hash: %s", hash)
+	}
+
+	for _, dir := range s.dirs {
+		typ, data, err := readLooseObject(filepath.Join(dir, hash[:2], hash[2:]))
+		if err == nil {
+			return typ, data, nil
+		}
+		if !os.IsNotExist(err) {
+			return 0, nil, fmt.Errorf("error on reading object %s: %s", hash, err)
+		}
+	}
+
+	s.once.Do(func() {
+		s.packs, s.err = s.openPacks()
+	})
+	if s.err != nil {
+		return 0, nil, s.err
+	}
+
+	id, err := hex.DecodeString(hash)
+	if err != nil {
+		return 0, nil, fmt.Errorf("invalid object hash: %s", hash)
+	}
+
+	for _, p := range s.packs {
+		if offset, ok := p.find(id); ok {
+			typ, data, err := p.read(s, offset)
+			if err != nil {
+				return 0, nil, fmt.Errorf("error on reading object %s: %s", hash, err)
+			}
+			return typ, data, nil
+		}
+	}
+
+	return 0, nil, fmt.Errorf("%w: %s", errObjectNotFound, hash)
+}
+
+func (s *objectStore) openPacks() ([]*packFile, error) {
+	var packs []*packFile
+
+	for _, dir := range s.dirs {
+		paths, err := filepath.Glob(filepath.Join(dir, "pack", "*.idx"))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, path := range paths {
+			p, err := openPackFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("error on reading pack %s: %s", filepath.Base(path), err)
+			}
+			packs = append(packs, p)
+		}
+	}
+
+	return packs, nil
+}
+
+// readLooseObject reads a zlib-compressed object with a "<type> <size>\x00" header.
+func readLooseObject(path string) (int, []byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer f.Close()
+
+	zr, err := zlib.NewReader(f)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer zr.Close()
+
+	data, err := ioutil.ReadAll(zr)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	i := bytes.IndexByte(data, 0)
+	if i == -1 {
+		return 0, nil, errors.New("invalid object header")
+	}
+
+	header := strings.SplitN(string(data[:i]), " ", 2)
+	if len(header) != 2 {
+		return 0, nil, errors.New("invalid object header")
+	}
+
+	var typ int
+	switch header[0] {
+	case "commit":
+		typ = objCommit
+	case "tree":
+		typ = objTree
+	case "blob":
+		typ = objBlob
+	case "tag":
+		typ = objTag
+	default:
+		return 0, nil, fmt.Errorf("invalid object type: %s", header[0])
+	}
+
+	size, err := strconv.Atoi(header[1])
+	if err != nil || size != len(data)-i-1 {
+		return 0, nil, errors.New("invalid object size")
+	}
+
+	return typ, data[i+1:], nil
+}
+
+// packFile is a pack file with its version 2 index.
+type packFile struct {
+	path    string
+	fanout  [256]uint32
+	hashes  []byte
+	offsets []byte
+	large   []byte
+}
+
+func openPackFile(idxPath string) (*packFile, error) {
+	data, err := ioutil.ReadFile(idxPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Example: \377tOc + version 2
+	if len(data) < 8+256*4 || !bytes.Equal(data[:4], []byte{0xff, 't', 'O', 'c'}) || binary.BigEndian.Uint32(data[4:8]) != 2 {
+		return nil, errors.New("unsupported pack index version")
+	}
+
+	p := &packFile{
+		path: strings.TrimSuffix(idxPath, ".idx") + ".pack",
+	}
+
+	for i := range p.fanout {
+		p.fanout[i] = binary.BigEndian.Uint32(data[8+i*4:])
+	}
+
+	n := int(p.fanout[255])
+	pos := 8 + 256*4
+	if len(data) < pos+n*(20+4+4) {
+		return nil, errors.New("truncated pack index")
+	}
+
+	p.hashes = data[pos : pos+n*20]
+	pos += n * 20
+	pos += n * 4 // CRC32 checksums
+	p.offsets = data[pos : pos+n*4]
+	pos += n * 4
+	p.large = data[pos:]
+
+	return p, nil
+}
+
+// find returns the offset of an object in the pack file.
+func (p *packFile) find(id []byte) (int64, bool) {
+	lo := 0
+	if id[0] > 0 {
+		lo = int(p.fanout[id[0]-1])
+	}
+	hi := int(p.fanout[id[0]])
+
+	i := lo + sort.Search(hi-lo, func(i int) bool {
+		return bytes.Compare(p.hashes[(lo+i)*20:(lo+i+1)*20], id) >= 0
+	})
+
+	if i >= hi || !bytes.Equal(p.hashes[i*20:(i+1)*20], id) {
+		return 0, false
+	}
+
+	offset := binary.BigEndian.Uint32(p.offsets[i*4:])
+	if offset&0x80000000 != 0 {
+		// The offset is in the table of 8-byte offsets for pack files larger than 2 GB
+		j := int(offset&0x7fffffff) * 8
+		if j+8 > len(p.large) {
+			return 0, false
+		}
+		return int64(binary.BigEndian.Uint64(p.large[j:])), true
+	}
+
+	return int64(offset), true
+}
+
+// read returns the type and content of the object at an offset, resolving deltas.
+func (p *packFile) read(s *objectStore, offset int64) (int, []byte, error) {
+	f, err := os.Open(p.path)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer f.Close()
+
+	return p.readAt(s, f, offset)
+}
+
+func (p *packFile) readAt(s *objectStore, f *os.File, offset int64) (int, []byte, error) {
+	r := bufio.NewReader(io.NewSectionReader(f, offset, 1<<62))
+
+	// The header is the type and size of the object in a variable-length encoding
+	b, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	typ := int(b>>4) & 7
+	size := int64(b & 0x0f)
+	for shift := uint(4); b&0x80 != 0; shift += 7 {
+		if b, err = r.ReadByte(); err != nil {
+			return 0, nil, err
+		}
+		size |= int64(b&0x7f) << shift
+	}
+
+	switch typ {
+	case objCommit, objTree, objBlob, objTag:
+		data, err := inflate(r, size)
+		return typ, data, err
+
+	case objOfsDelta:
+		// The base object is at a negative offset relative to this object
+		if b, err = r.ReadByte(); err != nil {
+			return 0, nil, err
+		}
+		rel := int64(b & 0x7f)
+		for b&0x80 != 0 {
+			if b, err = r.ReadByte(); err != nil {
+				return 0, nil, err
+			}
+			rel = ((rel + 1) << 7) | int64(b&0x7f)
+		}
+
+		delta, err := inflate(r, size)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		baseType, base, err := p.readAt(s, f, offset-rel)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		data, err := applyDelta(base, delta)
+		return baseType, data, err
+
+	case objRefDelta:
+		// The base object is referenced by its hash
+		id := make([]byte, 20)
+		if _, err := io.ReadFull(r, id); err != nil {
+			return 0, nil, err
+		}
+
+		delta, err := inflate(r, size)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		baseType, base, err := s.read(hex.EncodeToString(id))
+		if err != nil {
+			return 0, nil, err
+		}
+
+		data, err := applyDelta(base, delta)
+		return baseType, data, err
+
+	default:
+		return 0, nil, fmt.Errorf("invalid packed object type: %d", typ)
+	}
+}
+
+func inflate(r io.Reader, size int64) ([]byte, error) {
+	zr, err := zlib.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	data := make([]byte, size)
+	if _, err := io.ReadFull(zr, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// applyDelta reconstructs an object from its base object and a delta of copy and insert instructions.
+func applyDelta(base, delta []byte) ([]byte, error) {
+	errInvalid := errors.New("invalid delta")
+
+	readSize := func() (int, bool) {
+		var size int
+		for shift := uint(0); len(delta) > 0; shift += 7 {
+			b := delta[0]
+			delta = delta[1:]
+			size |= int(b&0x7f) << shift
+			if b&0x80 == 0 {
+				return size, true
+			}
+		}
+		return 0, false
+	}
+
+	baseSize, ok := readSize()
+	if !ok || baseSize != len(base) {
+		return nil, errInvalid
+	}
+
+	size, ok := readSize()
+	if !ok {
+		return nil, errInvalid
+	}
+
+	out := make([]byte, 0, size)
+
+	for len(delta) > 0 {
+		op := delta[0]
+		delta = delta[1:]
+
+		if op&0x80 != 0 {
+			// Copy from the base object
+			var offset, n int
+			for i := uint(0); i < 4; i++ {
+				if op&(1<<i) != 0 {
+					if len(delta) == 0 {
+						return nil, errInvalid
+					}
+					offset |= int(delta[0]) << (8 * i)
+					delta = delta[1:]
+				}
+			}
+			for i := uint(0); i < 3; i++ {
+				if op&(0x10<<i) != 0 {
+					if len(delta) == 0 {
+						return nil, errInvalid
+					}
+					n |= int(delta[0]) << (8 * i)
+					delta = delta[1:]
+				}
+			}
+			if n == 0 {
+				n = 0x10000
+			}
+			if offset+n > len(base) {
+				return nil, errInvalid
+			}
+			out = append(out, base[offset:offset+n]...)
+		} else if op != 0 {
+			// Insert from the delta
+			n := int(op)
+			if n > len(delta) {
+				return nil, errInvalid
+			}
+			out = append(out, delta[:n]...)
+			delta = delta[n:]
+		} else {
+			return nil, errInvalid
+		}
+	}
+
+	if len(out) != size {
+		return nil, errInvalid
+	}
+
+	return out, nil
+}
@@ -0,0 +1,461 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// The modes of index entries and tree entries.
+const (
+	modeRegular    = 0100644
+	modeExecutable = 0100755
+	modeSymlink    = 0120000
+	modeGitlink    = 0160000
+	modeTree       = 040000
+)
+
+// indexEntry is a file in the index (staging area).
+type indexEntry struct {
+	path  string
+	hash  string
+	mode  uint32
+	size  uint32
+	mtime int64 // nanoseconds
+	stage int
+	added bool // intent-to-add (git add -N)
+	skip  bool // skip-worktree (sparse checkout)
+}
+
+// readIndex reads the entries of a version 2, 3, or 4 index file.
+func readIndex(filename string) ([]indexEntry, int64, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	info, err := os.Stat(filename)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	errInvalid := errors.New("invalid index file")
+
+	if len(data) < 12 || string(data[:4]) != "DIRC" {
+		return nil, 0, errInvalid
+	}
+
+	version := binary.BigEndian.Uint32(data[4:8])
+	if version < 2 || version > 4 {
+		return nil, 0, fmt.Errorf("unsupported index version: %d", version)
+	}
+
+	count := int(binary.BigEndian.Uint32(data[8:12]))
+	entries := make([]indexEntry, 0, count)
+	pos := 12
+	prev := ""
+
+	for i := 0; i < count; i++ {
+		start := pos
+		if pos+62 > len(data) {
+			return nil, 0, errInvalid
+		}
+
+		e := indexEntry{
+			mtime: int64(binary.BigEndian.Uint32(data[pos+8:]))*1e9 + int64(binary.BigEndian.Uint32(data[pos+12:])),
+			mode:  binary.BigEndian.Uint32(data[pos+24:]),
+			size:  binary.BigEndian.Uint32(data[pos+36:]),
+			hash:  hex.EncodeToString(data[pos+40 : pos+60]),
+		}
+
+		flags := binary.BigEndian.Uint16(data[pos+60:])
+		e.stage = int(flags>>12) & 3
+		pos += 62
+
+		// Extended flags are only in version 3 and higher
+		if flags&0x4000 != 0 {
+			if version < 3 || pos+2 > len(data) {
+				return nil, 0, errInvalid
+			}
+			extended := binary.BigEndian.Uint16(data[pos:])
+			e.added = extended&0x2000 != 0
+			e.skip = extended&0x4000 != 0
+			pos += 2
+		}
+
+		if version == 4 {
+			// The path is prefix-compressed against the previous entry
+			strip, n := binary.Uvarint(data[pos:])
+			if n <= 0 || int(strip) > len(prev) {
+				return nil, 0, errInvalid
+			}
+			pos += n
+
+			end := bytes.IndexByte(data[pos:], 0)
+			if end == -1 {
+				return nil, 0, errInvalid
+			}
+
+			e.path = prev[:len(prev)-int(strip)] + string(data[pos:pos+end])
+			pos += end + 1
+		} else {
+			end := bytes.IndexByte(data[pos:], 0)
+			if end == -1 {
+				return nil, 0, errInvalid
+			}
+
+			e.path = string(data[pos : pos+end])
+
+			// Entries are padded with 1 to 8 null bytes to a multiple of 8 bytes
+			pos += end + 1
+			for (pos-start)%8 != 0 {
+				pos++
+			}
+		}
+
+		prev = e.path
+		entries = append(entries, e)
+	}
+
+	return entries, info.ModTime().UnixNano(), nil
+}
+
+// tree returns the files in a tree object recursively as a map of paths to their hashes and modes.
+func (r *fileRepository) tree(hash, prefix string, files map[string]indexEntry) error {
+	typ, data, err := r.objects.read(hash)
+	if err != nil {
+		return err
+	}
+
+	if typ != objTree {
+		return fmt.Errorf("object %s is not a tree", hash)
+	}
+
+	// Example: <mode> <name>\x00<20-byte hash>
+	for len(data) > 0 {
+		sp := bytes.IndexByte(data, ' ')
+		nul := bytes.IndexByte(data, 0)
+		if sp == -1 || nul == -1 || sp > nul || nul+21 > len(data) {
+			return fmt.Errorf("invalid tree object: %s", hash)
+		}
+
+		mode, err := strconv.ParseUint(string(data[:sp]), 8, 32)
+		if err != nil {
+			return fmt.Errorf("invalid tree object: %s", hash)
+		}
+
+		name := prefix + string(data[sp+1:nul])
+		entryHash := hex.EncodeToString(data[nul+1 : nul+21])
+		data = data[nul+21:]
+
+		if mode == modeTree {
+			if err := r.tree(entryHash, name+"/", files); err != nil {
+				return err
+			}
+		} else {
+			files[name] = indexEntry{path: name, hash: entryHash, mode: uint32(mode)}
+		}
+	}
+
+	return nil
+}
+
+// IsClean determines whether or not the working tree has no staged, unstaged, or untracked changes.
+func (r *fileRepository) IsClean(ctx context.Context) (bool, error) {
+	entries, indexTime, err := readIndex(filepath.Join(r.gitDir, "index"))
+	if err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+
+	// Compare the index with the HEAD commit for staged changes
+	committed := map[string]indexEntry{}
+	if head, err := r.resolve("HEAD"); err == nil {
+		typ, data, err := r.objects.read(head)
+		if err != nil {
+			return false, err
+		}
+		if typ != objCommit || !bytes.HasPrefix(data, []byte("tree ")) || len(data) < 45 {
+			return false, fmt.Errorf("invalid commit object: %s", head)
+		}
+		if err := r.tree(string(data[5:45]), "", committed); err != nil {
+			return false, err
+		}
+	}
+
+	if len(entries) != len(committed) {
+		return false, nil
+	}
+
+	tracked := map[string]bool{}
+	for _, e := range entries {
+		c, ok := committed[e.path]
+		if !ok || e.stage != 0 || e.added || c.hash != e.hash || c.mode != e.mode {
+			return false, nil
+		}
+		tracked[e.path] = true
+	}
+
+	// Compare the working tree with the index for unstaged changes
+	for _, e := range entries {
+		changed, err := r.changed(e, indexTime)
+		if err != nil {
+			return false, err
+		}
+		if changed {
+			return false, nil
+		}
+	}
+
+	// Look for the files not tracked and not ignored
+	untracked, err := r.untracked(tracked)
+	if err != nil {
+		return false, err
+	}
+
+	return !untracked, nil
+}
+
+// changed determines whether or not a tracked file is changed in the working tree.
+func (r *fileRepository) changed(e indexEntry, indexTime int64) (bool, error) {
+	// Submodules and the files outside of a sparse checkout are not checked
+	if e.mode == modeGitlink || e.skip {
+		return false, nil
+	}
+
+	filename := filepath.Join(r.workDir, filepath.FromSlash(e.path))
+	info, err := os.Lstat(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return true, nil
+		}
+		return false, err
+	}
+
+	var content []byte
+
+	switch {
+	case e.mode == modeSymlink:
+		if info.Mode()&os.ModeSymlink == 0 {
+			return true, nil
+		}
+		target, err := os.Readlink(filename)
+		if err != nil {
+			return false, err
+		}
+		content = []byte(filepath.ToSlash(target))
+
+	case info.Mode().IsRegular():
+		executable := info.Mode()&0111 != 0
+		if executable != (e.mode == modeExecutable) {
+			return true, nil
+		}
+		// The size of an entry modified at the same time as the index is reset to zero (racily clean)
+		if e.size != 0 && uint32(info.Size()) != e.size {
+			return true, nil
+		}
+
+		// A file modified before the index was written and not since then is not changed
+		// A file modified at the same time as the index could be changed after being staged (racy git)
+		if mtime := info.ModTime().UnixNano(); mtime == e.mtime && mtime < indexTime {
+			return false, nil
+		}
+
+		if content, err = ioutil.ReadFile(filename); err != nil {
+			return false, err
+		}
+
+	default:
+		return true, nil
+	}
+
+	return blobHash(content) != e.hash, nil
+}
+
+// blobHash returns the hash of the blob object for a content.
+func blobHash(content []byte) string {
+	h := sha1.New()
+	fmt.Fprintf(h, "blob %d\x00", len(content))
+	h.Write(content)
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// untracked determines whether or not there is a file in the working tree not tracked and not ignored.
+func (r *fileRepository) untracked(tracked map[string]bool) (bool, error) {
+	ig := &ignorer{}
+	ig.load(filepath.Join(r.commonDir, "info", "exclude"), "")
+
+	errFound := errors.New("untracked file found")
+
+	var walk func(dir, rel string) error
+	walk = func(dir, rel string) error {
+		ig.load(filepath.Join(dir, ".gitignore"), rel)
+		defer ig.unload(rel)
+
+		infos, err := ioutil.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+
+		for _, info := range infos {
+			name := info.Name()
+			p := path.Join(rel, name)
+
+			if rel == "" && name == ".git" {
+				continue
+			}
+
+			if info.IsDir() {
+				if tracked[p] || ig.ignored(p, true) {
+					continue
+				}
+
+				// A nested repository not tracked as a submodule is an untracked directory
+				if _, err := os.Stat(filepath.Join(dir, name, ".git")); err == nil {
+					return errFound
+				}
+
+				// An untracked directory is only reported if it has a file not ignored
+				if err := walk(filepath.Join(dir, name), p); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if !tracked[p] && !ig.ignored(p, false) {
+				return errFound
+			}
+		}
+
+		return nil
+	}
+
+	err := walk(r.workDir, "")
+	if err == errFound {
+		return true, nil
+	}
+
+	return false, err
+}
+
+// ignorePattern is a pattern in a .gitignore file.
+type ignorePattern struct {
+	base    string // the directory of the .gitignore file relative to the working tree
+	pattern string
+	negate  bool
+	dirOnly bool
+	rooted  bool // the pattern is matched against the path relative to base rather than the name
+}
+
+// ignorer matches paths against the patterns of the .gitignore files from the top-level directory to a directory.
+type ignorer struct {
+	patterns []ignorePattern
+}
+
+// load adds the patterns of an ignore file in a directory.
+func (ig *ignorer) load(filename, base string) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	data, err := ioutil.ReadAll(io.LimitReader(f, 1<<20))
+	if err != nil {
+		return
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.HasSuffix(line, `\ `) {
+			line = strings.TrimRight(line[:len(line)-2], " ") + `\ `
+		} else {
+			line = strings.TrimRight(line, " ")
+		}
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		p := ignorePattern{base: base}
+
+		if strings.HasPrefix(line, "!") {
+			p.negate = true
+			line = line[1:]
+		} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+			line = line[1:]
+		}
+
+		if strings.HasSuffix(line, "/") {
+			p.dirOnly = true
+			line = strings.TrimSuffix(line, "/")
+		}
+
+		if strings.Contains(line, "/") {
+			p.rooted = true
+			line = strings.TrimPrefix(line, "/")
+		}
+
+		if line == "" {
+			continue
+		}
+
+		p.pattern = line
+		ig.patterns = append(ig.patterns, p)
+	}
+}
+
+// unload removes the patterns of the ignore file in a directory.
+func (ig *ignorer) unload(base string) {
+	i := len(ig.patterns)
+	for i > 0 && ig.patterns[i-1].base == base {
+		i--
+	}
+
+	// The patterns of info/exclude have the same base as the top-level .gitignore and are kept
+	if base == "" {
+		return
+	}
+
+	ig.patterns = ig.patterns[:i]
+}
+
+// ignored determines whether or not a path relative to the working tree is ignored.
+// The last pattern matching the path decides.
+func (ig *ignorer) ignored(p string, isDir bool) bool {
+	for i := len(ig.patterns) - 1; i >= 0; i-- {
+		pat := ig.patterns[i]
+		if pat.dirOnly && !isDir {
+			continue
+		}
+
+		rel := p
+		if pat.base != "" {
+			if !strings.HasPrefix(p, pat.base+"/") {
+				continue
+			}
+			rel = strings.TrimPrefix(p, pat.base+"/")
+		}
+
+		if !pat.rooted {
+			rel = path.Base(rel)
+		}
+
+		if matchGlob(pat.pattern, rel, true) {
+			return !pat.negate
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,73 @@
+// Package httputil provides helpers for calling HTTP APIs.
+package httputil
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+)
+
+// Example: <https://api.github.com/repositories/1/issues?page=2>; rel="next" --> subs = []string{..., "https://api.github.com/repositories/1/issues?page=2"}
+var nextLinkRE = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+// NextLink returns the URL of the next page from a Link header or empty if there is no next page.
+// See https://tools.ietf.org/html/rfc8288
+func NextLink(h http.Header) string {
+	if subs := nextLinkRE.FindStringSubmatch(h.Get("Link")); len(subs) == 2 {
+		return subs[1]
+	}
+
+	return ""
+}
+
+// ListPages requests a paginated list with the given headers and calls the handle function for the JSON body of each page.
+// The pages are followed using the Link header until there is no next page or the handle function returns false.
+// See https://docs.github.com/en/rest/guides/traversing-with-pagination
+func ListPages(ctx context.Context, client *http.Client, url string, header http.Header, handle func([]byte) (bool, error)) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	for next := url; next != ""; {
+		req, err := http.NewRequest("GET", next, nil)
+		if err != nil {
+			return err
+		}
+
+		req = req.WithContext(ctx)
+		for key, values := range header {
+			req.Header[key] = values
+		}
+
+		res, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+
+		var page json.RawMessage
+		err = json.NewDecoder(res.Body).Decode(&page)
+		res.Body.Close()
+
+		if res.StatusCode != 200 {
+			return fmt.Errorf("GET %s: invalid status code %d", next, res.StatusCode)
+		}
+
+		if err != nil {
+			return fmt.Errorf("GET %s: %s", next, err)
+		}
+
+		more, err := handle(page)
+		if err != nil {
+			return fmt.Errorf("GET %s: %s", next, err)
+		}
+
+		next = ""
+		if more {
+			next = NextLink(res.Header)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,101 @@
+package httputil
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNextLink(t *testing.T) {
+	tests := []struct {
+		name         string
+		link         string
+		expectedNext string
+	}{
+		{
+			name:         "NoLink",
+			link:         "",
+			expectedNext: "",
+		},
+		{
+			name:         "NextAndLast",
+			link:         `<https://api.github.com/repositories/1/issues?page=2>; rel="next", <https://api.github.com/repositories/1/issues?page=5>; rel="last"`,
+			expectedNext: "https://api.github.com/repositories/1/issues?page=2",
+		},
+		{
+			name:         "LastPage",
+			link:         `<https://api.github.com/repositories/1/issues?page=1>; rel="first", <https://api.github.com/repositories/1/issues?page=4>; rel="prev"`,
+			expectedNext: "",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h := http.Header{}
+			h.Set("Link", tc.link)
+
+			assert.Equal(t, tc.expectedNext, NextLink(h))
+		})
+	}
+}
+
+func TestListPages(t *testing.T) {
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "token secret", r.Header.Get("Authorization"))
+
+		switch r.URL.Query().Get("page") {
+		case "":
+			w.Header().Set("Link", fmt.Sprintf(`<%s/items?page=2>; rel="next"`, ts.URL))
+			fmt.Fprint(w, `[1, 2]`)
+		case "2":
+			w.Header().Set("Link", fmt.Sprintf(`<%s/items?page=3>; rel="next"`, ts.URL))
+			fmt.Fprint(w, `[3]`)
+		case "3":
+			fmt.Fprint(w, `[4]`)
+		default:
+			w.WriteHeader(404)
+		}
+	}))
+	defer ts.Close()
+
+	header := http.Header{}
+	header.Set("Authorization", "token secret")
+
+	list := func(stopAfter int) ([]int, error) {
+		var items []int
+		err := ListPages(context.Background(), ts.Client(), ts.URL+"/items", header, func(data []byte) (bool, error) {
+			var page []int
+			if err := json.Unmarshal(data, &page); err != nil {
+				return false, err
+			}
+			items = append(items, page...)
+			return len(items) < stopAfter, nil
+		})
+		return items, err
+	}
+
+	t.Run("AllPages", func(t *testing.T) {
+		items, err := list(100)
+		assert.NoError(t, err)
+		assert.Equal(t, []int{1, 2, 3, 4}, items)
+	})
+
+	t.Run("Stop", func(t *testing.T) {
+		items, err := list(2)
+		assert.NoError(t, err)
+		assert.Equal(t, []int{1, 2}, items)
+	})
+
+	t.Run("InvalidStatusCode", func(t *testing.T) {
+		err := ListPages(context.Background(), ts.Client(), ts.URL+"/items?page=9", header, func([]byte) (bool, error) {
+			return true, nil
+		})
+		assert.Contains(t, err.Error(), "invalid status code 404")
+	})
+}
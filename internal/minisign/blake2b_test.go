@@ -0,0 +1,76 @@
+package minisign
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBLAKE2b(t *testing.T) {
+	oneBlock := make([]byte, 129)
+	for i := range oneBlock {
+		oneBlock[i] = byte(i)
+	}
+
+	manyBlocks := make([]byte, 1000)
+	for i := range manyBlocks {
+		manyBlocks[i] = byte(i % 251)
+	}
+
+	tests := []struct {
+		name              string
+		data              []byte
+		expectedBLAKE2b   string
+		expectedBLAKE2b32 string
+	}{
+		{
+			name:              "Empty",
+			data:              []byte{},
+			expectedBLAKE2b:   "786a02f742015903c6c6fd852552d272912f4740e15847618a86e217f71f5419d25e1031afee585313896444934eb04b903a685b1448b755d56f701afe9be2ce",
+			expectedBLAKE2b32: "0e5751c026e543b2e8ab2eb06099daa1d1e5df47778f7787faab45cdf12fe3a8",
+		},
+		{
+			name:              "ABC",
+			data:              []byte("abc"),
+			expectedBLAKE2b:   "ba80a53f981c4d0d6a2797b69f12f6e94c212f14685ac4b74b12bb6fdbffa2d17d87c5392aab792dc252d5de4533cc9518d38aa8dbf1925ab92386edd4009923",
+			expectedBLAKE2b32: "bddd813c634239723171ef3fee98579b94964e3bb1cb3e427262c8c068d52319",
+		},
+		{
+			name:              "OneBlock",
+			data:              oneBlock[:128],
+			expectedBLAKE2b:   "2319e3789c47e2daa5fe807f61bec2a1a6537fa03f19ff32e87eecbfd64b7e0e8ccff439ac333b040f19b0c4ddd11a61e24ac1fe0f10a039806c5dcc0da3d115",
+			expectedBLAKE2b32: "c3582f71ebb2be66fa5dd750f80baae97554f3b015663c8be377cfcb2488c1d1",
+		},
+		{
+			name:              "OneBlockAndOneByte",
+			data:              oneBlock,
+			expectedBLAKE2b:   "f59711d44a031d5f97a9413c065d1e614c417ede998590325f49bad2fd444d3e4418be19aec4e11449ac1a57207898bc57d76a1bcf3566292c20c683a5c4648f",
+			expectedBLAKE2b32: "f7f3c46ba2564ff4c4c162da1f5b605f9f1c4aa6a20652a9f9a337c1a2f5b9c9",
+		},
+		{
+			name:              "ManyBlocks",
+			data:              manyBlocks,
+			expectedBLAKE2b:   "c11e1c0340bd7e5a1b275f1230c962fad215ecb1391486e74e31b960a2f2996381a5fad092da06841d5f26e38f6ecfeaf441acbcd1c2de61aef121e7927175f5",
+			expectedBLAKE2b32: "b372d0608f720c8c3dd41e9c8eecb10143b41abe520b616607e754bf79c08331",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedBLAKE2b, hex.EncodeToString(blake2bSum(tc.data, 64)))
+			assert.Equal(t, tc.expectedBLAKE2b32, hex.EncodeToString(blake2bSum(tc.data, 32)))
+
+			// Writing the data in chunks results in the same digest
+			b := newBLAKE2b(64)
+			for i := 0; i < len(tc.data); i += 100 {
+				end := i + 100
+				if end > len(tc.data) {
+					end = len(tc.data)
+				}
+				_, _ = b.Write(tc.data[i:end])
+			}
+			assert.Equal(t, tc.expectedBLAKE2b, hex.EncodeToString(b.Sum()))
+		})
+	}
+}
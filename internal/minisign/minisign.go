@@ -0,0 +1,274 @@
+// Package minisign signs and verifies files in the format of minisign (https://jedisct1.github.io/minisign).
+// Signatures are created with pre-hashing (minisign 0.8 or later) and both pre-hashed and legacy signatures are verified.
+// Only unencrypted secret keys (minisign -G -W) are supported.
+package minisign
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+const (
+	untrustedCommentPrefix = "untrusted comment: "
+	trustedCommentPrefix   = "trusted comment: "
+
+	publicKeySize  = 2 + 8 + ed25519.PublicKeySize
+	secretKeySize  = 2 + 2 + 2 + 32 + 8 + 8 + 8 + ed25519.PrivateKeySize + 32
+	signatureSize  = 2 + 8 + ed25519.SignatureSize
+	checksumSize   = 32
+	secretKeyStart = 2 + 2 + 2 + 32 + 8 + 8
+)
+
+var (
+	algEd        = [2]byte{'E', 'd'}
+	algPrehashed = [2]byte{'E', 'D'}
+	algBLAKE2b   = [2]byte{'B', '2'}
+	kdfNone      = [2]byte{0, 0}
+)
+
+var (
+	// ErrEncryptedKey is returned when a secret key is encrypted with a password.
+	ErrEncryptedKey = errors.New("encrypted secret keys are not supported (use minisign -G -W for creating an unencrypted key)")
+
+	// ErrInvalidSignature is returned when a signature does not match a file.
+	ErrInvalidSignature = errors.New("invalid signature")
+)
+
+// KeyID identifies the key pair a signature is created with.
+type KeyID [8]byte
+
+// String returns the key id in the same format as minisign.
+func (id KeyID) String() string {
+	return fmt.Sprintf("%016X", binary.LittleEndian.Uint64(id[:]))
+}
+
+// PublicKey is a public key for verifying signatures.
+type PublicKey struct {
+	ID  KeyID
+	Key ed25519.PublicKey
+}
+
+// ParsePublicKey parses a public key from the content of a minisign public key file or its base64 line.
+func ParsePublicKey(s string) (PublicKey, error) {
+	b, err := decodeLine(s)
+	if err != nil {
+		return PublicKey{}, fmt.Errorf("invalid public key: %s", err)
+	}
+
+	if len(b) != publicKeySize || !bytes.Equal(b[:2], algEd[:]) {
+		return PublicKey{}, errors.New("invalid public key: unknown format")
+	}
+
+	var k PublicKey
+	copy(k.ID[:], b[2:10])
+	k.Key = ed25519.PublicKey(b[10:])
+
+	return k, nil
+}
+
+// String returns the base64 encoding of the public key.
+func (k PublicKey) String() string {
+	b := make([]byte, 0, publicKeySize)
+	b = append(b, algEd[:]...)
+	b = append(b, k.ID[:]...)
+	b = append(b, k.Key...)
+
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+// Encode returns the public key in the format of minisign public key files.
+func (k PublicKey) Encode() []byte {
+	return []byte(fmt.Sprintf("%sminisign public key %s\n%s\n", untrustedCommentPrefix, k.ID, k))
+}
+
+// PrivateKey is a secret key for signing files.
+type PrivateKey struct {
+	ID  KeyID
+	Key ed25519.PrivateKey
+}
+
+// GenerateKey creates a new key pair using an entropy source (i.e. crypto/rand.Reader).
+func GenerateKey(rand io.Reader) (PublicKey, PrivateKey, error) {
+	pub, priv, err := ed25519.GenerateKey(rand)
+	if err != nil {
+		return PublicKey{}, PrivateKey{}, err
+	}
+
+	var id KeyID
+	if _, err := io.ReadFull(rand, id[:]); err != nil {
+		return PublicKey{}, PrivateKey{}, err
+	}
+
+	return PublicKey{ID: id, Key: pub}, PrivateKey{ID: id, Key: priv}, nil
+}
+
+// ParsePrivateKey parses an unencrypted secret key from the content of a minisign secret key file or its base64 line.
+func ParsePrivateKey(s string) (PrivateKey, error) {
+	b, err := decodeLine(s)
+	if err != nil {
+		return PrivateKey{}, fmt.Errorf("invalid secret key: %s", err)
+	}
+
+	if len(b) != secretKeySize || !bytes.Equal(b[:2], algEd[:]) || !bytes.Equal(b[4:6], algBLAKE2b[:]) {
+		return PrivateKey{}, errors.New("invalid secret key: unknown format")
+	}
+
+	if !bytes.Equal(b[2:4], kdfNone[:]) {
+		return PrivateKey{}, ErrEncryptedKey
+	}
+
+	var k PrivateKey
+	copy(k.ID[:], b[secretKeyStart:secretKeyStart+8])
+	k.Key = ed25519.PrivateKey(b[secretKeyStart+8 : secretKeyStart+8+ed25519.PrivateKeySize])
+
+	chk := b[secretKeySize-checksumSize:]
+	if !bytes.Equal(chk, k.checksum()) {
+		return PrivateKey{}, errors.New("invalid secret key: checksum mismatch")
+	}
+
+	return k, nil
+}
+
+// Public returns the public key of the key pair.
+func (k PrivateKey) Public() PublicKey {
+	return PublicKey{
+		ID:  k.ID,
+		Key: k.Key.Public().(ed25519.PublicKey),
+	}
+}
+
+// Encode returns the unencrypted secret key in the format of minisign secret key files.
+func (k PrivateKey) Encode() []byte {
+	b := make([]byte, 0, secretKeySize)
+	b = append(b, algEd[:]...)
+	b = append(b, kdfNone[:]...)
+	b = append(b, algBLAKE2b[:]...)
+	b = append(b, make([]byte, 32+8+8)...) // No salt and limits for the key derivation function
+	b = append(b, k.ID[:]...)
+	b = append(b, k.Key...)
+	b = append(b, k.checksum()...)
+
+	return []byte(fmt.Sprintf("%sminisign secret key\n%s\n", untrustedCommentPrefix, base64.StdEncoding.EncodeToString(b)))
+}
+
+func (k PrivateKey) checksum() []byte {
+	h := newBLAKE2b(checksumSize)
+	_, _ = h.Write(algEd[:])
+	_, _ = h.Write(k.ID[:])
+	_, _ = h.Write(k.Key)
+
+	return h.Sum()
+}
+
+// Sign creates a pre-hashed signature for a file in the format of minisign signature files.
+// The trusted comment is signed too (i.e. timestamp:1600000000	file:app-linux-amd64).
+func Sign(key PrivateKey, r io.Reader, trustedComment string) ([]byte, error) {
+	if strings.ContainsAny(trustedComment, "\r\n") {
+		return nil, errors.New("trusted comment cannot have multiple lines")
+	}
+
+	h := newBLAKE2b(64)
+	if _, err := io.Copy(h, r); err != nil {
+		return nil, err
+	}
+
+	sig := make([]byte, 0, signatureSize)
+	sig = append(sig, algPrehashed[:]...)
+	sig = append(sig, key.ID[:]...)
+	sig = append(sig, ed25519.Sign(key.Key, h.Sum())...)
+
+	globalSig := ed25519.Sign(key.Key, globalMessage(sig, trustedComment))
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%ssignature from cherry secret key\n", untrustedCommentPrefix)
+	fmt.Fprintf(&buf, "%s\n", base64.StdEncoding.EncodeToString(sig))
+	fmt.Fprintf(&buf, "%s%s\n", trustedCommentPrefix, trustedComment)
+	fmt.Fprintf(&buf, "%s\n", base64.StdEncoding.EncodeToString(globalSig))
+
+	return buf.Bytes(), nil
+}
+
+// Verify verifies a file against a signature in the format of minisign signature files and returns the trusted comment.
+func Verify(key PublicKey, r io.Reader, signature []byte) (string, error) {
+	lines := strings.Split(strings.TrimRight(string(signature), "\r\n"), "\n")
+	for i := range lines {
+		lines[i] = strings.TrimRight(lines[i], "\r")
+	}
+
+	if len(lines) != 4 || !strings.HasPrefix(lines[0], untrustedCommentPrefix) || !strings.HasPrefix(lines[2], trustedCommentPrefix) {
+		return "", errors.New("invalid signature file")
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(lines[1])
+	if err != nil || len(sig) != signatureSize {
+		return "", errors.New("invalid signature file: invalid signature")
+	}
+
+	globalSig, err := base64.StdEncoding.DecodeString(lines[3])
+	if err != nil || len(globalSig) != ed25519.SignatureSize {
+		return "", errors.New("invalid signature file: invalid trusted comment signature")
+	}
+
+	var id KeyID
+	copy(id[:], sig[2:10])
+	if id != key.ID {
+		return "", fmt.Errorf("signature created with key %s instead of %s", id, key.ID)
+	}
+
+	var message []byte
+	switch {
+	case bytes.Equal(sig[:2], algPrehashed[:]):
+		h := newBLAKE2b(64)
+		if _, err := io.Copy(h, r); err != nil {
+			return "", err
+		}
+		message = h.Sum()
+	case bytes.Equal(sig[:2], algEd[:]):
+		if message, err = ioutil.ReadAll(r); err != nil {
+			return "", err
+		}
+	default:
+		return "", errors.New("invalid signature file: unknown signature algorithm")
+	}
+
+	if !ed25519.Verify(key.Key, message, sig[10:]) {
+		return "", ErrInvalidSignature
+	}
+
+	trustedComment := strings.TrimPrefix(lines[2], trustedCommentPrefix)
+	if !ed25519.Verify(key.Key, globalMessage(sig, trustedComment), globalSig) {
+		return "", fmt.Errorf("%w: the trusted comment is modified", ErrInvalidSignature)
+	}
+
+	return trustedComment, nil
+}
+
+// globalMessage returns the message signed for a trusted comment, so it cannot be modified independently of the signature.
+func globalMessage(sig []byte, trustedComment string) []byte {
+	m := make([]byte, 0, ed25519.SignatureSize+len(trustedComment))
+	m = append(m, sig[10:]...)
+	m = append(m, trustedComment...)
+
+	return m
+}
+
+// decodeLine decodes the base64 line of a minisign file ignoring its untrusted comment.
+func decodeLine(s string) ([]byte, error) {
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, untrustedCommentPrefix) {
+			continue
+		}
+
+		return base64.StdEncoding.DecodeString(line)
+	}
+
+	return nil, errors.New("no key found")
+}
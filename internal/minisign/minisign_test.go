@@ -0,0 +1,258 @@
+package minisign
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePublicKey(t *testing.T) {
+	tests := []struct {
+		name          string
+		s             string
+		expectedID    string
+		expectedError string
+	}{
+		{
+			// The public key of minisign itself (https://jedisct1.github.io/minisign)
+			name:       "Base64",
+			s:          "RWQf6LRCGA9i53mlYecO4IzT51TGPpvWucNSCh1CBM0QTaLn73Y7GFO3",
+			expectedID: "E7620F1842B4E81F",
+		},
+		{
+			name:       "File",
+			s:          "untrusted comment: minisign public key E7620F1842B4E81F\nRWQf6LRCGA9i53mlYecO4IzT51TGPpvWucNSCh1CBM0QTaLn73Y7GFO3\n",
+			expectedID: "E7620F1842B4E81F",
+		},
+		{
+			name:          "Empty",
+			s:             "untrusted comment: minisign public key\n",
+			expectedError: "invalid public key: no key found",
+		},
+		{
+			name:          "InvalidBase64",
+			s:             "RWQf6LRCGA9i53mlYecO4IzT51TGPpvWucNSCh1CBM0QTaLn73Y7GFO!",
+			expectedError: "invalid public key: illegal base64 data",
+		},
+		{
+			name:          "InvalidFormat",
+			s:             "RWQf6LRCGA9i53mlYecO4IzT51TGPpvWucNSCh1CBM0QTaLn",
+			expectedError: "invalid public key: unknown format",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			key, err := ParsePublicKey(tc.s)
+
+			if tc.expectedError != "" {
+				assert.Contains(t, err.Error(), tc.expectedError)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedID, key.ID.String())
+				assert.Equal(t, "RWQf6LRCGA9i53mlYecO4IzT51TGPpvWucNSCh1CBM0QTaLn73Y7GFO3", key.String())
+			}
+		})
+	}
+}
+
+func TestKeyEncoding(t *testing.T) {
+	pub, priv, err := GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	assert.Equal(t, pub, priv.Public())
+
+	t.Run("PublicKey", func(t *testing.T) {
+		data := pub.Encode()
+		assert.True(t, strings.HasPrefix(string(data), "untrusted comment: minisign public key "+pub.ID.String()+"\n"))
+
+		key, err := ParsePublicKey(string(data))
+		assert.NoError(t, err)
+		assert.Equal(t, pub, key)
+	})
+
+	t.Run("PrivateKey", func(t *testing.T) {
+		data := priv.Encode()
+
+		key, err := ParsePrivateKey(string(data))
+		assert.NoError(t, err)
+		assert.Equal(t, priv, key)
+	})
+
+	t.Run("EncryptedPrivateKey", func(t *testing.T) {
+		b, _ := decodeLine(string(priv.Encode()))
+		copy(b[2:4], "Sc")
+
+		_, err := ParsePrivateKey(base64.StdEncoding.EncodeToString(b))
+		assert.Equal(t, ErrEncryptedKey, err)
+	})
+
+	t.Run("CorruptedPrivateKey", func(t *testing.T) {
+		b, _ := decodeLine(string(priv.Encode()))
+		b[secretKeyStart+8] ^= 0xff
+
+		_, err := ParsePrivateKey(base64.StdEncoding.EncodeToString(b))
+		assert.EqualError(t, err, "invalid secret key: checksum mismatch")
+	})
+
+	t.Run("InvalidPrivateKey", func(t *testing.T) {
+		_, err := ParsePrivateKey(pub.String())
+		assert.EqualError(t, err, "invalid secret key: unknown format")
+	})
+}
+
+func TestSignVerify(t *testing.T) {
+	pub, priv, err := GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	otherPub, _, err := GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	content := []byte("binary content")
+	comment := "timestamp:1600000000\tfile:app-linux-amd64"
+
+	sig, err := Sign(priv, bytes.NewReader(content), comment)
+	assert.NoError(t, err)
+
+	// A legacy signature is created from the content instead of its hash
+	legacySig := func() []byte {
+		s := append(append(append([]byte{}, algEd[:]...), priv.ID[:]...), ed25519.Sign(priv.Key, content)...)
+		globalSig := ed25519.Sign(priv.Key, globalMessage(s, comment))
+		return []byte("untrusted comment: signature from minisign secret key\n" +
+			base64.StdEncoding.EncodeToString(s) + "\n" +
+			"trusted comment: " + comment + "\n" +
+			base64.StdEncoding.EncodeToString(globalSig) + "\n")
+	}()
+
+	tests := []struct {
+		name            string
+		key             PublicKey
+		content         []byte
+		signature       []byte
+		expectedComment string
+		expectedError   error
+		expectedMessage string
+	}{
+		{
+			name:            "Valid",
+			key:             pub,
+			content:         content,
+			signature:       sig,
+			expectedComment: comment,
+		},
+		{
+			name:            "ValidCRLF",
+			key:             pub,
+			content:         content,
+			signature:       bytes.ReplaceAll(sig, []byte("\n"), []byte("\r\n")),
+			expectedComment: comment,
+		},
+		{
+			name:            "Legacy",
+			key:             pub,
+			content:         content,
+			signature:       legacySig,
+			expectedComment: comment,
+		},
+		{
+			name:          "ModifiedContent",
+			key:           pub,
+			content:       []byte("malicious content"),
+			signature:     sig,
+			expectedError: ErrInvalidSignature,
+		},
+		{
+			name:          "ModifiedTrustedComment",
+			key:           pub,
+			content:       content,
+			signature:     bytes.Replace(sig, []byte("app-linux-amd64"), []byte("app-linux-arm64"), 1),
+			expectedError: ErrInvalidSignature,
+		},
+		{
+			name:            "OtherKey",
+			key:             otherPub,
+			content:         content,
+			signature:       sig,
+			expectedMessage: "signature created with key " + pub.ID.String(),
+		},
+		{
+			name:            "InvalidFile",
+			key:             pub,
+			content:         content,
+			signature:       []byte("untrusted comment: signature\n"),
+			expectedMessage: "invalid signature file",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			trustedComment, err := Verify(tc.key, bytes.NewReader(tc.content), tc.signature)
+
+			switch {
+			case tc.expectedError != nil:
+				assert.True(t, errors.Is(err, tc.expectedError))
+			case tc.expectedMessage != "":
+				assert.Contains(t, err.Error(), tc.expectedMessage)
+			default:
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedComment, trustedComment)
+			}
+		})
+	}
+
+	t.Run("MultilineTrustedComment", func(t *testing.T) {
+		_, err := Sign(priv, bytes.NewReader(content), "line1\nline2")
+		assert.Error(t, err)
+	})
+}
+
+func TestVerifyMinisign(t *testing.T) {
+	// Signatures created by minisign -S with the minisign secret key for a file named test (https://github.com/jedisct1/go-minisign)
+	key, err := ParsePublicKey("RWQf6LRCGA9i53mlYecO4IzT51TGPpvWucNSCh1CBM0QTaLn73Y7GFO3")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name            string
+		content         []byte
+		signature       string
+		expectedComment string
+		expectedError   error
+	}{
+		{
+			name:            "Prehashed",
+			content:         []byte("test"),
+			signature:       "untrusted comment: signature from minisign secret key\nRUQf6LRCGA9i559r3g7V1qNyJDApGip8MfqcadIgT9CuhV3EMhHoN1mGTkUidF/z7SrlQgXdy8ofjb7bNJJylDOocrCo8KLzZwo=\ntrusted comment: timestamp:1635443258\tfile:test\thashed\n/cj37GK60vryibFn+ftOgbCvW9NKhKYgjVpFFQUcWPAnjO23wrvVDTt7cloNC06maoBli9q6qwZDXXoaxweICQ==\n",
+			expectedComment: "timestamp:1635443258\tfile:test\thashed",
+		},
+		{
+			name:            "Legacy",
+			content:         []byte("test"),
+			signature:       "untrusted comment: signature from minisign secret key\nRWQf6LRCGA9i59SLOFxz6NxvASXDJeRtuZykwQepbDEGt87ig1BNpWaVWuNrm73YiIiJbq71Wi+dP9eKL8OC351vwIasSSbXxwA=\ntrusted comment: timestamp:1635442742\tfile:test\n0YteLgV960ia80vnA/fHbvkyjl/IoP/HNOCaZfrF0CdhAlp7ok+Tpkya+VpWPX5C/Is3q8a/kEDSY7fBmmgJCg==\n",
+			expectedComment: "timestamp:1635442742\tfile:test",
+		},
+		{
+			name:          "ModifiedContent",
+			content:       []byte("tests"),
+			signature:     "untrusted comment: signature from minisign secret key\nRUQf6LRCGA9i559r3g7V1qNyJDApGip8MfqcadIgT9CuhV3EMhHoN1mGTkUidF/z7SrlQgXdy8ofjb7bNJJylDOocrCo8KLzZwo=\ntrusted comment: timestamp:1635443258\tfile:test\thashed\n/cj37GK60vryibFn+ftOgbCvW9NKhKYgjVpFFQUcWPAnjO23wrvVDTt7cloNC06maoBli9q6qwZDXXoaxweICQ==\n",
+			expectedError: ErrInvalidSignature,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			trustedComment, err := Verify(key, bytes.NewReader(tc.content), []byte(tc.signature))
+
+			if tc.expectedError != nil {
+				assert.True(t, errors.Is(err, tc.expectedError))
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedComment, trustedComment)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+)
+
+// DryRun is a Provider that records the operations changing the remote repository instead of making them.
+// Read-only operations are delegated to the underlying provider.
+type DryRun struct {
+	Provider Provider
+	// Record is called with a description of each operation not made.
+	Record func(string)
+}
+
+// Name returns the name of the underlying provider.
+func (d *DryRun) Name() string {
+	return d.Provider.Name()
+}
+
+// CheckPermission checks the permission using the underlying provider.
+func (d *DryRun) CheckPermission(ctx context.Context) error {
+	return d.Provider.CheckPermission(ctx)
+}
+
+// CreateDraft records creating a draft release.
+func (d *DryRun) CreateDraft(ctx context.Context, release Release) (Release, error) {
+	d.Record(fmt.Sprintf("%s: create draft release %s for tag %s targeting %s", d.Name(), release.Name, release.TagName, release.Target))
+
+	release.Draft = true
+
+	return release, nil
+}
+
+// UploadAsset records uploading a file for a release.
+func (d *DryRun) UploadAsset(ctx context.Context, release Release, path string) (Asset, error) {
+	d.Record(fmt.Sprintf("%s: upload asset %s to release %s", d.Name(), path, release.Name))
+
+	return Asset{
+		Name: filepath.Base(path),
+	}, nil
+}
+
+// Publish records publishing a release.
+func (d *DryRun) Publish(ctx context.Context, release Release) (Release, error) {
+	d.Record(fmt.Sprintf("%s: publish release %s with %d asset(s)", d.Name(), release.Name, len(release.Assets)))
+
+	release.Draft = false
+
+	return release, nil
+}
+
+// DeleteRelease records deleting a release.
+func (d *DryRun) DeleteRelease(ctx context.Context, release Release) error {
+	d.Record(fmt.Sprintf("%s: delete release %s", d.Name(), release.Name))
+
+	return nil
+}
+
+// DeleteAsset records deleting an asset of a release.
+func (d *DryRun) DeleteAsset(ctx context.Context, release Release, asset Asset) error {
+	d.Record(fmt.Sprintf("%s: delete asset %s of release %s", d.Name(), asset.Name, release.Name))
+
+	return nil
+}
+
+// ProtectBranch records enabling or disabling the protection of a branch.
+func (d *DryRun) ProtectBranch(ctx context.Context, branch string, protect bool) error {
+	if protect {
+		d.Record(fmt.Sprintf("%s: re-enable protection of branch %s", d.Name(), branch))
+	} else {
+		d.Record(fmt.Sprintf("%s: disable protection of branch %s", d.Name(), branch))
+	}
+
+	return nil
+}
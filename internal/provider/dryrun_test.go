@@ -0,0 +1,77 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDryRun(t *testing.T) {
+	var requests []string
+
+	g, close := newGitHub(t, func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+
+		switch r.URL.Path {
+		case "/user":
+			fmt.Fprint(w, `{ "id": 1, "login": "octocat" }`)
+		case "/repos/moorara/cherry/collaborators/octocat/permission":
+			fmt.Fprint(w, `{ "permission": "admin" }`)
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL)
+		}
+	})
+	defer close()
+
+	var plan []string
+
+	d := &DryRun{
+		Provider: g,
+		Record: func(step string) {
+			plan = append(plan, step)
+		},
+	}
+
+	ctx := context.Background()
+
+	assert.Equal(t, "GitHub", d.Name())
+	assert.NoError(t, d.CheckPermission(ctx))
+
+	release, err := d.CreateDraft(ctx, Release{Name: "0.2.0", TagName: "v0.2.0", Target: "main"})
+	assert.NoError(t, err)
+	assert.True(t, release.Draft)
+
+	asset, err := d.UploadAsset(ctx, release, "bin/cherry-linux-amd64")
+	assert.NoError(t, err)
+	assert.Equal(t, Asset{Name: "cherry-linux-amd64"}, asset)
+
+	assert.NoError(t, d.ProtectBranch(ctx, "main", false))
+	assert.NoError(t, d.ProtectBranch(ctx, "main", true))
+
+	assert.NoError(t, d.DeleteAsset(ctx, release, asset))
+	assert.NoError(t, d.DeleteRelease(ctx, release))
+
+	release.Assets = []Asset{asset}
+	release, err = d.Publish(ctx, release)
+	assert.NoError(t, err)
+	assert.False(t, release.Draft)
+
+	// Only the read-only requests are made
+	assert.Equal(t, []string{
+		"GET /user",
+		"GET /repos/moorara/cherry/collaborators/octocat/permission",
+	}, requests)
+
+	assert.Equal(t, []string{
+		"GitHub: create draft release 0.2.0 for tag v0.2.0 targeting main",
+		"GitHub: upload asset bin/cherry-linux-amd64 to release 0.2.0",
+		"GitHub: disable protection of branch main",
+		"GitHub: re-enable protection of branch main",
+		"GitHub: delete asset cherry-linux-amd64 of release 0.2.0",
+		"GitHub: delete release 0.2.0",
+		"GitHub: publish release 0.2.0 with 1 asset(s)",
+	}, plan)
+}
@@ -0,0 +1,272 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+
+	netURL "net/url"
+)
+
+// Gitea implements Provider for Gitea and Forgejo using Gitea REST API v1.
+// See https://try.gitea.io/api/swagger
+type Gitea struct {
+	Client *http.Client
+	// APIURL is the base URL of Gitea API (i.e. https://gitea.example.com/api/v1).
+	APIURL string
+	Token  string
+	Owner  string
+	Repo   string
+
+	// unprotected keeps the branch protections removed for restoring them later.
+	unprotected map[string]map[string]interface{}
+}
+
+type giteaRelease struct {
+	ID         int    `json:"id"`
+	Name       string `json:"name"`
+	TagName    string `json:"tag_name"`
+	Target     string `json:"target_commitish"`
+	Draft      bool   `json:"draft"`
+	Prerelease bool   `json:"prerelease"`
+	Body       string `json:"body"`
+	HTMLURL    string `json:"html_url"`
+}
+
+func (g *Gitea) url(format string, a ...interface{}) string {
+	return strings.TrimRight(g.APIURL, "/") + fmt.Sprintf(format, a...)
+}
+
+func (g *Gitea) request(ctx context.Context, method, url string, body io.Reader) *http.Request {
+	req, _ := http.NewRequest(method, url, body)
+	req = req.WithContext(ctx)
+	req.Header.Set("Authorization", "token "+g.Token)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "cherry")
+	req.Header.Set("Content-Type", "application/json")
+
+	return req
+}
+
+// Name returns the name of the provider.
+func (g *Gitea) Name() string {
+	return "Gitea"
+}
+
+// CheckPermission checks if the user has admin permission for creating releases and pushing tags.
+func (g *Gitea) CheckPermission(ctx context.Context) error {
+	// See https://try.gitea.io/api/swagger#/user/userGetCurrent
+	user := struct {
+		Login string `json:"login"`
+	}{}
+
+	req := g.request(ctx, "GET", g.url("/user"), nil)
+	if err := send(g.Client, req, 200, &user); err != nil {
+		return err
+	}
+
+	// The permissions of the authenticated user are included in the repository
+	// See https://try.gitea.io/api/swagger#/repository/repoGet
+	repo := struct {
+		Permissions struct {
+			Admin bool `json:"admin"`
+		} `json:"permissions"`
+	}{}
+
+	req = g.request(ctx, "GET", g.url("/repos/%s/%s", g.Owner, g.Repo), nil)
+	if err := send(g.Client, req, 200, &repo); err != nil {
+		return err
+	}
+
+	if !repo.Permissions.Admin {
+		return fmt.Errorf("%w: %s is not an admin", ErrPermission, user.Login)
+	}
+
+	return nil
+}
+
+// CreateDraft creates a draft Gitea release.
+// See https://try.gitea.io/api/swagger#/repository/repoCreateRelease
+func (g *Gitea) CreateDraft(ctx context.Context, release Release) (Release, error) {
+	return g.editRelease(ctx, "POST", g.url("/repos/%s/%s/releases", g.Owner, g.Repo), 201, release, true)
+}
+
+// UploadAsset uploads a file as an attachment to a Gitea release.
+// See https://try.gitea.io/api/swagger#/repository/repoCreateReleaseAttachment
+func (g *Gitea) UploadAsset(ctx context.Context, release Release, path string) (Asset, error) {
+	f, name, _, _, err := openAsset(path)
+	if err != nil {
+		return Asset{}, err
+	}
+	defer f.Close()
+
+	body := new(bytes.Buffer)
+	mw := multipart.NewWriter(body)
+
+	part, err := mw.CreateFormFile("attachment", name)
+	if err != nil {
+		return Asset{}, err
+	}
+
+	if _, err := io.Copy(part, f); err != nil {
+		return Asset{}, err
+	}
+
+	if err := mw.Close(); err != nil {
+		return Asset{}, err
+	}
+
+	url := g.url("/repos/%s/%s/releases/%s/assets?name=%s", g.Owner, g.Repo, release.ID, netURL.QueryEscape(name))
+	req := g.request(ctx, "POST", url, body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+
+	asset := struct {
+		ID          int    `json:"id"`
+		Name        string `json:"name"`
+		DownloadURL string `json:"browser_download_url"`
+	}{}
+
+	if err := send(g.Client, req, 201, &asset); err != nil {
+		return Asset{}, err
+	}
+
+	return Asset{
+		ID:   strconv.Itoa(asset.ID),
+		Name: asset.Name,
+		URL:  asset.DownloadURL,
+	}, nil
+}
+
+// Publish publishes a draft Gitea release.
+// See https://try.gitea.io/api/swagger#/repository/repoEditRelease
+func (g *Gitea) Publish(ctx context.Context, release Release) (Release, error) {
+	return g.editRelease(ctx, "PATCH", g.url("/repos/%s/%s/releases/%s", g.Owner, g.Repo, release.ID), 200, release, false)
+}
+
+func (g *Gitea) editRelease(ctx context.Context, method, url string, expectedStatusCode int, release Release, draft bool) (Release, error) {
+	body := new(bytes.Buffer)
+	_ = json.NewEncoder(body).Encode(struct {
+		Name       string `json:"name"`
+		TagName    string `json:"tag_name"`
+		Target     string `json:"target_commitish"`
+		Draft      bool   `json:"draft"`
+		Prerelease bool   `json:"prerelease"`
+		Body       string `json:"body"`
+	}{
+		Name:       release.Name,
+		TagName:    release.TagName,
+		Target:     release.Target,
+		Draft:      draft,
+		Prerelease: release.Prerelease,
+		Body:       release.Body,
+	})
+
+	out := giteaRelease{}
+	req := g.request(ctx, method, url, body)
+	if err := send(g.Client, req, expectedStatusCode, &out); err != nil {
+		return Release{}, err
+	}
+
+	return Release{
+		ID:          strconv.Itoa(out.ID),
+		Name:        out.Name,
+		TagName:     out.TagName,
+		Target:      out.Target,
+		Draft:       out.Draft,
+		Prerelease:  out.Prerelease,
+		Maintenance: release.Maintenance,
+		Body:        out.Body,
+		URL:         out.HTMLURL,
+		Assets:      release.Assets,
+	}, nil
+}
+
+// DeleteRelease deletes a Gitea release.
+// See https://try.gitea.io/api/swagger#/repository/repoDeleteRelease
+func (g *Gitea) DeleteRelease(ctx context.Context, release Release) error {
+	req := g.request(ctx, "DELETE", g.url("/repos/%s/%s/releases/%s", g.Owner, g.Repo, release.ID), nil)
+	return send(g.Client, req, 204, nil)
+}
+
+// DeleteAsset deletes an attachment of a Gitea release.
+// See https://try.gitea.io/api/swagger#/repository/repoDeleteReleaseAttachment
+func (g *Gitea) DeleteAsset(ctx context.Context, release Release, asset Asset) error {
+	req := g.request(ctx, "DELETE", g.url("/repos/%s/%s/releases/%s/assets/%s", g.Owner, g.Repo, release.ID, asset.ID), nil)
+	return send(g.Client, req, 204, nil)
+}
+
+// ProtectBranch removes the protection of a branch or creates it again with the same options it had before.
+// See https://try.gitea.io/api/swagger#/repository/repoGetBranchProtection
+func (g *Gitea) ProtectBranch(ctx context.Context, branch string, protect bool) error {
+	if g.unprotected == nil {
+		g.unprotected = map[string]map[string]interface{}{}
+	}
+
+	if protect {
+		bp, ok := g.unprotected[branch]
+		if !ok {
+			// The branch was not protected before
+			return nil
+		}
+
+		body := new(bytes.Buffer)
+		_ = json.NewEncoder(body).Encode(bp)
+
+		req := g.request(ctx, "POST", g.url("/repos/%s/%s/branch_protections", g.Owner, g.Repo), body)
+		if err := send(g.Client, req, 201, nil); err != nil {
+			return err
+		}
+
+		delete(g.unprotected, branch)
+		return nil
+	}
+
+	bp := map[string]interface{}{}
+	url := g.url("/repos/%s/%s/branch_protections/%s", g.Owner, g.Repo, netURL.PathEscape(branch))
+
+	req := g.request(ctx, "GET", url, nil)
+	if err := send(g.Client, req, 200, &bp); err != nil {
+		if serr := new(statusError); errors.As(err, &serr) && serr.StatusCode == 404 {
+			// The branch is not protected
+			return nil
+		}
+		return err
+	}
+
+	req = g.request(ctx, "DELETE", url, nil)
+	if err := send(g.Client, req, 204, nil); err != nil {
+		return err
+	}
+
+	// The options for creating a branch protection are the same as the ones returned except the timestamps
+	delete(bp, "created_at")
+	delete(bp, "updated_at")
+	if _, ok := bp["branch_name"]; !ok {
+		bp["branch_name"] = branch
+	}
+
+	g.unprotected[branch] = bp
+
+	return nil
+}
+
+// SaveProtections returns the branch protections removed and not created again yet.
+func (g *Gitea) SaveProtections() (json.RawMessage, error) {
+	if len(g.unprotected) == 0 {
+		return nil, nil
+	}
+
+	return json.Marshal(g.unprotected)
+}
+
+// LoadProtections loads the branch protections removed before for creating them again.
+func (g *Gitea) LoadProtections(data json.RawMessage) error {
+	return json.Unmarshal(data, &g.unprotected)
+}
@@ -0,0 +1,226 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newGitea(t *testing.T, handler http.HandlerFunc) (*Gitea, func()) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "token gitea-token", r.Header.Get("Authorization"))
+		handler(w, r)
+	}))
+
+	g := &Gitea{
+		Client: ts.Client(),
+		APIURL: ts.URL + "/api/v1",
+		Token:  "gitea-token",
+		Owner:  "moorara",
+		Repo:   "cherry",
+	}
+
+	return g, ts.Close
+}
+
+func TestGiteaCheckPermission(t *testing.T) {
+	tests := []struct {
+		name          string
+		admin         bool
+		expectedError string
+	}{
+		{
+			name:  "Admin",
+			admin: true,
+		},
+		{
+			name:          "NotAdmin",
+			admin:         false,
+			expectedError: "insufficient permission for releasing: octocat is not an admin",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g, close := newGitea(t, func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/api/v1/user":
+					fmt.Fprint(w, `{ "id": 1, "login": "octocat" }`)
+				case "/api/v1/repos/moorara/cherry":
+					fmt.Fprintf(w, `{ "id": 1, "name": "cherry", "permissions": { "admin": %t, "push": true, "pull": true } }`, tc.admin)
+				default:
+					t.Errorf("unexpected request: %s %s", r.Method, r.URL)
+				}
+			})
+			defer close()
+
+			err := g.CheckPermission(context.Background())
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestGiteaRelease(t *testing.T) {
+	g, close := newGitea(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/api/v1/repos/moorara/cherry/releases":
+			b, _ := ioutil.ReadAll(r.Body)
+			assert.JSONEq(t, `{ "name": "0.2.0", "tag_name": "v0.2.0", "target_commitish": "main", "draft": true, "prerelease": true, "body": "" }`, string(b))
+			w.WriteHeader(201)
+			fmt.Fprint(w, `{ "id": 3, "name": "0.2.0", "tag_name": "v0.2.0", "target_commitish": "main", "draft": true, "prerelease": true, "html_url": "https://gitea.example.com/moorara/cherry/releases/tag/v0.2.0" }`)
+		case r.Method == "POST" && r.URL.Path == "/api/v1/repos/moorara/cherry/releases/3/assets":
+			assert.Equal(t, "cherry-linux-amd64", r.URL.Query().Get("name"))
+			f, h, err := r.FormFile("attachment")
+			assert.NoError(t, err)
+			assert.Equal(t, "cherry-linux-amd64", h.Filename)
+			b, _ := ioutil.ReadAll(f)
+			assert.Equal(t, "binary", string(b))
+			w.WriteHeader(201)
+			fmt.Fprint(w, `{ "id": 5, "name": "cherry-linux-amd64", "browser_download_url": "https://gitea.example.com/attachments/5" }`)
+		case r.Method == "PATCH" && r.URL.Path == "/api/v1/repos/moorara/cherry/releases/3":
+			b, _ := ioutil.ReadAll(r.Body)
+			assert.JSONEq(t, `{ "name": "0.2.0", "tag_name": "v0.2.0", "target_commitish": "main", "draft": false, "prerelease": true, "body": "changes" }`, string(b))
+			fmt.Fprint(w, `{ "id": 3, "name": "0.2.0", "tag_name": "v0.2.0", "target_commitish": "main", "draft": false, "prerelease": true, "body": "changes", "html_url": "https://gitea.example.com/moorara/cherry/releases/tag/v0.2.0" }`)
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL)
+		}
+	})
+	defer close()
+
+	ctx := context.Background()
+
+	release, err := g.CreateDraft(ctx, Release{
+		Name:       "0.2.0",
+		TagName:    "v0.2.0",
+		Target:     "main",
+		Prerelease: true,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, Release{
+		ID:         "3",
+		Name:       "0.2.0",
+		TagName:    "v0.2.0",
+		Target:     "main",
+		Draft:      true,
+		Prerelease: true,
+		URL:        "https://gitea.example.com/moorara/cherry/releases/tag/v0.2.0",
+	}, release)
+
+	dir, err := ioutil.TempDir("", "cherry-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "cherry-linux-amd64")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("binary"), 0755))
+
+	asset, err := g.UploadAsset(ctx, release, path)
+	assert.NoError(t, err)
+	assert.Equal(t, Asset{
+		ID:   "5",
+		Name: "cherry-linux-amd64",
+		URL:  "https://gitea.example.com/attachments/5",
+	}, asset)
+
+	release.Assets = []Asset{asset}
+	release.Body = "changes"
+	release, err = g.Publish(ctx, release)
+	assert.NoError(t, err)
+	assert.Equal(t, Release{
+		ID:         "3",
+		Name:       "0.2.0",
+		TagName:    "v0.2.0",
+		Target:     "main",
+		Draft:      false,
+		Prerelease: true,
+		Body:       "changes",
+		URL:        "https://gitea.example.com/moorara/cherry/releases/tag/v0.2.0",
+		Assets:     []Asset{asset},
+	}, release)
+}
+
+func TestGiteaDelete(t *testing.T) {
+	var requests []string
+
+	g, close := newGitea(t, func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		w.WriteHeader(204)
+	})
+	defer close()
+
+	ctx := context.Background()
+	release := Release{ID: "3", Name: "0.2.0", TagName: "v0.2.0", Draft: true}
+
+	assert.NoError(t, g.DeleteAsset(ctx, release, Asset{ID: "5", Name: "cherry-linux-amd64"}))
+	assert.NoError(t, g.DeleteRelease(ctx, release))
+	assert.Equal(t, []string{
+		"DELETE /api/v1/repos/moorara/cherry/releases/3/assets/5",
+		"DELETE /api/v1/repos/moorara/cherry/releases/3",
+	}, requests)
+}
+
+func TestGiteaProtectBranch(t *testing.T) {
+	var requests []string
+
+	g, close := newGitea(t, func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/api/v1/repos/moorara/cherry/branch_protections/main":
+			fmt.Fprint(w, `{ "branch_name": "main", "enable_push": false, "required_approvals": 1, "created_at": "2020-08-20T16:30:00Z", "updated_at": "2020-08-20T16:30:00Z" }`)
+		case r.Method == "GET":
+			w.WriteHeader(404)
+		case r.Method == "DELETE":
+			w.WriteHeader(204)
+		case r.Method == "POST":
+			b, _ := ioutil.ReadAll(r.Body)
+			assert.JSONEq(t, `{ "branch_name": "main", "enable_push": false, "required_approvals": 1 }`, string(b))
+			w.WriteHeader(201)
+			fmt.Fprint(w, `{}`)
+		}
+	})
+	defer close()
+
+	ctx := context.Background()
+
+	// Branches not protected are left untouched
+	assert.NoError(t, g.ProtectBranch(ctx, "develop", false))
+	assert.NoError(t, g.ProtectBranch(ctx, "develop", true))
+
+	assert.NoError(t, g.ProtectBranch(ctx, "main", false))
+	assert.NoError(t, g.ProtectBranch(ctx, "main", true))
+	assert.Equal(t, []string{
+		"GET /api/v1/repos/moorara/cherry/branch_protections/develop",
+		"GET /api/v1/repos/moorara/cherry/branch_protections/main",
+		"DELETE /api/v1/repos/moorara/cherry/branch_protections/main",
+		"POST /api/v1/repos/moorara/cherry/branch_protections",
+	}, requests)
+
+	t.Run("SavedProtections", func(t *testing.T) {
+		assert.NoError(t, g.ProtectBranch(ctx, "main", false))
+		data, err := g.SaveProtections()
+		assert.NoError(t, err)
+
+		// Another run protects the branch again with the saved options
+		other := &Gitea{Client: g.Client, APIURL: g.APIURL, Token: g.Token, Owner: g.Owner, Repo: g.Repo}
+		assert.NoError(t, other.LoadProtections(data))
+		assert.NoError(t, other.ProtectBranch(ctx, "main", true))
+
+		data, err = other.SaveProtections()
+		assert.NoError(t, err)
+		assert.Nil(t, data)
+		assert.Equal(t, "POST /api/v1/repos/moorara/cherry/branch_protections", requests[len(requests)-1])
+	})
+}
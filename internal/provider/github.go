@@ -0,0 +1,286 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	netURL "net/url"
+)
+
+const defaultGitHubAPIURL = "https://api.github.com"
+
+// Example: https://uploads.github.com/repos/octocat/Hello-World/releases/1/assets{?name,label} --> {?name,label}
+var uploadURLTemplateRE = regexp.MustCompile(`\{\?[0-9A-Za-z_,]+\}`)
+
+// GitHub implements Provider for GitHub using GitHub REST API v3.
+// See https://docs.github.com/en/rest
+type GitHub struct {
+	Client *http.Client
+	// APIURL is the base URL of GitHub API (default: https://api.github.com).
+	APIURL string
+	// UploadURL is the base URL for uploading release assets (default: the upload URL returned for the release).
+	UploadURL string
+	Token     string
+	Owner     string
+	Repo      string
+
+	// unprotected keeps the branches the protection is not enforced for administrators anymore for enforcing it again later.
+	unprotected map[string]bool
+}
+
+type githubRelease struct {
+	ID         int    `json:"id"`
+	Name       string `json:"name"`
+	TagName    string `json:"tag_name"`
+	Target     string `json:"target_commitish"`
+	Draft      bool   `json:"draft"`
+	Prerelease bool   `json:"prerelease"`
+	Body       string `json:"body"`
+	HTMLURL    string `json:"html_url"`
+	UploadURL  string `json:"upload_url"`
+}
+
+func (r githubRelease) release() Release {
+	return Release{
+		ID:         strconv.Itoa(r.ID),
+		Name:       r.Name,
+		TagName:    r.TagName,
+		Target:     r.Target,
+		Draft:      r.Draft,
+		Prerelease: r.Prerelease,
+		Body:       r.Body,
+		URL:        r.HTMLURL,
+		UploadURL:  r.UploadURL,
+	}
+}
+
+func (g *GitHub) url(format string, a ...interface{}) string {
+	apiURL := g.APIURL
+	if apiURL == "" {
+		apiURL = defaultGitHubAPIURL
+	}
+
+	return strings.TrimRight(apiURL, "/") + fmt.Sprintf(format, a...)
+}
+
+func (g *GitHub) request(ctx context.Context, method, url string, body io.Reader) *http.Request {
+	req, _ := http.NewRequest(method, url, body)
+	req = req.WithContext(ctx)
+	req.Header.Set("Authorization", "token "+g.Token)
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	req.Header.Set("User-Agent", "cherry") // ref: https://docs.github.com/en/rest/overview/resources-in-the-rest-api#user-agent-required
+	req.Header.Set("Content-Type", "application/json")
+
+	return req
+}
+
+// Name returns the name of the provider.
+func (g *GitHub) Name() string {
+	return "GitHub"
+}
+
+// CheckPermission checks if the user has admin permission for creating releases and pushing tags.
+func (g *GitHub) CheckPermission(ctx context.Context) error {
+	// Get the currently authenticated user
+	// See https://docs.github.com/en/rest/reference/users#get-the-authenticated-user
+	user := struct {
+		Login string `json:"login"`
+	}{}
+
+	req := g.request(ctx, "GET", g.url("/user"), nil)
+	if err := send(g.Client, req, 200, &user); err != nil {
+		return err
+	}
+
+	// See https://docs.github.com/en/rest/reference/repos#get-repository-permissions-for-a-user
+	permission := struct {
+		Permission string `json:"permission"`
+	}{}
+
+	req = g.request(ctx, "GET", g.url("/repos/%s/%s/collaborators/%s/permission", g.Owner, g.Repo, user.Login), nil)
+	if err := send(g.Client, req, 200, &permission); err != nil {
+		return err
+	}
+
+	if permission.Permission != "admin" {
+		return fmt.Errorf("%w: %s has %s permission", ErrPermission, user.Login, permission.Permission)
+	}
+
+	return nil
+}
+
+// CreateDraft creates a draft GitHub release.
+// See https://docs.github.com/en/rest/reference/repos#create-a-release
+func (g *GitHub) CreateDraft(ctx context.Context, release Release) (Release, error) {
+	return g.editRelease(ctx, "POST", g.url("/repos/%s/%s/releases", g.Owner, g.Repo), 201, release, true)
+}
+
+// UploadAsset uploads a file to a GitHub release.
+// See https://docs.github.com/en/rest/reference/repos#upload-a-release-asset
+func (g *GitHub) UploadAsset(ctx context.Context, release Release, path string) (Asset, error) {
+	f, name, size, mimeType, err := openAsset(path)
+	if err != nil {
+		return Asset{}, err
+	}
+	defer f.Close()
+
+	url := uploadURLTemplateRE.ReplaceAllLiteralString(release.UploadURL, "")
+	if g.UploadURL != "" {
+		url = fmt.Sprintf("%s/repos/%s/%s/releases/%s/assets", strings.TrimRight(g.UploadURL, "/"), g.Owner, g.Repo, release.ID)
+	}
+	url = fmt.Sprintf("%s?name=%s", url, netURL.QueryEscape(name))
+	req := g.request(ctx, "POST", url, f)
+	req.Header.Set("Content-Type", mimeType)
+	req.ContentLength = size
+
+	asset := struct {
+		ID          int    `json:"id"`
+		Name        string `json:"name"`
+		DownloadURL string `json:"browser_download_url"`
+	}{}
+
+	if err := send(g.Client, req, 201, &asset); err != nil {
+		return Asset{}, err
+	}
+
+	return Asset{
+		ID:   strconv.Itoa(asset.ID),
+		Name: asset.Name,
+		URL:  asset.DownloadURL,
+	}, nil
+}
+
+// Publish publishes a draft GitHub release.
+// See https://docs.github.com/en/rest/reference/repos#update-a-release
+func (g *GitHub) Publish(ctx context.Context, release Release) (Release, error) {
+	return g.editRelease(ctx, "PATCH", g.url("/repos/%s/%s/releases/%s", g.Owner, g.Repo, release.ID), 200, release, false)
+}
+
+func (g *GitHub) editRelease(ctx context.Context, method, url string, expectedStatusCode int, release Release, draft bool) (Release, error) {
+	// A maintenance release should not become the latest release
+	var makeLatest string
+	if release.Maintenance {
+		makeLatest = "false"
+	}
+
+	body := new(bytes.Buffer)
+	_ = json.NewEncoder(body).Encode(struct {
+		Name       string `json:"name"`
+		TagName    string `json:"tag_name"`
+		Target     string `json:"target_commitish"`
+		Draft      bool   `json:"draft"`
+		Prerelease bool   `json:"prerelease"`
+		MakeLatest string `json:"make_latest,omitempty"`
+		Body       string `json:"body"`
+	}{
+		Name:       release.Name,
+		TagName:    release.TagName,
+		Target:     release.Target,
+		Draft:      draft,
+		Prerelease: release.Prerelease,
+		MakeLatest: makeLatest,
+		Body:       release.Body,
+	})
+
+	out := githubRelease{}
+	req := g.request(ctx, method, url, body)
+	if err := send(g.Client, req, expectedStatusCode, &out); err != nil {
+		return Release{}, err
+	}
+
+	r := out.release()
+	r.Maintenance = release.Maintenance
+	r.Assets = release.Assets
+
+	return r, nil
+}
+
+// ProtectBranch stops enforcing the branch protection for administrators or enforces it again if it was enforced before.
+// Branches that are not protected or do not enforce the protection for administrators are left untouched.
+// See https://docs.github.com/en/rest/reference/repos#get-branch-protection
+// See https://docs.github.com/en/rest/reference/repos#set-admin-branch-protection
+// See https://docs.github.com/en/rest/reference/repos#delete-admin-branch-protection
+func (g *GitHub) ProtectBranch(ctx context.Context, branch string, protect bool) error {
+	if g.unprotected == nil {
+		g.unprotected = map[string]bool{}
+	}
+
+	url := g.url("/repos/%s/%s/branches/%s/protection/enforce_admins", g.Owner, g.Repo, branch)
+
+	if protect {
+		if !g.unprotected[branch] {
+			// The protection was not enforced for administrators before
+			return nil
+		}
+
+		if err := send(g.Client, g.request(ctx, "POST", url, nil), 200, nil); err != nil {
+			return err
+		}
+
+		delete(g.unprotected, branch)
+		return nil
+	}
+
+	protection := struct {
+		EnforceAdmins struct {
+			Enabled bool `json:"enabled"`
+		} `json:"enforce_admins"`
+	}{}
+
+	req := g.request(ctx, "GET", g.url("/repos/%s/%s/branches/%s/protection", g.Owner, g.Repo, branch), nil)
+	if err := send(g.Client, req, 200, &protection); err != nil {
+		if serr := new(statusError); errors.As(err, &serr) && serr.StatusCode == 404 {
+			// The branch is not protected
+			return nil
+		}
+		return err
+	}
+
+	if !protection.EnforceAdmins.Enabled {
+		return nil
+	}
+
+	if err := send(g.Client, g.request(ctx, "DELETE", url, nil), 204, nil); err != nil {
+		return err
+	}
+
+	g.unprotected[branch] = true
+
+	return nil
+}
+
+// SaveProtections returns the branches the protection is not enforced for administrators anymore.
+func (g *GitHub) SaveProtections() (json.RawMessage, error) {
+	if len(g.unprotected) == 0 {
+		return nil, nil
+	}
+
+	return json.Marshal(g.unprotected)
+}
+
+// LoadProtections loads the branches the protection was not enforced for administrators anymore for enforcing it again.
+func (g *GitHub) LoadProtections(data json.RawMessage) error {
+	return json.Unmarshal(data, &g.unprotected)
+}
+
+// DeleteRelease deletes a GitHub release.
+// See https://docs.github.com/en/rest/reference/repos#delete-a-release
+func (g *GitHub) DeleteRelease(ctx context.Context, release Release) error {
+	req := g.request(ctx, "DELETE", g.url("/repos/%s/%s/releases/%s", g.Owner, g.Repo, release.ID), nil)
+	return send(g.Client, req, 204, nil)
+}
+
+// DeleteAsset deletes an asset of a GitHub release.
+// See https://docs.github.com/en/rest/reference/repos#delete-a-release-asset
+func (g *GitHub) DeleteAsset(ctx context.Context, release Release, asset Asset) error {
+	req := g.request(ctx, "DELETE", g.url("/repos/%s/%s/releases/assets/%s", g.Owner, g.Repo, asset.ID), nil)
+	return send(g.Client, req, 204, nil)
+}
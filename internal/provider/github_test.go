@@ -0,0 +1,322 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newGitHub(t *testing.T, handler http.HandlerFunc) (*GitHub, func()) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "token github-token", r.Header.Get("Authorization"))
+		assert.Equal(t, "cherry", r.Header.Get("User-Agent"))
+		handler(w, r)
+	}))
+
+	g := &GitHub{
+		Client: ts.Client(),
+		APIURL: ts.URL,
+		Token:  "github-token",
+		Owner:  "moorara",
+		Repo:   "cherry",
+	}
+
+	return g, ts.Close
+}
+
+func TestGitHubCheckPermission(t *testing.T) {
+	tests := []struct {
+		name          string
+		permission    string
+		statusCode    int
+		expectedError string
+	}{
+		{
+			name:       "Admin",
+			permission: "admin",
+			statusCode: 200,
+		},
+		{
+			name:          "Write",
+			permission:    "write",
+			statusCode:    200,
+			expectedError: "insufficient permission for releasing: octocat has write permission",
+		},
+		{
+			name:          "NotFound",
+			statusCode:    404,
+			expectedError: "/repos/moorara/cherry/collaborators/octocat/permission: invalid status code 404",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g, close := newGitHub(t, func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/user":
+					fmt.Fprint(w, `{ "id": 1, "login": "octocat" }`)
+				case "/repos/moorara/cherry/collaborators/octocat/permission":
+					w.WriteHeader(tc.statusCode)
+					fmt.Fprintf(w, `{ "permission": "%s" }`, tc.permission)
+				default:
+					t.Errorf("unexpected request: %s %s", r.Method, r.URL)
+				}
+			})
+			defer close()
+
+			err := g.CheckPermission(context.Background())
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestGitHubRelease(t *testing.T) {
+	var uploadURL string
+
+	g, close := newGitHub(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/repos/moorara/cherry/releases":
+			b, _ := ioutil.ReadAll(r.Body)
+			assert.JSONEq(t, `{ "name": "0.2.0", "tag_name": "v0.2.0", "target_commitish": "main", "draft": true, "prerelease": false, "body": "changes" }`, string(b))
+			w.WriteHeader(201)
+			fmt.Fprintf(w, `{ "id": 1, "name": "0.2.0", "tag_name": "v0.2.0", "target_commitish": "main", "draft": true, "body": "changes", "html_url": "https://github.com/moorara/cherry/releases/v0.2.0", "upload_url": "%s{?name,label}" }`, uploadURL)
+		case r.Method == "POST" && r.URL.Path == "/uploads/1/assets":
+			assert.Equal(t, "cherry-linux-amd64", r.URL.Query().Get("name"))
+			b, _ := ioutil.ReadAll(r.Body)
+			assert.Equal(t, "binary", string(b))
+			w.WriteHeader(201)
+			fmt.Fprint(w, `{ "id": 2, "name": "cherry-linux-amd64", "browser_download_url": "https://github.com/moorara/cherry/releases/download/v0.2.0/cherry-linux-amd64" }`)
+		case r.Method == "PATCH" && r.URL.Path == "/repos/moorara/cherry/releases/1":
+			b, _ := ioutil.ReadAll(r.Body)
+			assert.JSONEq(t, `{ "name": "0.2.0", "tag_name": "v0.2.0", "target_commitish": "main", "draft": false, "prerelease": false, "body": "changes" }`, string(b))
+			fmt.Fprint(w, `{ "id": 1, "name": "0.2.0", "tag_name": "v0.2.0", "target_commitish": "main", "draft": false, "body": "changes", "html_url": "https://github.com/moorara/cherry/releases/v0.2.0" }`)
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL)
+		}
+	})
+	defer close()
+
+	uploadURL = g.APIURL + "/uploads/1/assets"
+
+	ctx := context.Background()
+
+	release, err := g.CreateDraft(ctx, Release{
+		Name:    "0.2.0",
+		TagName: "v0.2.0",
+		Target:  "main",
+		Body:    "changes",
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, Release{
+		ID:        "1",
+		Name:      "0.2.0",
+		TagName:   "v0.2.0",
+		Target:    "main",
+		Draft:     true,
+		Body:      "changes",
+		URL:       "https://github.com/moorara/cherry/releases/v0.2.0",
+		UploadURL: uploadURL + "{?name,label}",
+	}, release)
+
+	dir, err := ioutil.TempDir("", "cherry-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "cherry-linux-amd64")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("binary"), 0755))
+
+	asset, err := g.UploadAsset(ctx, release, path)
+	assert.NoError(t, err)
+	assert.Equal(t, Asset{
+		ID:   "2",
+		Name: "cherry-linux-amd64",
+		URL:  "https://github.com/moorara/cherry/releases/download/v0.2.0/cherry-linux-amd64",
+	}, asset)
+
+	release.Assets = []Asset{asset}
+	release, err = g.Publish(ctx, release)
+	assert.NoError(t, err)
+	assert.Equal(t, Release{
+		ID:      "1",
+		Name:    "0.2.0",
+		TagName: "v0.2.0",
+		Target:  "main",
+		Draft:   false,
+		Body:    "changes",
+		URL:     "https://github.com/moorara/cherry/releases/v0.2.0",
+		Assets:  []Asset{asset},
+	}, release)
+}
+
+func TestGitHubProtectBranch(t *testing.T) {
+	var requests []string
+	enforced := true
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/repos/moorara/cherry/branches/main/protection":
+			fmt.Fprintf(w, `{ "enforce_admins": { "enabled": %t } }`, enforced)
+		case r.Method == "GET" && r.URL.Path == "/repos/moorara/cherry/branches/staging/protection":
+			fmt.Fprint(w, `{ "enforce_admins": { "enabled": false } }`)
+		case r.Method == "GET":
+			w.WriteHeader(404)
+			fmt.Fprint(w, `{ "message": "Branch not protected" }`)
+		case r.Method == "DELETE":
+			enforced = false
+			w.WriteHeader(204)
+		case r.Method == "POST":
+			enforced = true
+			w.WriteHeader(200)
+		}
+	}
+
+	g, close := newGitHub(t, handler)
+	defer close()
+
+	ctx := context.Background()
+
+	// Branches not protected or not enforcing the protection for administrators are left untouched
+	assert.NoError(t, g.ProtectBranch(ctx, "release/1.4", false))
+	assert.NoError(t, g.ProtectBranch(ctx, "release/1.4", true))
+	assert.NoError(t, g.ProtectBranch(ctx, "staging", false))
+	assert.NoError(t, g.ProtectBranch(ctx, "staging", true))
+
+	assert.NoError(t, g.ProtectBranch(ctx, "main", false))
+	assert.NoError(t, g.ProtectBranch(ctx, "main", true))
+	assert.Equal(t, []string{
+		"GET /repos/moorara/cherry/branches/release/1.4/protection",
+		"GET /repos/moorara/cherry/branches/staging/protection",
+		"GET /repos/moorara/cherry/branches/main/protection",
+		"DELETE /repos/moorara/cherry/branches/main/protection/enforce_admins",
+		"POST /repos/moorara/cherry/branches/main/protection/enforce_admins",
+	}, requests)
+
+	t.Run("SavedProtections", func(t *testing.T) {
+		requests = nil
+
+		assert.NoError(t, g.ProtectBranch(ctx, "main", false))
+		data, err := g.SaveProtections()
+		assert.NoError(t, err)
+
+		// Another run finds the protection not enforced and enforces it again
+		other, close := newGitHub(t, handler)
+		defer close()
+
+		assert.NoError(t, other.LoadProtections(data))
+		assert.NoError(t, other.ProtectBranch(ctx, "main", false))
+		assert.NoError(t, other.ProtectBranch(ctx, "main", true))
+
+		data, err = other.SaveProtections()
+		assert.NoError(t, err)
+		assert.Nil(t, data)
+
+		assert.Equal(t, []string{
+			"GET /repos/moorara/cherry/branches/main/protection",
+			"DELETE /repos/moorara/cherry/branches/main/protection/enforce_admins",
+			"GET /repos/moorara/cherry/branches/main/protection",
+			"POST /repos/moorara/cherry/branches/main/protection/enforce_admins",
+		}, requests)
+	})
+}
+
+func TestGitHubMaintenanceRelease(t *testing.T) {
+	g, close := newGitHub(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "PATCH" && r.URL.Path == "/repos/moorara/cherry/releases/1":
+			b, _ := ioutil.ReadAll(r.Body)
+			assert.JSONEq(t, `{ "name": "1.4.7", "tag_name": "v1.4.7", "target_commitish": "release/1.4", "draft": false, "prerelease": false, "make_latest": "false", "body": "changes" }`, string(b))
+			fmt.Fprint(w, `{ "id": 1, "name": "1.4.7", "tag_name": "v1.4.7", "target_commitish": "release/1.4", "draft": false, "body": "changes", "html_url": "https://github.com/moorara/cherry/releases/v1.4.7" }`)
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL)
+		}
+	})
+	defer close()
+
+	release, err := g.Publish(context.Background(), Release{
+		ID:          "1",
+		Name:        "1.4.7",
+		TagName:     "v1.4.7",
+		Target:      "release/1.4",
+		Draft:       true,
+		Maintenance: true,
+		Body:        "changes",
+	})
+
+	assert.NoError(t, err)
+	assert.True(t, release.Maintenance)
+	assert.False(t, release.Draft)
+}
+
+func TestGitHubDelete(t *testing.T) {
+	var requests []string
+
+	g, close := newGitHub(t, func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		w.WriteHeader(204)
+	})
+	defer close()
+
+	ctx := context.Background()
+	release := Release{ID: "1", Name: "0.2.0", TagName: "v0.2.0", Draft: true}
+
+	assert.NoError(t, g.DeleteAsset(ctx, release, Asset{ID: "2", Name: "cherry-linux-amd64"}))
+	assert.NoError(t, g.DeleteRelease(ctx, release))
+	assert.Equal(t, []string{
+		"DELETE /repos/moorara/cherry/releases/assets/2",
+		"DELETE /repos/moorara/cherry/releases/1",
+	}, requests)
+}
+
+func TestGitHubUploadAssetWithUploadURL(t *testing.T) {
+	g, close := newGitHub(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/api/uploads/repos/moorara/cherry/releases/1/assets":
+			assert.Equal(t, "cherry-linux-amd64", r.URL.Query().Get("name"))
+			w.WriteHeader(201)
+			fmt.Fprint(w, `{ "id": 2, "name": "cherry-linux-amd64", "browser_download_url": "https://github.example.com/moorara/cherry/releases/download/v0.2.0/cherry-linux-amd64" }`)
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL)
+		}
+	})
+	defer close()
+
+	g.UploadURL = g.APIURL + "/api/uploads"
+	g.APIURL += "/api/v3"
+
+	dir, err := ioutil.TempDir("", "cherry-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "cherry-linux-amd64")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("binary"), 0755))
+
+	release := Release{
+		ID:        "1",
+		TagName:   "v0.2.0",
+		UploadURL: "https://uploads.github.example.com/repos/moorara/cherry/releases/1/assets{?name,label}",
+	}
+
+	asset, err := g.UploadAsset(context.Background(), release, path)
+	assert.NoError(t, err)
+	assert.Equal(t, Asset{
+		ID:   "2",
+		Name: "cherry-linux-amd64",
+		URL:  "https://github.example.com/moorara/cherry/releases/download/v0.2.0/cherry-linux-amd64",
+	}, asset)
+}
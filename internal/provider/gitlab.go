@@ -0,0 +1,300 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	netURL "net/url"
+)
+
+const (
+	defaultGitLabAPIURL = "https://gitlab.com/api/v4"
+
+	// gitlabMaintainer is the minimum access level for managing releases and protected branches.
+	// See https://docs.gitlab.com/ee/api/members.html#valid-access-levels
+	gitlabMaintainer = 40
+)
+
+// GitLab implements Provider for GitLab using GitLab REST API v4.
+// GitLab does not have draft releases, so a release is only created when it is published.
+// Assets are uploaded to the generic package registry of the project and linked to the release.
+// See https://docs.gitlab.com/ee/api/README.html
+type GitLab struct {
+	Client *http.Client
+	// APIURL is the base URL of GitLab API (default: https://gitlab.com/api/v4).
+	APIURL string
+	Token  string
+	Owner  string
+	Repo   string
+
+	// unprotected keeps the access levels of the branches unprotected for restoring them later.
+	unprotected map[string]gitlabProtectedBranch
+}
+
+type gitlabAccessLevel struct {
+	AccessLevel int `json:"access_level"`
+}
+
+type gitlabProtectedBranch struct {
+	Name              string              `json:"name"`
+	PushAccessLevels  []gitlabAccessLevel `json:"push_access_levels"`
+	MergeAccessLevels []gitlabAccessLevel `json:"merge_access_levels"`
+}
+
+func (g *GitLab) url(format string, a ...interface{}) string {
+	apiURL := g.APIURL
+	if apiURL == "" {
+		apiURL = defaultGitLabAPIURL
+	}
+
+	// The project can be identified by its URL-encoded path (i.e. moorara%2Fcherry)
+	project := netURL.PathEscape(g.Owner + "/" + g.Repo)
+
+	return strings.TrimRight(apiURL, "/") + strings.Replace(fmt.Sprintf(format, a...), ":id", project, 1)
+}
+
+func (g *GitLab) request(ctx context.Context, method, url string, body io.Reader) *http.Request {
+	req, _ := http.NewRequest(method, url, body)
+	req = req.WithContext(ctx)
+	req.Header.Set("PRIVATE-TOKEN", g.Token)
+	req.Header.Set("User-Agent", "cherry")
+	req.Header.Set("Content-Type", "application/json")
+
+	return req
+}
+
+// Name returns the name of the provider.
+func (g *GitLab) Name() string {
+	return "GitLab"
+}
+
+// CheckPermission checks if the user has at least maintainer access level for creating releases and pushing tags.
+func (g *GitLab) CheckPermission(ctx context.Context) error {
+	// See https://docs.gitlab.com/ee/api/users.html#for-normal-users-1
+	user := struct {
+		ID       int    `json:"id"`
+		Username string `json:"username"`
+	}{}
+
+	req := g.request(ctx, "GET", g.url("/user"), nil)
+	if err := send(g.Client, req, 200, &user); err != nil {
+		return err
+	}
+
+	// See https://docs.gitlab.com/ee/api/members.html#get-a-member-of-a-group-or-project-including-inherited-and-invited-members
+	member := gitlabAccessLevel{}
+
+	req = g.request(ctx, "GET", g.url("/projects/:id/members/all/%d", user.ID), nil)
+	if err := send(g.Client, req, 200, &member); err != nil {
+		return err
+	}
+
+	if member.AccessLevel < gitlabMaintainer {
+		return fmt.Errorf("%w: %s has access level %d", ErrPermission, user.Username, member.AccessLevel)
+	}
+
+	return nil
+}
+
+// CreateDraft prepares a release without creating it, since GitLab does not support draft releases.
+func (g *GitLab) CreateDraft(ctx context.Context, release Release) (Release, error) {
+	release.ID = release.TagName
+	release.Draft = true
+
+	return release, nil
+}
+
+// UploadAsset uploads a file to the generic package registry of the project.
+// The package is named after the repository and versioned by the release tag.
+// See https://docs.gitlab.com/ee/user/packages/generic_packages
+func (g *GitLab) UploadAsset(ctx context.Context, release Release, path string) (Asset, error) {
+	f, name, size, _, err := openAsset(path)
+	if err != nil {
+		return Asset{}, err
+	}
+	defer f.Close()
+
+	url := g.url("/projects/:id/packages/generic/%s/%s/%s", netURL.PathEscape(g.Repo), netURL.PathEscape(release.TagName), netURL.PathEscape(name))
+	req := g.request(ctx, "PUT", url+"?select=package_file", f)
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.ContentLength = size
+
+	file := struct {
+		ID        int `json:"id"`
+		PackageID int `json:"package_id"`
+	}{}
+
+	if err := send(g.Client, req, 201, &file); err != nil {
+		return Asset{}, err
+	}
+
+	return Asset{
+		// A package file is identified by the package and the file IDs
+		ID:   fmt.Sprintf("%d/%d", file.PackageID, file.ID),
+		Name: name,
+		URL:  url,
+	}, nil
+}
+
+// Publish creates a GitLab release for an existing tag and links the uploaded assets to it.
+// See https://docs.gitlab.com/ee/api/releases/#create-a-release
+func (g *GitLab) Publish(ctx context.Context, release Release) (Release, error) {
+	type link struct {
+		Name     string `json:"name"`
+		URL      string `json:"url"`
+		LinkType string `json:"link_type"`
+	}
+
+	in := struct {
+		Name        string `json:"name"`
+		TagName     string `json:"tag_name"`
+		Description string `json:"description"`
+		Assets      struct {
+			Links []link `json:"links"`
+		} `json:"assets"`
+	}{
+		Name:        release.Name,
+		TagName:     release.TagName,
+		Description: release.Body,
+	}
+
+	in.Assets.Links = []link{}
+	for _, asset := range release.Assets {
+		in.Assets.Links = append(in.Assets.Links, link{
+			Name:     asset.Name,
+			URL:      asset.URL,
+			LinkType: "package",
+		})
+	}
+
+	body := new(bytes.Buffer)
+	_ = json.NewEncoder(body).Encode(in)
+
+	out := struct {
+		Name    string `json:"name"`
+		TagName string `json:"tag_name"`
+		Links   struct {
+			Self string `json:"self"`
+		} `json:"_links"`
+	}{}
+
+	req := g.request(ctx, "POST", g.url("/projects/:id/releases"), body)
+	if err := send(g.Client, req, 201, &out); err != nil {
+		return Release{}, err
+	}
+
+	release.ID = out.TagName
+	release.Name = out.Name
+	release.TagName = out.TagName
+	release.Draft = false
+	release.URL = out.Links.Self
+
+	return release, nil
+}
+
+// DeleteRelease deletes a GitLab release.
+// Nothing is deleted if the release is not published yet, since it is not created until then.
+// See https://docs.gitlab.com/ee/api/releases/#delete-a-release
+func (g *GitLab) DeleteRelease(ctx context.Context, release Release) error {
+	if release.Draft {
+		return nil
+	}
+
+	req := g.request(ctx, "DELETE", g.url("/projects/:id/releases/%s", netURL.PathEscape(release.TagName)), nil)
+	return send(g.Client, req, 200, nil)
+}
+
+// DeleteAsset deletes a file uploaded to the generic package registry of the project.
+// See https://docs.gitlab.com/ee/api/packages.html#delete-a-package-file
+func (g *GitLab) DeleteAsset(ctx context.Context, release Release, asset Asset) error {
+	// Example: 4/25 --> ids = []string{"4", "25"}
+	ids := strings.SplitN(asset.ID, "/", 2)
+	if len(ids) != 2 {
+		return fmt.Errorf("invalid package file id: %s", asset.ID)
+	}
+
+	req := g.request(ctx, "DELETE", g.url("/projects/:id/packages/%s/package_files/%s", ids[0], ids[1]), nil)
+	return send(g.Client, req, 204, nil)
+}
+
+// ProtectBranch unprotects a protected branch or protects it again with the same access levels it had before.
+// See https://docs.gitlab.com/ee/api/protected_branches.html
+func (g *GitLab) ProtectBranch(ctx context.Context, branch string, protect bool) error {
+	if g.unprotected == nil {
+		g.unprotected = map[string]gitlabProtectedBranch{}
+	}
+
+	if protect {
+		pb, ok := g.unprotected[branch]
+		if !ok {
+			// The branch was not protected before
+			return nil
+		}
+
+		query := netURL.Values{}
+		query.Set("name", branch)
+		query.Set("push_access_level", fmt.Sprint(gitlabAccessLevelOf(pb.PushAccessLevels)))
+		query.Set("merge_access_level", fmt.Sprint(gitlabAccessLevelOf(pb.MergeAccessLevels)))
+
+		req := g.request(ctx, "POST", g.url("/projects/:id/protected_branches?%s", query.Encode()), nil)
+		if err := send(g.Client, req, 201, nil); err != nil {
+			return err
+		}
+
+		delete(g.unprotected, branch)
+		return nil
+	}
+
+	pb := gitlabProtectedBranch{}
+	url := g.url("/projects/:id/protected_branches/%s", netURL.PathEscape(branch))
+
+	req := g.request(ctx, "GET", url, nil)
+	if err := send(g.Client, req, 200, &pb); err != nil {
+		if serr := new(statusError); errors.As(err, &serr) && serr.StatusCode == 404 {
+			// The branch is not protected
+			return nil
+		}
+		return err
+	}
+
+	req = g.request(ctx, "DELETE", url, nil)
+	if err := send(g.Client, req, 204, nil); err != nil {
+		return err
+	}
+
+	g.unprotected[branch] = pb
+
+	return nil
+}
+
+// SaveProtections returns the access levels of the branches unprotected and not protected again yet.
+func (g *GitLab) SaveProtections() (json.RawMessage, error) {
+	if len(g.unprotected) == 0 {
+		return nil, nil
+	}
+
+	return json.Marshal(g.unprotected)
+}
+
+// LoadProtections loads the access levels of the branches unprotected before for protecting them again.
+func (g *GitLab) LoadProtections(data json.RawMessage) error {
+	return json.Unmarshal(data, &g.unprotected)
+}
+
+// gitlabAccessLevelOf returns the lowest access level allowed (default: maintainer).
+func gitlabAccessLevelOf(levels []gitlabAccessLevel) int {
+	level := gitlabMaintainer
+	for i, l := range levels {
+		if i == 0 || l.AccessLevel < level {
+			level = l.AccessLevel
+		}
+	}
+
+	return level
+}
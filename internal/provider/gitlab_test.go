@@ -0,0 +1,271 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newGitLab(t *testing.T, handler http.HandlerFunc) (*GitLab, func()) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "gitlab-token", r.Header.Get("PRIVATE-TOKEN"))
+		handler(w, r)
+	}))
+
+	g := &GitLab{
+		Client: ts.Client(),
+		APIURL: ts.URL + "/api/v4",
+		Token:  "gitlab-token",
+		Owner:  "moorara",
+		Repo:   "cherry",
+	}
+
+	return g, ts.Close
+}
+
+func TestGitLabCheckPermission(t *testing.T) {
+	tests := []struct {
+		name          string
+		accessLevel   int
+		expectedError string
+	}{
+		{
+			name:        "Maintainer",
+			accessLevel: 40,
+		},
+		{
+			name:        "Owner",
+			accessLevel: 50,
+		},
+		{
+			name:          "Developer",
+			accessLevel:   30,
+			expectedError: "insufficient permission for releasing: octocat has access level 30",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g, close := newGitLab(t, func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.EscapedPath() {
+				case "/api/v4/user":
+					fmt.Fprint(w, `{ "id": 7, "username": "octocat" }`)
+				case "/api/v4/projects/moorara%2Fcherry/members/all/7":
+					fmt.Fprintf(w, `{ "id": 7, "username": "octocat", "access_level": %d }`, tc.accessLevel)
+				default:
+					t.Errorf("unexpected request: %s %s", r.Method, r.URL)
+				}
+			})
+			defer close()
+
+			err := g.CheckPermission(context.Background())
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestGitLabRelease(t *testing.T) {
+	var requests []string
+
+	g, close := newGitLab(t, func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.EscapedPath())
+
+		switch {
+		case r.Method == "PUT" && r.URL.EscapedPath() == "/api/v4/projects/moorara%2Fcherry/packages/generic/cherry/v0.2.0/cherry-linux-amd64":
+			assert.Equal(t, "package_file", r.URL.Query().Get("select"))
+			b, _ := ioutil.ReadAll(r.Body)
+			assert.Equal(t, "binary", string(b))
+			w.WriteHeader(201)
+			fmt.Fprint(w, `{ "id": 25, "package_id": 4, "file_name": "cherry-linux-amd64" }`)
+		case r.Method == "POST" && r.URL.EscapedPath() == "/api/v4/projects/moorara%2Fcherry/releases":
+			b, _ := ioutil.ReadAll(r.Body)
+			assert.JSONEq(t, fmt.Sprintf(`{
+				"name": "0.2.0",
+				"tag_name": "v0.2.0",
+				"description": "changes",
+				"assets": {
+					"links": [
+						{ "name": "cherry-linux-amd64", "url": "%s/api/v4/projects/moorara%%2Fcherry/packages/generic/cherry/v0.2.0/cherry-linux-amd64", "link_type": "package" }
+					]
+				}
+			}`, "http://"+r.Host), string(b))
+			w.WriteHeader(201)
+			fmt.Fprint(w, `{ "name": "0.2.0", "tag_name": "v0.2.0", "_links": { "self": "https://gitlab.com/moorara/cherry/-/releases/v0.2.0" } }`)
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL)
+		}
+	})
+	defer close()
+
+	ctx := context.Background()
+
+	release, err := g.CreateDraft(ctx, Release{
+		Name:    "0.2.0",
+		TagName: "v0.2.0",
+		Target:  "main",
+		Body:    "changes",
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, Release{
+		ID:      "v0.2.0",
+		Name:    "0.2.0",
+		TagName: "v0.2.0",
+		Target:  "main",
+		Draft:   true,
+		Body:    "changes",
+	}, release)
+
+	dir, err := ioutil.TempDir("", "cherry-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "cherry-linux-amd64")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("binary"), 0755))
+
+	asset, err := g.UploadAsset(ctx, release, path)
+	assert.NoError(t, err)
+	assert.Equal(t, Asset{
+		ID:   "4/25",
+		Name: "cherry-linux-amd64",
+		URL:  g.APIURL + "/projects/moorara%2Fcherry/packages/generic/cherry/v0.2.0/cherry-linux-amd64",
+	}, asset)
+
+	release.Assets = []Asset{asset}
+	release, err = g.Publish(ctx, release)
+	assert.NoError(t, err)
+	assert.Equal(t, Release{
+		ID:      "v0.2.0",
+		Name:    "0.2.0",
+		TagName: "v0.2.0",
+		Target:  "main",
+		Draft:   false,
+		Body:    "changes",
+		URL:     "https://gitlab.com/moorara/cherry/-/releases/v0.2.0",
+		Assets:  []Asset{asset},
+	}, release)
+
+	// No request is made for creating a draft release
+	assert.Equal(t, []string{
+		"PUT /api/v4/projects/moorara%2Fcherry/packages/generic/cherry/v0.2.0/cherry-linux-amd64",
+		"POST /api/v4/projects/moorara%2Fcherry/releases",
+	}, requests)
+}
+
+func TestGitLabDelete(t *testing.T) {
+	var requests []string
+
+	g, close := newGitLab(t, func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.EscapedPath())
+
+		switch {
+		case r.Method == "DELETE" && r.URL.EscapedPath() == "/api/v4/projects/moorara%2Fcherry/packages/4/package_files/25":
+			w.WriteHeader(204)
+		case r.Method == "DELETE" && r.URL.EscapedPath() == "/api/v4/projects/moorara%2Fcherry/releases/v0.2.0":
+			fmt.Fprint(w, `{ "name": "0.2.0", "tag_name": "v0.2.0" }`)
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL)
+		}
+	})
+	defer close()
+
+	ctx := context.Background()
+	release := Release{ID: "v0.2.0", Name: "0.2.0", TagName: "v0.2.0", Draft: true}
+
+	assert.NoError(t, g.DeleteAsset(ctx, release, Asset{ID: "4/25", Name: "cherry-linux-amd64"}))
+	assert.EqualError(t, g.DeleteAsset(ctx, release, Asset{ID: "25", Name: "cherry-linux-amd64"}), "invalid package file id: 25")
+
+	// No request is made for deleting a draft release
+	assert.NoError(t, g.DeleteRelease(ctx, release))
+
+	release.Draft = false
+	assert.NoError(t, g.DeleteRelease(ctx, release))
+
+	assert.Equal(t, []string{
+		"DELETE /api/v4/projects/moorara%2Fcherry/packages/4/package_files/25",
+		"DELETE /api/v4/projects/moorara%2Fcherry/releases/v0.2.0",
+	}, requests)
+}
+
+func TestGitLabProtectBranch(t *testing.T) {
+	var requests []string
+	protected := true
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.EscapedPath()+" "+r.URL.RawQuery)
+
+		switch {
+		case r.Method == "GET" && r.URL.EscapedPath() == "/api/v4/projects/moorara%2Fcherry/protected_branches/main" && protected:
+			fmt.Fprint(w, `{
+				"name": "main",
+				"push_access_levels": [ { "access_level": 40 } ],
+				"merge_access_levels": [ { "access_level": 30 } ]
+			}`)
+		case r.Method == "GET":
+			w.WriteHeader(404)
+		case r.Method == "DELETE":
+			protected = false
+			w.WriteHeader(204)
+		case r.Method == "POST":
+			protected = true
+			w.WriteHeader(201)
+		}
+	}
+
+	g, close := newGitLab(t, handler)
+	defer close()
+
+	ctx := context.Background()
+
+	// Branches not protected are left untouched
+	assert.NoError(t, g.ProtectBranch(ctx, "develop", false))
+	assert.NoError(t, g.ProtectBranch(ctx, "develop", true))
+
+	assert.NoError(t, g.ProtectBranch(ctx, "main", false))
+	assert.NoError(t, g.ProtectBranch(ctx, "main", true))
+	assert.Equal(t, []string{
+		"GET /api/v4/projects/moorara%2Fcherry/protected_branches/develop ",
+		"GET /api/v4/projects/moorara%2Fcherry/protected_branches/main ",
+		"DELETE /api/v4/projects/moorara%2Fcherry/protected_branches/main ",
+		"POST /api/v4/projects/moorara%2Fcherry/protected_branches merge_access_level=30&name=main&push_access_level=40",
+	}, requests)
+
+	t.Run("SavedProtections", func(t *testing.T) {
+		requests = nil
+
+		assert.NoError(t, g.ProtectBranch(ctx, "main", false))
+		data, err := g.SaveProtections()
+		assert.NoError(t, err)
+
+		// Another run finds the branch unprotected and protects it again with the saved access levels
+		other, close := newGitLab(t, handler)
+		defer close()
+
+		assert.NoError(t, other.LoadProtections(data))
+		assert.NoError(t, other.ProtectBranch(ctx, "main", false))
+		assert.NoError(t, other.ProtectBranch(ctx, "main", true))
+
+		data, err = other.SaveProtections()
+		assert.NoError(t, err)
+		assert.Nil(t, data)
+
+		assert.Equal(t, []string{
+			"GET /api/v4/projects/moorara%2Fcherry/protected_branches/main ",
+			"DELETE /api/v4/projects/moorara%2Fcherry/protected_branches/main ",
+			"GET /api/v4/projects/moorara%2Fcherry/protected_branches/main ",
+			"POST /api/v4/projects/moorara%2Fcherry/protected_branches merge_access_level=30&name=main&push_access_level=40",
+		}, requests)
+	})
+}
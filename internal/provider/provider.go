@@ -0,0 +1,148 @@
+// Package provider implements releasing on remote repository hosting services.
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// ErrPermission is returned when the user does not have enough permission for releasing.
+var ErrPermission = errors.New("insufficient permission for releasing")
+
+// Release is a release on a remote repository hosting service.
+type Release struct {
+	// ID identifies the release on the provider.
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	TagName    string `json:"tagName"`
+	Target     string `json:"target"`
+	Draft      bool   `json:"draft"`
+	Prerelease bool   `json:"prerelease"`
+	// Maintenance is set for a release of an older release line, so it is not marked as the latest release.
+	Maintenance bool   `json:"maintenance"`
+	Body        string `json:"body"`
+	// URL is the web URL of the release.
+	URL string `json:"url"`
+	// UploadURL is used by providers that upload assets to a separate endpoint.
+	UploadURL string `json:"uploadUrl"`
+	// Assets are the files uploaded for the release.
+	Assets []Asset `json:"assets"`
+}
+
+// Asset is a file attached to a release.
+type Asset struct {
+	// ID identifies the asset on the provider.
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// URL is the download URL of the asset.
+	URL string `json:"url"`
+}
+
+// Provider is a remote repository hosting service for creating releases.
+type Provider interface {
+	// Name returns the name of the provider (i.e. GitHub).
+	Name() string
+	// CheckPermission checks if the user has enough permission for releasing.
+	// If not, the returned error wraps ErrPermission.
+	CheckPermission(ctx context.Context) error
+	// CreateDraft creates a new draft release that is not visible publicly.
+	CreateDraft(ctx context.Context, release Release) (Release, error)
+	// UploadAsset uploads a file for a draft release.
+	UploadAsset(ctx context.Context, release Release, path string) (Asset, error)
+	// Publish publishes a draft release.
+	// The tag for the release should be pushed before publishing it.
+	Publish(ctx context.Context, release Release) (Release, error)
+	// ProtectBranch enables or disables the protection of a branch against direct pushes by the user.
+	ProtectBranch(ctx context.Context, branch string, protect bool) error
+	// DeleteRelease deletes a release that is not published yet.
+	DeleteRelease(ctx context.Context, release Release) error
+	// DeleteAsset deletes a file uploaded for a release that is not published yet.
+	DeleteAsset(ctx context.Context, release Release, asset Asset) error
+}
+
+// ProtectionSaver is implemented by providers that remove the protection of a branch for enabling direct pushes to it.
+// The removed protections can be saved and loaded again, so a branch can be protected again after restarting (i.e. resuming a release).
+type ProtectionSaver interface {
+	// SaveProtections returns the protections removed from the branches that are not protected again yet.
+	SaveProtections() (json.RawMessage, error)
+	// LoadProtections loads the protections saved before for protecting the branches again.
+	LoadProtections(data json.RawMessage) error
+}
+
+// openAsset opens a file for uploading and returns its name, size, and mime type.
+func openAsset(path string) (*os.File, string, int64, string, error) {
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return nil, "", 0, "", err
+	}
+
+	stat, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, "", 0, "", err
+	}
+
+	// Read the first 512 bytes of file to determine the mime type of asset
+	buff := make([]byte, 512)
+	if _, err = f.Read(buff); err != nil && err != io.EOF {
+		f.Close()
+		return nil, "", 0, "", err
+	}
+
+	// http.DetectContentType will return "application/octet-stream" if it cannot determine a more specific one
+	mimeType := http.DetectContentType(buff)
+
+	// Reset the offset back to the beginning of the file
+	if _, err = f.Seek(0, io.SeekStart); err != nil {
+		f.Close()
+		return nil, "", 0, "", err
+	}
+
+	return f, filepath.Base(f.Name()), stat.Size(), mimeType, nil
+}
+
+// statusError is returned when a response does not have the expected status code.
+type statusError struct {
+	Method     string
+	URL        string
+	StatusCode int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("%s %s: invalid status code %d", e.Method, e.URL, e.StatusCode)
+}
+
+// send sends an HTTP request and decodes the response body into out if the response has the expected status code.
+func send(client *http.Client, req *http.Request, expectedStatusCode int, out interface{}) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != expectedStatusCode {
+		return &statusError{
+			Method:     req.Method,
+			URL:        req.URL.String(),
+			StatusCode: res.StatusCode,
+		}
+	}
+
+	if out != nil {
+		if err := json.NewDecoder(res.Body).Decode(out); err != nil {
+			return fmt.Errorf("%s %s: %s", req.Method, req.URL, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package spec
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// platformVariants are the variants of architectures and the environment variables for building them.
+// Example: linux-arm-v7 --> GOARM=7
+var platformVariants = map[string]struct {
+	env    string
+	values map[string]string
+}{
+	"386":      {"GO386", map[string]string{"sse2": "sse2", "softfloat": "softfloat"}},
+	"amd64":    {"GOAMD64", map[string]string{"v1": "v1", "v2": "v2", "v3": "v3", "v4": "v4"}},
+	"arm":      {"GOARM", map[string]string{"v5": "5", "v6": "6", "v7": "7"}},
+	"mips":     {"GOMIPS", map[string]string{"hardfloat": "hardfloat", "softfloat": "softfloat"}},
+	"mipsle":   {"GOMIPS", map[string]string{"hardfloat": "hardfloat", "softfloat": "softfloat"}},
+	"mips64":   {"GOMIPS64", map[string]string{"hardfloat": "hardfloat", "softfloat": "softfloat"}},
+	"mips64le": {"GOMIPS64", map[string]string{"hardfloat": "hardfloat", "softfloat": "softfloat"}},
+}
+
+// Platform is a target platform for building binaries.
+type Platform struct {
+	OS      string
+	Arch    string
+	Variant string
+}
+
+// ParsePlatform parses a platform in os-arch or os-arch-variant format (i.e. linux-amd64, linux-arm-v7, or linux-mips-softfloat).
+func ParsePlatform(s string) (Platform, error) {
+	parts := strings.Split(s, "-")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] == "" {
+		return Platform{}, fmt.Errorf("invalid platform %q: expected os-arch or os-arch-variant", s)
+	}
+
+	p := Platform{
+		OS:   parts[0],
+		Arch: parts[1],
+	}
+
+	if len(parts) == 3 {
+		p.Variant = parts[2]
+
+		variants, ok := platformVariants[p.Arch]
+		if !ok {
+			return Platform{}, fmt.Errorf("invalid platform %q: %s has no variants", s, p.Arch)
+		}
+
+		if _, ok := variants.values[p.Variant]; !ok {
+			names := []string{}
+			for name := range variants.values {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return Platform{}, fmt.Errorf("invalid platform %q: %s variant must be one of %s", s, p.Arch, strings.Join(names, ", "))
+		}
+	}
+
+	return p, nil
+}
+
+// String returns the platform in os-arch or os-arch-variant format.
+func (p Platform) String() string {
+	if p.Variant == "" {
+		return p.OS + "-" + p.Arch
+	}
+
+	return p.OS + "-" + p.Arch + "-" + p.Variant
+}
+
+// Ext returns the file extension of executable binaries for the platform (i.e. .exe for windows).
+func (p Platform) Ext() string {
+	if p.OS == "windows" {
+		return ".exe"
+	}
+
+	return ""
+}
+
+// Env returns the environment variables for building binaries for the platform (i.e. GOOS=linux GOARCH=arm GOARM=7).
+func (p Platform) Env() []string {
+	env := []string{"GOOS=" + p.OS, "GOARCH=" + p.Arch}
+	if p.Variant != "" {
+		variants := platformVariants[p.Arch]
+		env = append(env, variants.env+"="+variants.values[p.Variant])
+	}
+
+	return env
+}
+
+// SupportedBy returns true if the platform is one of the os/arch pairs supported by go (go tool dist list).
+func (p Platform) SupportedBy(distList []string) bool {
+	for _, pair := range distList {
+		if pair == p.OS+"/"+p.Arch {
+			return true
+		}
+	}
+
+	return false
+}
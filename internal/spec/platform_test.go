@@ -0,0 +1,139 @@
+package spec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePlatform(t *testing.T) {
+	tests := []struct {
+		name             string
+		s                string
+		expectedPlatform Platform
+		expectedError    string
+	}{
+		{
+			name:             "OSArch",
+			s:                "linux-amd64",
+			expectedPlatform: Platform{OS: "linux", Arch: "amd64"},
+		},
+		{
+			name:             "ARMVariant",
+			s:                "linux-arm-v6",
+			expectedPlatform: Platform{OS: "linux", Arch: "arm", Variant: "v6"},
+		},
+		{
+			name:             "AMD64Variant",
+			s:                "linux-amd64-v3",
+			expectedPlatform: Platform{OS: "linux", Arch: "amd64", Variant: "v3"},
+		},
+		{
+			name:             "MIPSVariant",
+			s:                "linux-mips-softfloat",
+			expectedPlatform: Platform{OS: "linux", Arch: "mips", Variant: "softfloat"},
+		},
+		{
+			name:          "MissingArch",
+			s:             "linux",
+			expectedError: `invalid platform "linux": expected os-arch or os-arch-variant`,
+		},
+		{
+			name:          "EmptyArch",
+			s:             "linux-",
+			expectedError: `invalid platform "linux-": expected os-arch or os-arch-variant`,
+		},
+		{
+			name:          "TooManyParts",
+			s:             "linux-arm-v7-hf",
+			expectedError: `invalid platform "linux-arm-v7-hf": expected os-arch or os-arch-variant`,
+		},
+		{
+			name:          "NoVariants",
+			s:             "darwin-arm64-v8",
+			expectedError: `invalid platform "darwin-arm64-v8": arm64 has no variants`,
+		},
+		{
+			name:          "InvalidVariant",
+			s:             "linux-arm-v8",
+			expectedError: `invalid platform "linux-arm-v8": arm variant must be one of v5, v6, v7`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := ParsePlatform(tc.s)
+
+			if tc.expectedError != "" {
+				assert.EqualError(t, err, tc.expectedError)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedPlatform, p)
+				assert.Equal(t, tc.s, p.String())
+			}
+		})
+	}
+}
+
+func TestPlatform(t *testing.T) {
+	distList := []string{"darwin/amd64", "linux/386", "linux/amd64", "linux/arm", "linux/mips", "windows/amd64"}
+
+	tests := []struct {
+		name              string
+		platform          Platform
+		expectedExt       string
+		expectedEnv       []string
+		expectedSupported bool
+	}{
+		{
+			name:              "Linux",
+			platform:          Platform{OS: "linux", Arch: "amd64"},
+			expectedExt:       "",
+			expectedEnv:       []string{"GOOS=linux", "GOARCH=amd64"},
+			expectedSupported: true,
+		},
+		{
+			name:              "Windows",
+			platform:          Platform{OS: "windows", Arch: "amd64"},
+			expectedExt:       ".exe",
+			expectedEnv:       []string{"GOOS=windows", "GOARCH=amd64"},
+			expectedSupported: true,
+		},
+		{
+			name:              "ARMVariant",
+			platform:          Platform{OS: "linux", Arch: "arm", Variant: "v7"},
+			expectedExt:       "",
+			expectedEnv:       []string{"GOOS=linux", "GOARCH=arm", "GOARM=7"},
+			expectedSupported: true,
+		},
+		{
+			name:              "AMD64Variant",
+			platform:          Platform{OS: "linux", Arch: "amd64", Variant: "v3"},
+			expectedExt:       "",
+			expectedEnv:       []string{"GOOS=linux", "GOARCH=amd64", "GOAMD64=v3"},
+			expectedSupported: true,
+		},
+		{
+			name:              "MIPSVariant",
+			platform:          Platform{OS: "linux", Arch: "mips", Variant: "softfloat"},
+			expectedExt:       "",
+			expectedEnv:       []string{"GOOS=linux", "GOARCH=mips", "GOMIPS=softfloat"},
+			expectedSupported: true,
+		},
+		{
+			name:              "Unsupported",
+			platform:          Platform{OS: "darwin", Arch: "386"},
+			expectedExt:       "",
+			expectedEnv:       []string{"GOOS=darwin", "GOARCH=386"},
+			expectedSupported: false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedExt, tc.platform.Ext())
+			assert.Equal(t, tc.expectedEnv, tc.platform.Env())
+			assert.Equal(t, tc.expectedSupported, tc.platform.SupportedBy(distList))
+		})
+	}
+}
@@ -4,9 +4,18 @@ import (
 	"encoding/json"
 	"errors"
 	"flag"
+	"fmt"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"text/template"
 
+	"github.com/moorara/cherry/internal/minisign"
+	"github.com/moorara/cherry/pkg/semver"
 	"gopkg.in/yaml.v2"
 )
 
@@ -16,23 +25,32 @@ const (
 	defaultLanguage       = "go"
 	defaultMainFile       = "main.go"
 	defaultVersionPackage = "./version"
+	defaultArchiveName    = "{{.Name}}_{{.Version}}_{{.OS}}_{{.Arch}}{{with .Variant}}_{{.}}{{end}}"
 )
 
 var (
-	specFiles         = []string{"cherry.yml", "cherry.yaml", "cherry.json"}
-	defaultGoVersions = []string{"1.15"}
-	defaultPlatforms  = []string{"linux-386", "linux-amd64", "linux-arm", "linux-arm64", "darwin-amd64", "windows-386", "windows-amd64"}
+	specFiles                     = []string{"cherry.yml", "cherry.yaml", "cherry.json"}
+	defaultChangelogSource        = "git"
+	defaultChangelogExcludeLabels = []string{"question", "duplicate", "invalid", "wontfix"}
+	defaultGoVersions             = []string{"1.15"}
+	defaultPlatforms              = []string{"linux-386", "linux-amd64", "linux-arm", "linux-arm64", "darwin-amd64", "windows-386", "windows-amd64"}
 )
 
+// Example: bin/app_{{.OS}}_{{.Arch}}{{.Ext}} --> bin/app__
+var templateActionRE = regexp.MustCompile(`\{\{.*?\}\}`)
+
 // Spec has all the specifications for Cherry.
 type Spec struct {
 	ToolName    string `json:"-" yaml:"-"`
 	ToolVersion string `json:"-" yaml:"-"`
-
-	Version  string  `json:"version" yaml:"version"`
-	Language string  `json:"language" yaml:"language"`
-	Build    Build   `json:"build" yaml:"build"`
-	Release  Release `json:"release" yaml:"release"`
+	// ToolPublicKey is the public key release artifacts of the tool itself are verified with.
+	ToolPublicKey string `json:"-" yaml:"-"`
+
+	Version  string    `json:"version" yaml:"version"`
+	Language string    `json:"language" yaml:"language"`
+	Build    Build     `json:"build" yaml:"build"`
+	Release  Release   `json:"release" yaml:"release"`
+	Projects []Project `json:"projects" yaml:"projects"`
 }
 
 // FromFile reads and returns specifications from a file.
@@ -65,6 +83,10 @@ func FromFile() (Spec, error) {
 			return Spec{}, err
 		}
 
+		if err := spec.Validate(); err != nil {
+			return Spec{}, err
+		}
+
 		return spec, nil
 	}
 
@@ -86,17 +108,61 @@ func (s Spec) WithDefaults() Spec {
 	s.Build = s.Build.WithDefaults()
 	s.Release = s.Release.WithDefaults()
 
+	for i, p := range s.Projects {
+		s.Projects[i] = p.WithDefaults()
+	}
+
 	return s
 }
 
+// Validate checks the specifications and returns an error if any of them is invalid.
+func (s Spec) Validate() error {
+	if err := s.Build.Validate(); err != nil {
+		return err
+	}
+
+	if err := s.Release.Validate(); err != nil {
+		return err
+	}
+
+	names := map[string]bool{}
+	for _, p := range s.Projects {
+		if err := p.Validate(); err != nil {
+			return err
+		}
+
+		if names[p.Name] {
+			return fmt.Errorf("duplicate project name %q", p.Name)
+		}
+		names[p.Name] = true
+	}
+
+	return nil
+}
+
+// Project returns the project with the given name.
+func (s Spec) Project(name string) (Project, bool) {
+	for _, p := range s.Projects {
+		if p.Name == name {
+			return p, true
+		}
+	}
+
+	return Project{}, false
+}
+
 // Build has the specifications for build command.
 type Build struct {
-	CrossCompile   bool     `json:"crossCompile" yaml:"cross_compile"`
-	MainFile       string   `json:"mainFile" yaml:"main_file"`
+	CrossCompile bool   `json:"crossCompile" yaml:"cross_compile"`
+	MainFile     string `json:"mainFile" yaml:"main_file"`
+	// BinaryFile is the path for binary files or a template executed for each platform (i.e. bin/app_{{.OS}}_{{.Arch}}{{.Ext}}).
 	BinaryFile     string   `json:"binaryFile" yaml:"binary_file"`
 	VersionPackage string   `json:"versionPackage" yaml:"version_package"`
 	GoVersions     []string `json:"goVersions" yaml:"go_versions"`
 	Platforms      []string `json:"platforms" yaml:"platforms"`
+	// Parallel is the maximum number of platforms cross-compiled concurrently. It defaults to the number of CPUs.
+	Parallel int      `json:"parallel" yaml:"parallel"`
+	Archives Archives `json:"archives" yaml:"archives"`
 }
 
 // WithDefaults returns a new object with default values.
@@ -126,9 +192,93 @@ func (b Build) WithDefaults() Build {
 		b.Platforms = defaultPlatforms
 	}
 
+	if b.Parallel == 0 {
+		b.Parallel = runtime.NumCPU()
+	}
+
+	b.Archives = b.Archives.WithDefaults()
+
 	return b
 }
 
+// Validate checks the build specifications and returns an error if any of them is invalid.
+// Go versions can be exact versions (1.14.6) or ranges (1.15, 1.12.x, >=1.13).
+func (b Build) Validate() error {
+	for _, v := range b.GoVersions {
+		if _, err := semver.NewConstraint(v); err != nil {
+			return fmt.Errorf("invalid go version %q: %s", v, err)
+		}
+	}
+
+	if b.Parallel < 0 {
+		return fmt.Errorf("invalid parallel %d: must be a positive number", b.Parallel)
+	}
+
+	if _, err := b.ParsePlatforms(); err != nil {
+		return err
+	}
+
+	if _, err := b.BinaryPath(Platform{OS: "linux", Arch: "amd64"}); err != nil {
+		return err
+	}
+
+	return b.Archives.Validate()
+}
+
+// ParsePlatforms returns the platforms for cross-compiling.
+func (b Build) ParsePlatforms() ([]Platform, error) {
+	platforms := []Platform{}
+	for _, s := range b.Platforms {
+		p, err := ParsePlatform(s)
+		if err != nil {
+			return nil, err
+		}
+		platforms = append(platforms, p)
+	}
+
+	return platforms, nil
+}
+
+// BinaryPath returns the path of the binary file built for a platform.
+// If the binary file is a template, it is executed for the platform (i.e. bin/app_{{.OS}}_{{.Arch}}{{.Ext}} --> bin/app_linux_amd64).
+// Otherwise, the platform is added to it when cross-compiling and then the file extension of the platform (i.e. bin/app-windows-amd64.exe).
+func (b Build) BinaryPath(p Platform) (string, error) {
+	if !strings.Contains(b.BinaryFile, "{{") {
+		if b.CrossCompile {
+			return b.BinaryFile + "-" + p.String() + p.Ext(), nil
+		}
+		return b.BinaryFile + p.Ext(), nil
+	}
+
+	t, err := template.New("binary_file").Parse(b.BinaryFile)
+	if err != nil {
+		return "", fmt.Errorf("invalid binary file %q: %s", b.BinaryFile, err)
+	}
+
+	var buf strings.Builder
+	if err := t.Execute(&buf, p); err != nil {
+		return "", fmt.Errorf("invalid binary file %q: %s", b.BinaryFile, err)
+	}
+
+	return buf.String(), nil
+}
+
+// BinaryName returns the file name of the binary built for a platform without the platform in it (i.e. app or app.exe).
+// If the binary file is a template, its template actions are removed (i.e. bin/app_{{.OS}}_{{.Arch}}{{.Ext}} --> app).
+func (b Build) BinaryName(p Platform) string {
+	file := filepath.ToSlash(b.BinaryFile)
+	if !strings.Contains(file, "{{") {
+		return path.Base(file) + p.Ext()
+	}
+
+	name := strings.Trim(path.Base(templateActionRE.ReplaceAllString(file, "")), "-_.")
+	if name == "" || name == "/" {
+		name = "app"
+	}
+
+	return name + p.Ext()
+}
+
 // FlagSet returns a flag set for arguments of build command.
 func (b *Build) FlagSet() *flag.FlagSet {
 	fs := flag.NewFlagSet("build", flag.ContinueOnError)
@@ -136,20 +286,143 @@ func (b *Build) FlagSet() *flag.FlagSet {
 	fs.StringVar(&b.MainFile, "main-file", b.MainFile, "")
 	fs.StringVar(&b.BinaryFile, "binary-file", b.BinaryFile, "")
 	fs.StringVar(&b.VersionPackage, "version-package", b.VersionPackage, "")
+	fs.IntVar(&b.Parallel, "parallel", b.Parallel, "")
+	fs.BoolVar(&b.Archives.Enabled, "archive", b.Archives.Enabled, "")
 
 	return fs
 }
 
+// Archives has the specifications for packaging binaries in archives.
+type Archives struct {
+	Enabled bool `json:"enabled" yaml:"enabled"`
+	// Name is a template for the names of archives without the file extension (default: app_1.2.3_linux_amd64).
+	Name string `json:"name" yaml:"name"`
+	// Format is either tar.gz or zip. If not set, zip is used for windows and tar.gz for other platforms.
+	Format string `json:"format" yaml:"format"`
+	// Files are glob patterns for the files included in archives besides the binary, LICENSE, and README files.
+	Files []string `json:"files" yaml:"files"`
+}
+
+// WithDefaults returns a new object with default values.
+func (a Archives) WithDefaults() Archives {
+	if a.Name == "" {
+		a.Name = defaultArchiveName
+	}
+
+	return a
+}
+
+// Validate checks the archives specifications and returns an error if any of them is invalid.
+func (a Archives) Validate() error {
+	if a.Format != "" && a.Format != "tar.gz" && a.Format != "zip" {
+		return fmt.Errorf("invalid archive format %q: must be tar.gz or zip", a.Format)
+	}
+
+	if _, err := a.ArchiveName("app", "0.1.0", Platform{OS: "linux", Arch: "amd64"}); err != nil {
+		return err
+	}
+
+	for _, pattern := range a.Files {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid archive file %q: %s", pattern, err)
+		}
+	}
+
+	return nil
+}
+
+// ArchiveFormat returns the format of the archive for a platform.
+func (a Archives) ArchiveFormat(p Platform) string {
+	if a.Format != "" {
+		return a.Format
+	}
+
+	if p.OS == "windows" {
+		return "zip"
+	}
+
+	return "tar.gz"
+}
+
+// ArchiveName returns the file name of the archive for a binary built for a platform (i.e. app_1.2.3_linux_amd64.tar.gz).
+// The name template can use the name of the binary ({{.Name}}), the version ({{.Version}}), and the platform ({{.OS}}, {{.Arch}}, and {{.Variant}}).
+func (a Archives) ArchiveName(name, version string, p Platform) (string, error) {
+	t, err := template.New("name").Parse(a.Name)
+	if err != nil {
+		return "", fmt.Errorf("invalid archive name %q: %s", a.Name, err)
+	}
+
+	data := struct {
+		Platform
+		Name    string
+		Version string
+	}{p, name, version}
+
+	var buf strings.Builder
+	if err := t.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("invalid archive name %q: %s", a.Name, err)
+	}
+
+	return buf.String() + "." + a.ArchiveFormat(p), nil
+}
+
 // Release has the specifications for release command.
 type Release struct {
 	Build bool `json:"build" yaml:"build"`
+	// Provider is either github, gitlab, or gitea. If not set, it is detected from the remote repository url.
+	Provider string `json:"provider" yaml:"provider"`
+	// CAFile is a PEM file with the certificate authorities trusted for connecting to the remote repository in addition to the system ones.
+	CAFile string `json:"caFile" yaml:"ca_file"`
+	// Branches are the glob patterns (i.e. release/*) for the branches releases can be made from.
+	// If not set, releases can only be made from the default branch of the remote repository.
+	Branches  []string  `json:"branches" yaml:"branches"`
+	GitHub    GitHub    `json:"github" yaml:"github"`
+	Changelog Changelog `json:"changelog" yaml:"changelog"`
+	Checksums Checksums `json:"checksums" yaml:"checksums"`
+	Signing   Signing   `json:"signing" yaml:"signing"`
 }
 
 // WithDefaults returns a new object with default values.
 func (r Release) WithDefaults() Release {
+	r.Changelog = r.Changelog.WithDefaults()
+
 	return r
 }
 
+// Validate checks the release specifications and returns an error if any of them is invalid.
+func (r Release) Validate() error {
+	if r.Provider != "" && r.Provider != "github" && r.Provider != "gitlab" && r.Provider != "gitea" {
+		return fmt.Errorf("invalid release provider %q: must be github, gitlab, or gitea", r.Provider)
+	}
+
+	for _, pattern := range r.Branches {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid release branch pattern %q: %s", pattern, err)
+		}
+	}
+
+	if err := r.GitHub.Validate(); err != nil {
+		return err
+	}
+
+	if err := r.Signing.Validate(); err != nil {
+		return err
+	}
+
+	return r.Changelog.Validate()
+}
+
+// MatchBranch determines whether or not a branch matches any of the release branch patterns.
+func (r Release) MatchBranch(branch string) bool {
+	for _, pattern := range r.Branches {
+		if ok, _ := path.Match(pattern, branch); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
 // FlagSet returns a flag set for arguments of release command.
 func (r *Release) FlagSet() *flag.FlagSet {
 	fs := flag.NewFlagSet("release", flag.ContinueOnError)
@@ -157,3 +430,120 @@ func (r *Release) FlagSet() *flag.FlagSet {
 
 	return fs
 }
+
+// GitHub has the specifications for releasing GitHub repositories.
+// The URLs are only required for GitHub Enterprise Server if they cannot be derived from the remote repository host.
+type GitHub struct {
+	// APIURL is the base URL of GitHub API (i.e. https://github.example.com/api/v3).
+	APIURL string `json:"apiUrl" yaml:"api_url"`
+	// UploadURL is the base URL for uploading release assets (i.e. https://github.example.com/api/uploads).
+	UploadURL string `json:"uploadUrl" yaml:"upload_url"`
+}
+
+// Validate checks the GitHub specifications and returns an error if any of them is invalid.
+func (g GitHub) Validate() error {
+	for _, u := range []string{g.APIURL, g.UploadURL} {
+		if u == "" {
+			continue
+		}
+
+		if parsed, err := url.Parse(u); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("invalid github url %q: must be an absolute http or https url", u)
+		}
+	}
+
+	return nil
+}
+
+// Checksums has the specifications for the checksums of release artifacts.
+// The SHA-256 checksums are always generated.
+type Checksums struct {
+	SHA512 bool `json:"sha512" yaml:"sha512"`
+}
+
+// Signing has the specifications for signing release artifacts with a minisign key.
+type Signing struct {
+	// KeyFile is the path to an unencrypted minisign secret key file.
+	// The secret key can also be set using CHERRY_SIGNING_KEY environment variable.
+	KeyFile string `json:"keyFile" yaml:"key_file"`
+	// PublicKey is the minisign public key for verifying signatures (i.e. RWQf6LRCGA9i53mlYecO4IzT51TGPpvWucNSCh1CBM0QTaLn73Y7GFO3).
+	// It is also embedded in the binaries built.
+	PublicKey string `json:"publicKey" yaml:"public_key"`
+}
+
+// Validate checks the signing specifications and returns an error if any of them is invalid.
+func (s Signing) Validate() error {
+	if s.PublicKey != "" {
+		if _, err := minisign.ParsePublicKey(s.PublicKey); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Changelog has the specifications for generating change logs.
+type Changelog struct {
+	// Source is either git (commits) or github (pull requests and issues).
+	Source        string   `json:"source" yaml:"source"`
+	ExcludeLabels []string `json:"excludeLabels" yaml:"exclude_labels"`
+}
+
+// WithDefaults returns a new object with default values.
+func (c Changelog) WithDefaults() Changelog {
+	if c.Source == "" {
+		c.Source = defaultChangelogSource
+	}
+
+	if len(c.ExcludeLabels) == 0 {
+		c.ExcludeLabels = defaultChangelogExcludeLabels
+	}
+
+	return c
+}
+
+// Validate checks the change log specifications and returns an error if any of them is invalid.
+func (c Changelog) Validate() error {
+	if c.Source != "" && c.Source != "git" && c.Source != "github" {
+		return fmt.Errorf("invalid change log source %q: must be git or github", c.Source)
+	}
+
+	return nil
+}
+
+// Project has the specifications for a module in a monorepo that is versioned and released independently.
+type Project struct {
+	Name string `json:"name" yaml:"name"`
+	// Path is the directory of the project relative to the root of the repository (i.e. services/api).
+	Path string `json:"path" yaml:"path"`
+	// TagPrefix is prepended to the version tags of the project (default: services/api/ for services/api/v1.2.3).
+	TagPrefix string `json:"tagPrefix" yaml:"tag_prefix"`
+}
+
+// WithDefaults returns a new object with default values.
+func (p Project) WithDefaults() Project {
+	if p.TagPrefix == "" && p.Path != "" {
+		// Go convention for the version tags of nested modules
+		p.TagPrefix = path.Clean(p.Path) + "/"
+	}
+
+	return p
+}
+
+// Validate checks the project specifications and returns an error if any of them is invalid.
+func (p Project) Validate() error {
+	if p.Name == "" {
+		return errors.New("project name is required")
+	}
+
+	if p.Path == "" || path.IsAbs(p.Path) || path.Clean(p.Path) == "." || strings.HasPrefix(path.Clean(p.Path), "..") {
+		return fmt.Errorf("invalid path %q for project %s: must be a relative path inside the repository", p.Path, p.Name)
+	}
+
+	return nil
+}
+
+// Tag returns the version tag of the project for a semantic version (i.e. services/api/v1.2.3).
+func (p Project) Tag(v semver.SemVer) string {
+	return p.TagPrefix + "v" + v.String()
+}
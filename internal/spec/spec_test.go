@@ -1,8 +1,10 @@
 package spec
 
 import (
+	"runtime"
 	"testing"
 
+	"github.com/moorara/cherry/pkg/semver"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -43,6 +45,16 @@ func TestFromFile(t *testing.T) {
 			specFiles:     []string{"test/invalid.json"},
 			expectedError: "invalid character",
 		},
+		{
+			name:          "InvalidGoVersion",
+			specFiles:     []string{"test/invalid_go_version.yaml"},
+			expectedError: `invalid go version "go1.14"`,
+		},
+		{
+			name:          "InvalidChangelogSource",
+			specFiles:     []string{"test/invalid_changelog_source.yaml"},
+			expectedError: `invalid change log source "gitlab"`,
+		},
 		{
 			name:      "MinimumYAML",
 			specFiles: []string{"test/min.yaml"},
@@ -80,9 +92,37 @@ func TestFromFile(t *testing.T) {
 					VersionPackage: "./version",
 					GoVersions:     []string{"1.15", "1.14.6", "1.12.x"},
 					Platforms:      []string{"linux-386", "linux-amd64", "linux-arm", "linux-arm64", "darwin-amd64", "windows-386", "windows-amd64"},
+					Parallel:       4,
+					Archives: Archives{
+						Enabled: true,
+						Name:    "{{.Name}}-{{.Version}}-{{.OS}}-{{.Arch}}",
+						Files:   []string{"docs/*.md"},
+					},
 				},
 				Release: Release{
-					Build: true,
+					Build:    true,
+					Provider: "github",
+					CAFile:   "/etc/ssl/certs/company.pem",
+					Branches: []string{"main", "release/*"},
+					GitHub: GitHub{
+						APIURL:    "https://github.example.com/api/v3",
+						UploadURL: "https://github.example.com/api/uploads",
+					},
+					Changelog: Changelog{
+						Source:        "github",
+						ExcludeLabels: []string{"question", "wontfix"},
+					},
+					Checksums: Checksums{
+						SHA512: true,
+					},
+					Signing: Signing{
+						KeyFile:   "/etc/cherry/minisign.key",
+						PublicKey: "RWQf6LRCGA9i53mlYecO4IzT51TGPpvWucNSCh1CBM0QTaLn73Y7GFO3",
+					},
+				},
+				Projects: []Project{
+					{Name: "api", Path: "services/api"},
+					{Name: "cli", Path: "tools/cli", TagPrefix: "cli-"},
 				},
 			},
 		},
@@ -99,9 +139,37 @@ func TestFromFile(t *testing.T) {
 					VersionPackage: "./version",
 					GoVersions:     []string{"1.15", "1.14.6", "1.12.x"},
 					Platforms:      []string{"linux-386", "linux-amd64", "linux-arm", "linux-arm64", "darwin-amd64", "windows-386", "windows-amd64"},
+					Parallel:       4,
+					Archives: Archives{
+						Enabled: true,
+						Name:    "{{.Name}}-{{.Version}}-{{.OS}}-{{.Arch}}",
+						Files:   []string{"docs/*.md"},
+					},
 				},
 				Release: Release{
-					Build: true,
+					Build:    true,
+					Provider: "github",
+					CAFile:   "/etc/ssl/certs/company.pem",
+					Branches: []string{"main", "release/*"},
+					GitHub: GitHub{
+						APIURL:    "https://github.example.com/api/v3",
+						UploadURL: "https://github.example.com/api/uploads",
+					},
+					Changelog: Changelog{
+						Source:        "github",
+						ExcludeLabels: []string{"question", "wontfix"},
+					},
+					Checksums: Checksums{
+						SHA512: true,
+					},
+					Signing: Signing{
+						KeyFile:   "/etc/cherry/minisign.key",
+						PublicKey: "RWQf6LRCGA9i53mlYecO4IzT51TGPpvWucNSCh1CBM0QTaLn73Y7GFO3",
+					},
+				},
+				Projects: []Project{
+					{Name: "api", Path: "services/api"},
+					{Name: "cli", Path: "tools/cli", TagPrefix: "cli-"},
 				},
 			},
 		},
@@ -142,9 +210,17 @@ func TestSpecWithDefaults(t *testing.T) {
 					VersionPackage: defaultVersionPackage,
 					GoVersions:     defaultGoVersions,
 					Platforms:      defaultPlatforms,
+					Parallel:       runtime.NumCPU(),
+					Archives: Archives{
+						Name: defaultArchiveName,
+					},
 				},
 				Release: Release{
 					Build: false,
+					Changelog: Changelog{
+						Source:        defaultChangelogSource,
+						ExcludeLabels: defaultChangelogExcludeLabels,
+					},
 				},
 			},
 		},
@@ -159,9 +235,17 @@ func TestSpecWithDefaults(t *testing.T) {
 					VersionPackage: "./version",
 					GoVersions:     []string{"1.15", "1.14.6"},
 					Platforms:      []string{"linux-amd64", "darwin-amd64", "windows-amd64"},
+					Parallel:       2,
+					Archives: Archives{
+						Enabled: true,
+						Format:  "zip",
+					},
 				},
 				Release: Release{
 					Build: true,
+					Changelog: Changelog{
+						Source: "github",
+					},
 				},
 			},
 			Spec{
@@ -176,9 +260,19 @@ func TestSpecWithDefaults(t *testing.T) {
 					VersionPackage: "./version",
 					GoVersions:     []string{"1.15", "1.14.6"},
 					Platforms:      []string{"linux-amd64", "darwin-amd64", "windows-amd64"},
+					Parallel:       2,
+					Archives: Archives{
+						Enabled: true,
+						Name:    defaultArchiveName,
+						Format:  "zip",
+					},
 				},
 				Release: Release{
 					Build: true,
+					Changelog: Changelog{
+						Source:        "github",
+						ExcludeLabels: defaultChangelogExcludeLabels,
+					},
 				},
 			},
 		},
@@ -189,6 +283,70 @@ func TestSpecWithDefaults(t *testing.T) {
 	}
 }
 
+func TestSpecValidate(t *testing.T) {
+	tests := []struct {
+		name          string
+		spec          Spec
+		expectedError string
+	}{
+		{
+			name: "Projects",
+			spec: Spec{
+				Projects: []Project{
+					{Name: "api", Path: "services/api"},
+					{Name: "cli", Path: "tools/cli"},
+				},
+			},
+		},
+		{
+			name: "InvalidProject",
+			spec: Spec{
+				Projects: []Project{
+					{Name: "api", Path: "../api"},
+				},
+			},
+			expectedError: `invalid path "../api" for project api: must be a relative path inside the repository`,
+		},
+		{
+			name: "DuplicateProject",
+			spec: Spec{
+				Projects: []Project{
+					{Name: "api", Path: "services/api"},
+					{Name: "api", Path: "services/api/v2"},
+				},
+			},
+			expectedError: `duplicate project name "api"`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.spec.Validate()
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestSpecProject(t *testing.T) {
+	s := Spec{
+		Projects: []Project{
+			{Name: "api", Path: "services/api", TagPrefix: "services/api/"},
+		},
+	}
+
+	p, ok := s.Project("api")
+	assert.True(t, ok)
+	assert.Equal(t, "services/api", p.Path)
+
+	_, ok = s.Project("cli")
+	assert.False(t, ok)
+}
+
 func TestBuildWithDefaults(t *testing.T) {
 	tests := []struct {
 		build         Build
@@ -203,6 +361,10 @@ func TestBuildWithDefaults(t *testing.T) {
 				VersionPackage: defaultVersionPackage,
 				GoVersions:     defaultGoVersions,
 				Platforms:      defaultPlatforms,
+				Parallel:       runtime.NumCPU(),
+				Archives: Archives{
+					Name: defaultArchiveName,
+				},
 			},
 		},
 		{
@@ -213,6 +375,11 @@ func TestBuildWithDefaults(t *testing.T) {
 				VersionPackage: "./version",
 				GoVersions:     []string{"1.15", "1.14.6"},
 				Platforms:      []string{"linux-amd64", "darwin-amd64", "windows-amd64"},
+				Parallel:       2,
+				Archives: Archives{
+					Enabled: true,
+					Name:    "{{.Name}}_{{.Version}}_{{.OS}}_{{.Arch}}",
+				},
 			},
 			Build{
 				CrossCompile:   true,
@@ -221,6 +388,11 @@ func TestBuildWithDefaults(t *testing.T) {
 				VersionPackage: "./version",
 				GoVersions:     []string{"1.15", "1.14.6"},
 				Platforms:      []string{"linux-amd64", "darwin-amd64", "windows-amd64"},
+				Parallel:       2,
+				Archives: Archives{
+					Enabled: true,
+					Name:    "{{.Name}}_{{.Version}}_{{.OS}}_{{.Arch}}",
+				},
 			},
 		},
 	}
@@ -230,6 +402,195 @@ func TestBuildWithDefaults(t *testing.T) {
 	}
 }
 
+func TestBuildValidate(t *testing.T) {
+	tests := []struct {
+		name          string
+		build         Build
+		expectedError string
+	}{
+		{
+			name:  "NoGoVersion",
+			build: Build{},
+		},
+		{
+			name: "ValidGoVersions",
+			build: Build{
+				GoVersions: []string{"1.15", "1.14.6", "1.12.x", ">=1.13"},
+			},
+		},
+		{
+			name: "InvalidGoVersion",
+			build: Build{
+				GoVersions: []string{"1.15", "go1.14"},
+			},
+			expectedError: `invalid go version "go1.14"`,
+		},
+		{
+			name: "InvalidParallel",
+			build: Build{
+				Parallel: -1,
+			},
+			expectedError: "invalid parallel -1",
+		},
+		{
+			name: "ValidPlatforms",
+			build: Build{
+				Platforms: []string{"linux-amd64", "linux-arm-v7", "linux-amd64-v3", "linux-mips-softfloat", "windows-amd64"},
+			},
+		},
+		{
+			name: "InvalidPlatform",
+			build: Build{
+				Platforms: []string{"linux-amd64", "linux-arm64-v8"},
+			},
+			expectedError: `invalid platform "linux-arm64-v8": arm64 has no variants`,
+		},
+		{
+			name: "ValidBinaryFileTemplate",
+			build: Build{
+				BinaryFile: "bin/app_{{.OS}}_{{.Arch}}{{.Ext}}",
+			},
+		},
+		{
+			name: "InvalidBinaryFileTemplate",
+			build: Build{
+				BinaryFile: "bin/app_{{.Name}}",
+			},
+			expectedError: `invalid binary file "bin/app_{{.Name}}"`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.build.Validate()
+
+			if tc.expectedError != "" {
+				assert.Contains(t, err.Error(), tc.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestBuildBinaryPath(t *testing.T) {
+	tests := []struct {
+		name          string
+		build         Build
+		platform      Platform
+		expectedPath  string
+		expectedError string
+	}{
+		{
+			name:         "Linux",
+			build:        Build{BinaryFile: "bin/app"},
+			platform:     Platform{OS: "linux", Arch: "amd64"},
+			expectedPath: "bin/app",
+		},
+		{
+			name:         "Windows",
+			build:        Build{BinaryFile: "bin/app"},
+			platform:     Platform{OS: "windows", Arch: "amd64"},
+			expectedPath: "bin/app.exe",
+		},
+		{
+			name:         "CrossCompileLinux",
+			build:        Build{CrossCompile: true, BinaryFile: "bin/app"},
+			platform:     Platform{OS: "linux", Arch: "arm", Variant: "v7"},
+			expectedPath: "bin/app-linux-arm-v7",
+		},
+		{
+			name:         "CrossCompileWindows",
+			build:        Build{CrossCompile: true, BinaryFile: "bin/app"},
+			platform:     Platform{OS: "windows", Arch: "386"},
+			expectedPath: "bin/app-windows-386.exe",
+		},
+		{
+			name:         "Template",
+			build:        Build{CrossCompile: true, BinaryFile: "bin/{{.OS}}/{{.Arch}}{{if .Variant}}_{{.Variant}}{{end}}/app{{.Ext}}"},
+			platform:     Platform{OS: "linux", Arch: "amd64", Variant: "v3"},
+			expectedPath: "bin/linux/amd64_v3/app",
+		},
+		{
+			name:         "TemplateWindows",
+			build:        Build{BinaryFile: "bin/app_{{.OS}}_{{.Arch}}{{.Ext}}"},
+			platform:     Platform{OS: "windows", Arch: "amd64"},
+			expectedPath: "bin/app_windows_amd64.exe",
+		},
+		{
+			name:          "InvalidTemplate",
+			build:         Build{BinaryFile: "bin/app_{{.OS"},
+			platform:      Platform{OS: "linux", Arch: "amd64"},
+			expectedError: `invalid binary file "bin/app_{{.OS"`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			path, err := tc.build.BinaryPath(tc.platform)
+
+			if tc.expectedError != "" {
+				assert.Contains(t, err.Error(), tc.expectedError)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedPath, path)
+			}
+		})
+	}
+}
+
+func TestBuildBinaryName(t *testing.T) {
+	tests := []struct {
+		name         string
+		build        Build
+		platform     Platform
+		expectedName string
+	}{
+		{
+			name:         "Linux",
+			build:        Build{CrossCompile: true, BinaryFile: "bin/app"},
+			platform:     Platform{OS: "linux", Arch: "amd64"},
+			expectedName: "app",
+		},
+		{
+			name:         "Windows",
+			build:        Build{CrossCompile: true, BinaryFile: "bin/app"},
+			platform:     Platform{OS: "windows", Arch: "amd64"},
+			expectedName: "app.exe",
+		},
+		{
+			name:         "Template",
+			build:        Build{CrossCompile: true, BinaryFile: "bin/app_{{.OS}}_{{.Arch}}{{.Ext}}"},
+			platform:     Platform{OS: "linux", Arch: "amd64"},
+			expectedName: "app",
+		},
+		{
+			name:         "TemplateWindows",
+			build:        Build{CrossCompile: true, BinaryFile: "bin/app_{{.OS}}_{{.Arch}}{{.Ext}}"},
+			platform:     Platform{OS: "windows", Arch: "amd64"},
+			expectedName: "app.exe",
+		},
+		{
+			name:         "TemplateDirectories",
+			build:        Build{CrossCompile: true, BinaryFile: "bin/{{.OS}}/{{.Arch}}{{if .Variant}}_{{.Variant}}{{end}}/app{{.Ext}}"},
+			platform:     Platform{OS: "linux", Arch: "arm", Variant: "v7"},
+			expectedName: "app",
+		},
+		{
+			name:         "TemplateOnly",
+			build:        Build{CrossCompile: true, BinaryFile: "bin/{{.OS}}-{{.Arch}}"},
+			platform:     Platform{OS: "linux", Arch: "amd64"},
+			expectedName: "app",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedName, tc.build.BinaryName(tc.platform))
+		})
+	}
+}
+
 func TestBuildFlagSet(t *testing.T) {
 	tests := []struct {
 		build        Build
@@ -247,6 +608,7 @@ func TestBuildFlagSet(t *testing.T) {
 				VersionPackage: "./version",
 				GoVersions:     []string{"1.15"},
 				Platforms:      []string{"linux-386", "linux-amd64", "darwin-amd64", "windows-386", "windows-amd64"},
+				Parallel:       4,
 			},
 			expectedName: "build",
 		},
@@ -258,6 +620,98 @@ func TestBuildFlagSet(t *testing.T) {
 	}
 }
 
+func TestArchivesValidate(t *testing.T) {
+	tests := []struct {
+		name          string
+		archives      Archives
+		expectedError string
+	}{
+		{
+			name:     "Default",
+			archives: Archives{}.WithDefaults(),
+		},
+		{
+			name: "Valid",
+			archives: Archives{
+				Enabled: true,
+				Name:    "{{.Name}}-{{.Version}}-{{.OS}}-{{.Arch}}",
+				Format:  "zip",
+				Files:   []string{"docs/*.md", "CHANGELOG.md"},
+			},
+		},
+		{
+			name:          "InvalidFormat",
+			archives:      Archives{Name: defaultArchiveName, Format: "rar"},
+			expectedError: `invalid archive format "rar": must be tar.gz or zip`,
+		},
+		{
+			name:          "InvalidName",
+			archives:      Archives{Name: "{{.Name}}_{{.Commit}}"},
+			expectedError: `invalid archive name "{{.Name}}_{{.Commit}}"`,
+		},
+		{
+			name:          "InvalidFile",
+			archives:      Archives{Name: defaultArchiveName, Files: []string{"docs/[.md"}},
+			expectedError: `invalid archive file "docs/[.md"`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.archives.Validate()
+
+			if tc.expectedError != "" {
+				assert.Contains(t, err.Error(), tc.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestArchivesArchiveName(t *testing.T) {
+	tests := []struct {
+		name         string
+		archives     Archives
+		platform     Platform
+		expectedName string
+	}{
+		{
+			name:         "Linux",
+			archives:     Archives{}.WithDefaults(),
+			platform:     Platform{OS: "linux", Arch: "amd64"},
+			expectedName: "app_1.2.3_linux_amd64.tar.gz",
+		},
+		{
+			name:         "LinuxVariant",
+			archives:     Archives{}.WithDefaults(),
+			platform:     Platform{OS: "linux", Arch: "arm", Variant: "v7"},
+			expectedName: "app_1.2.3_linux_arm_v7.tar.gz",
+		},
+		{
+			name:         "Windows",
+			archives:     Archives{}.WithDefaults(),
+			platform:     Platform{OS: "windows", Arch: "amd64"},
+			expectedName: "app_1.2.3_windows_amd64.zip",
+		},
+		{
+			name:         "Format",
+			archives:     Archives{Name: "{{.Name}}-{{.Version}}-{{.OS}}-{{.Arch}}", Format: "zip"},
+			platform:     Platform{OS: "darwin", Arch: "amd64"},
+			expectedName: "app-1.2.3-darwin-amd64.zip",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			name, err := tc.archives.ArchiveName("app", "1.2.3", tc.platform)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedName, name)
+		})
+	}
+}
+
 func TestReleaseWithDefaults(t *testing.T) {
 	tests := []struct {
 		release         Release
@@ -267,14 +721,26 @@ func TestReleaseWithDefaults(t *testing.T) {
 			Release{},
 			Release{
 				Build: false,
+				Changelog: Changelog{
+					Source:        defaultChangelogSource,
+					ExcludeLabels: defaultChangelogExcludeLabels,
+				},
 			},
 		},
 		{
 			Release{
 				Build: true,
+				Changelog: Changelog{
+					Source:        "github",
+					ExcludeLabels: []string{"wontfix"},
+				},
 			},
 			Release{
 				Build: true,
+				Changelog: Changelog{
+					Source:        "github",
+					ExcludeLabels: []string{"wontfix"},
+				},
 			},
 		},
 	}
@@ -284,6 +750,94 @@ func TestReleaseWithDefaults(t *testing.T) {
 	}
 }
 
+func TestReleaseValidate(t *testing.T) {
+	tests := []struct {
+		name          string
+		release       Release
+		expectedError string
+	}{
+		{
+			name:    "Empty",
+			release: Release{},
+		},
+		{
+			name:    "Gitea",
+			release: Release{Provider: "gitea"},
+		},
+		{
+			name:          "InvalidProvider",
+			release:       Release{Provider: "bitbucket"},
+			expectedError: `invalid release provider "bitbucket": must be github, gitlab, or gitea`,
+		},
+		{
+			name:          "InvalidGitHub",
+			release:       Release{GitHub: GitHub{APIURL: "github.example.com"}},
+			expectedError: `invalid github url "github.example.com": must be an absolute http or https url`,
+		},
+		{
+			name:    "Branches",
+			release: Release{Branches: []string{"main", "release/*"}},
+		},
+		{
+			name:          "InvalidBranches",
+			release:       Release{Branches: []string{"release/[0-9"}},
+			expectedError: `invalid release branch pattern "release/[0-9": syntax error in pattern`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.release.Validate()
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestReleaseMatchBranch(t *testing.T) {
+	tests := []struct {
+		name          string
+		release       Release
+		branch        string
+		expectedMatch bool
+	}{
+		{
+			name:          "NoPattern",
+			release:       Release{},
+			branch:        "main",
+			expectedMatch: false,
+		},
+		{
+			name:          "Exact",
+			release:       Release{Branches: []string{"main", "release/*"}},
+			branch:        "main",
+			expectedMatch: true,
+		},
+		{
+			name:          "Glob",
+			release:       Release{Branches: []string{"main", "release/*"}},
+			branch:        "release/1.4",
+			expectedMatch: true,
+		},
+		{
+			name:          "NoMatch",
+			release:       Release{Branches: []string{"main", "release/*"}},
+			branch:        "feature/release/1.4",
+			expectedMatch: false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedMatch, tc.release.MatchBranch(tc.branch))
+		})
+	}
+}
+
 func TestReleaseFlagSet(t *testing.T) {
 	tests := []struct {
 		release      Release
@@ -306,3 +860,201 @@ func TestReleaseFlagSet(t *testing.T) {
 		assert.Equal(t, tc.expectedName, fs.Name())
 	}
 }
+
+func TestGitHubValidate(t *testing.T) {
+	tests := []struct {
+		name          string
+		github        GitHub
+		expectedError string
+	}{
+		{
+			name:   "Empty",
+			github: GitHub{},
+		},
+		{
+			name: "Enterprise",
+			github: GitHub{
+				APIURL:    "https://github.example.com/api/v3",
+				UploadURL: "https://github.example.com/api/uploads",
+			},
+		},
+		{
+			name: "RelativeURL",
+			github: GitHub{
+				APIURL: "github.example.com/api/v3",
+			},
+			expectedError: `invalid github url "github.example.com/api/v3": must be an absolute http or https url`,
+		},
+		{
+			name: "InvalidScheme",
+			github: GitHub{
+				UploadURL: "ftp://github.example.com/api/uploads",
+			},
+			expectedError: `invalid github url "ftp://github.example.com/api/uploads": must be an absolute http or https url`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.github.Validate()
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestChangelogValidate(t *testing.T) {
+	tests := []struct {
+		name          string
+		changelog     Changelog
+		expectedError string
+	}{
+		{
+			name:      "Empty",
+			changelog: Changelog{},
+		},
+		{
+			name:      "Git",
+			changelog: Changelog{Source: "git"},
+		},
+		{
+			name:      "GitHub",
+			changelog: Changelog{Source: "github"},
+		},
+		{
+			name:          "Invalid",
+			changelog:     Changelog{Source: "gitlab"},
+			expectedError: `invalid change log source "gitlab": must be git or github`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.changelog.Validate()
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestSigningValidate(t *testing.T) {
+	tests := []struct {
+		name          string
+		signing       Signing
+		expectedError string
+	}{
+		{
+			name:    "Empty",
+			signing: Signing{},
+		},
+		{
+			name: "Valid",
+			signing: Signing{
+				KeyFile:   "minisign.key",
+				PublicKey: "RWQf6LRCGA9i53mlYecO4IzT51TGPpvWucNSCh1CBM0QTaLn73Y7GFO3",
+			},
+		},
+		{
+			name: "InvalidPublicKey",
+			signing: Signing{
+				PublicKey: "RWQf6LRCGA9i53mlYecO4IzT51TGPpvWucNSCh1C",
+			},
+			expectedError: "invalid public key: unknown format",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.signing.Validate()
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestProjectWithDefaults(t *testing.T) {
+	tests := []struct {
+		name            string
+		project         Project
+		expectedProject Project
+	}{
+		{
+			name:            "DefaultTagPrefix",
+			project:         Project{Name: "api", Path: "services/api/"},
+			expectedProject: Project{Name: "api", Path: "services/api/", TagPrefix: "services/api/"},
+		},
+		{
+			name:            "TagPrefix",
+			project:         Project{Name: "cli", Path: "tools/cli", TagPrefix: "cli-"},
+			expectedProject: Project{Name: "cli", Path: "tools/cli", TagPrefix: "cli-"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedProject, tc.project.WithDefaults())
+		})
+	}
+}
+
+func TestProjectValidate(t *testing.T) {
+	tests := []struct {
+		name          string
+		project       Project
+		expectedError string
+	}{
+		{
+			name:    "Valid",
+			project: Project{Name: "api", Path: "services/api"},
+		},
+		{
+			name:          "NoName",
+			project:       Project{Path: "services/api"},
+			expectedError: "project name is required",
+		},
+		{
+			name:          "NoPath",
+			project:       Project{Name: "api"},
+			expectedError: `invalid path "" for project api: must be a relative path inside the repository`,
+		},
+		{
+			name:          "AbsolutePath",
+			project:       Project{Name: "api", Path: "/services/api"},
+			expectedError: `invalid path "/services/api" for project api: must be a relative path inside the repository`,
+		},
+		{
+			name:          "RootPath",
+			project:       Project{Name: "api", Path: "./"},
+			expectedError: `invalid path "./" for project api: must be a relative path inside the repository`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.project.Validate()
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestProjectTag(t *testing.T) {
+	p := Project{Name: "api", Path: "services/api", TagPrefix: "services/api/"}
+	assert.Equal(t, "services/api/v1.2.3", p.Tag(semver.SemVer{Major: 1, Minor: 2, Patch: 3}))
+}
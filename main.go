@@ -39,6 +39,7 @@ func main() {
 	// Get default values for zero fields
 	s = s.WithDefaults()
 	s.ToolVersion = version.Version
+	s.ToolPublicKey = version.PublicKey
 
 	c := cli.NewCLI("cherry", version.String())
 	c.Args = os.Args[1:]
@@ -47,16 +48,22 @@ func main() {
 			return command.NewInitCommand(ui)
 		},
 		"semver": func() (cli.Command, error) {
-			return command.NewSemverCommand(ui)
+			return command.NewSemverCommand(ui, s)
 		},
 		"build": func() (cli.Command, error) {
 			return command.NewBuildCommand(ui, s)
 		},
+		"changelog": func() (cli.Command, error) {
+			return command.NewChangelogCommand(ui, s)
+		},
 		"release": func() (cli.Command, error) {
 			return command.NewReleaseCommand(ui, s)
 		},
 		"update": func() (cli.Command, error) {
-			return command.NewUpdateCommand(ui)
+			return command.NewUpdateCommand(ui, s)
+		},
+		"verify": func() (cli.Command, error) {
+			return command.NewVerifyCommand(ui, s)
 		},
 	}
 
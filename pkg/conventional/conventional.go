@@ -0,0 +1,190 @@
+// Package conventional provides a parser for Conventional Commits
+// and a way for inferring the semantic version level from a list of commits.
+// See https://www.conventionalcommits.org/en/v1.0.0
+package conventional
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/moorara/cherry/pkg/semver"
+)
+
+var (
+	// ErrNotConventional is returned when a commit message does not follow the Conventional Commits specification.
+	ErrNotConventional = errors.New("not a conventional commit")
+
+	headerRE = regexp.MustCompile(`^([A-Za-z][0-9A-Za-z-]*)(\(([^()\r\n]+)\))?(!)?: +(\S.*)$`)
+	footerRE = regexp.MustCompile(`^(BREAKING CHANGE|BREAKING-CHANGE|[A-Za-z][0-9A-Za-z-]*)(: | #)(.*)$`)
+)
+
+// Footer is a trailer in a commit message (i.e. "Reviewed-by: Jane" or "Refs #123").
+type Footer struct {
+	Token string
+	Value string
+}
+
+// Commit is a parsed conventional commit message.
+type Commit struct {
+	Type        string
+	Scope       string
+	Breaking    bool
+	Description string
+	Body        string
+	Footers     []Footer
+}
+
+// Parse reads a commit message and returns a Commit.
+// The commit type is normalized to lower case.
+// If the message does not follow the Conventional Commits specification, ErrNotConventional will be returned.
+func Parse(message string) (Commit, error) {
+	lines := strings.Split(strings.TrimSpace(strings.ReplaceAll(message, "\r\n", "\n")), "\n")
+
+	subs := headerRE.FindStringSubmatch(strings.TrimSpace(lines[0]))
+	if subs == nil {
+		return Commit{}, ErrNotConventional
+	}
+
+	c := Commit{
+		Type:        strings.ToLower(subs[1]),
+		Scope:       subs[3],
+		Breaking:    subs[4] == "!",
+		Description: strings.TrimSpace(subs[5]),
+	}
+
+	// Split the rest of the message into paragraphs
+	var paragraphs [][]string
+	var paragraph []string
+	for _, line := range lines[1:] {
+		if strings.TrimSpace(line) == "" {
+			if len(paragraph) > 0 {
+				paragraphs = append(paragraphs, paragraph)
+				paragraph = nil
+			}
+			continue
+		}
+		paragraph = append(paragraph, line)
+	}
+	if len(paragraph) > 0 {
+		paragraphs = append(paragraphs, paragraph)
+	}
+
+	// Footers are the trailing paragraphs starting with a footer token
+	i := len(paragraphs)
+	for i > 0 && footerRE.MatchString(paragraphs[i-1][0]) {
+		i--
+	}
+
+	var body []string
+	for _, p := range paragraphs[:i] {
+		body = append(body, strings.Join(p, "\n"))
+	}
+	c.Body = strings.Join(body, "\n\n")
+
+	for _, p := range paragraphs[i:] {
+		for _, line := range p {
+			if subs := footerRE.FindStringSubmatch(line); subs != nil {
+				c.Footers = append(c.Footers, Footer{
+					Token: subs[1],
+					Value: strings.TrimSpace(subs[3]),
+				})
+			} else {
+				// A continuation of the previous footer value
+				f := &c.Footers[len(c.Footers)-1]
+				f.Value += "\n" + strings.TrimSpace(line)
+			}
+		}
+	}
+
+	for _, f := range c.Footers {
+		if f.Token == "BREAKING CHANGE" || f.Token == "BREAKING-CHANGE" {
+			c.Breaking = true
+		}
+	}
+
+	return c, nil
+}
+
+// Header returns the first line of the commit message.
+func (c Commit) Header() string {
+	var scope, breaking string
+
+	if c.Scope != "" {
+		scope = "(" + c.Scope + ")"
+	}
+
+	if c.Breaking {
+		breaking = "!"
+	}
+
+	return fmt.Sprintf("%s%s%s: %s", c.Type, scope, breaking, c.Description)
+}
+
+// BreakingChange returns the description of the breaking change.
+// If there is no BREAKING CHANGE footer, the commit description is returned for a breaking commit.
+func (c Commit) BreakingChange() string {
+	for _, f := range c.Footers {
+		if f.Token == "BREAKING CHANGE" || f.Token == "BREAKING-CHANGE" {
+			return f.Value
+		}
+	}
+
+	if c.Breaking {
+		return c.Description
+	}
+
+	return ""
+}
+
+// Bump infers the semantic version level for releasing a list of commits on top of the current version.
+//
+//   - A breaking change results in a major release (or a minor release if the current major version is zero).
+//   - A feat commit results in a minor release.
+//   - Any other commit results in a patch release.
+//
+// The second return value explains the reasoning behind the decision.
+func Bump(current semver.SemVer, commits []Commit) (semver.Version, []string) {
+	var breaking, features, others []Commit
+
+	for _, c := range commits {
+		switch {
+		case c.Breaking:
+			breaking = append(breaking, c)
+		case c.Type == "feat":
+			features = append(features, c)
+		default:
+			others = append(others, c)
+		}
+	}
+
+	var reasons []string
+
+	switch {
+	case len(breaking) > 0:
+		for _, c := range breaking {
+			reasons = append(reasons, fmt.Sprintf("breaking change: %s", c.Header()))
+		}
+
+		if current.Major == 0 {
+			reasons = append(reasons, fmt.Sprintf("major version is zero (%s), so breaking changes result in a minor release", current))
+			return semver.Minor, reasons
+		}
+
+		reasons = append(reasons, "breaking changes result in a major release")
+		return semver.Major, reasons
+
+	case len(features) > 0:
+		for _, c := range features {
+			reasons = append(reasons, fmt.Sprintf("feature: %s", c.Header()))
+		}
+
+		reasons = append(reasons, "new features result in a minor release")
+		return semver.Minor, reasons
+
+	default:
+		reasons = append(reasons, fmt.Sprintf("no breaking change or feature in %d commit(s), so a patch release", len(others)))
+		return semver.Patch, reasons
+	}
+}
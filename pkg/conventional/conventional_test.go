@@ -0,0 +1,254 @@
+package conventional
+
+import (
+	"testing"
+
+	"github.com/moorara/cherry/pkg/semver"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name           string
+		message        string
+		expectedCommit Commit
+		expectedError  error
+	}{
+		{
+			name:          "Empty",
+			message:       "",
+			expectedError: ErrNotConventional,
+		},
+		{
+			name:          "NoType",
+			message:       "Releasing 0.2.7",
+			expectedError: ErrNotConventional,
+		},
+		{
+			name:          "NoDescription",
+			message:       "fix: ",
+			expectedError: ErrNotConventional,
+		},
+		{
+			name:          "EmptyScope",
+			message:       "fix(): handle empty tag",
+			expectedError: ErrNotConventional,
+		},
+		{
+			name:    "Minimal",
+			message: "fix: handle empty tag\n",
+			expectedCommit: Commit{
+				Type:        "fix",
+				Description: "handle empty tag",
+			},
+		},
+		{
+			name:    "Scope",
+			message: "Feat(release): add -auto flag",
+			expectedCommit: Commit{
+				Type:        "feat",
+				Scope:       "release",
+				Description: "add -auto flag",
+			},
+		},
+		{
+			name:    "BreakingMarker",
+			message: "refactor(spec)!: rename release.build",
+			expectedCommit: Commit{
+				Type:        "refactor",
+				Scope:       "spec",
+				Breaking:    true,
+				Description: "rename release.build",
+			},
+		},
+		{
+			name:    "Body",
+			message: "docs: document pre-releases\n\nFirst paragraph\nstill first paragraph.\n\nSecond paragraph.",
+			expectedCommit: Commit{
+				Type:        "docs",
+				Description: "document pre-releases",
+				Body:        "First paragraph\nstill first paragraph.\n\nSecond paragraph.",
+			},
+		},
+		{
+			name:    "Footers",
+			message: "fix: prevent racing of requests\r\n\r\nIntroduce a request id.\r\n\r\nReviewed-by: Z\r\nRefs #123\r\n",
+			expectedCommit: Commit{
+				Type:        "fix",
+				Description: "prevent racing of requests",
+				Body:        "Introduce a request id.",
+				Footers: []Footer{
+					{Token: "Reviewed-by", Value: "Z"},
+					{Token: "Refs", Value: "123"},
+				},
+			},
+		},
+		{
+			name:    "BreakingChangeFooter",
+			message: "feat: allow provided config object to extend other configs\n\nBREAKING CHANGE: `extends` key in config file is now used\n  for extending other config files\nRefs: #42",
+			expectedCommit: Commit{
+				Type:        "feat",
+				Breaking:    true,
+				Description: "allow provided config object to extend other configs",
+				Footers: []Footer{
+					{Token: "BREAKING CHANGE", Value: "`extends` key in config file is now used\nfor extending other config files"},
+					{Token: "Refs", Value: "#42"},
+				},
+			},
+		},
+		{
+			name:    "BreakingChangeHyphenFooter",
+			message: "chore: drop support for Go 1.13\n\nBREAKING-CHANGE: use Go 1.14 or newer",
+			expectedCommit: Commit{
+				Type:        "chore",
+				Breaking:    true,
+				Description: "drop support for Go 1.13",
+				Footers: []Footer{
+					{Token: "BREAKING-CHANGE", Value: "use Go 1.14 or newer"},
+				},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			commit, err := Parse(tc.message)
+
+			if tc.expectedError != nil {
+				assert.Equal(t, tc.expectedError, err)
+				assert.Equal(t, Commit{}, commit)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedCommit, commit)
+			}
+		})
+	}
+}
+
+func TestCommitHeader(t *testing.T) {
+	tests := []struct {
+		commit         Commit
+		expectedHeader string
+	}{
+		{
+			Commit{Type: "fix", Description: "handle empty tag"},
+			"fix: handle empty tag",
+		},
+		{
+			Commit{Type: "feat", Scope: "release", Breaking: true, Description: "remove -patch flag"},
+			"feat(release)!: remove -patch flag",
+		},
+	}
+
+	for _, tc := range tests {
+		assert.Equal(t, tc.expectedHeader, tc.commit.Header())
+	}
+}
+
+func TestCommitBreakingChange(t *testing.T) {
+	tests := []struct {
+		commit                 Commit
+		expectedBreakingChange string
+	}{
+		{
+			Commit{Type: "fix", Description: "handle empty tag"},
+			"",
+		},
+		{
+			Commit{Type: "feat", Breaking: true, Description: "remove -patch flag"},
+			"remove -patch flag",
+		},
+		{
+			Commit{
+				Type:        "feat",
+				Breaking:    true,
+				Description: "remove -patch flag",
+				Footers:     []Footer{{Token: "BREAKING CHANGE", Value: "patch is the default level"}},
+			},
+			"patch is the default level",
+		},
+	}
+
+	for _, tc := range tests {
+		assert.Equal(t, tc.expectedBreakingChange, tc.commit.BreakingChange())
+	}
+}
+
+func TestBump(t *testing.T) {
+	tests := []struct {
+		name            string
+		current         semver.SemVer
+		commits         []Commit
+		expectedVersion semver.Version
+		expectedReasons []string
+	}{
+		{
+			name:            "NoCommit",
+			current:         semver.SemVer{Major: 1, Minor: 2, Patch: 0},
+			commits:         nil,
+			expectedVersion: semver.Patch,
+			expectedReasons: []string{
+				"no breaking change or feature in 0 commit(s), so a patch release",
+			},
+		},
+		{
+			name:    "Fixes",
+			current: semver.SemVer{Major: 1, Minor: 2, Patch: 0},
+			commits: []Commit{
+				{Type: "fix", Description: "handle empty tag"},
+				{Type: "docs", Description: "update README"},
+			},
+			expectedVersion: semver.Patch,
+			expectedReasons: []string{
+				"no breaking change or feature in 2 commit(s), so a patch release",
+			},
+		},
+		{
+			name:    "Features",
+			current: semver.SemVer{Major: 1, Minor: 2, Patch: 0},
+			commits: []Commit{
+				{Type: "fix", Description: "handle empty tag"},
+				{Type: "feat", Scope: "release", Description: "add -auto flag"},
+			},
+			expectedVersion: semver.Minor,
+			expectedReasons: []string{
+				"feature: feat(release): add -auto flag",
+				"new features result in a minor release",
+			},
+		},
+		{
+			name:    "BreakingChanges",
+			current: semver.SemVer{Major: 1, Minor: 2, Patch: 0},
+			commits: []Commit{
+				{Type: "feat", Description: "add -auto flag"},
+				{Type: "refactor", Breaking: true, Description: "rename release.build"},
+			},
+			expectedVersion: semver.Major,
+			expectedReasons: []string{
+				"breaking change: refactor!: rename release.build",
+				"breaking changes result in a major release",
+			},
+		},
+		{
+			name:    "BreakingChangesInitialDevelopment",
+			current: semver.SemVer{Major: 0, Minor: 3, Patch: 1},
+			commits: []Commit{
+				{Type: "refactor", Breaking: true, Description: "rename release.build"},
+			},
+			expectedVersion: semver.Minor,
+			expectedReasons: []string{
+				"breaking change: refactor!: rename release.build",
+				"major version is zero (0.3.1), so breaking changes result in a minor release",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			version, reasons := Bump(tc.current, tc.commits)
+
+			assert.Equal(t, tc.expectedVersion, version)
+			assert.Equal(t, tc.expectedReasons, reasons)
+		})
+	}
+}
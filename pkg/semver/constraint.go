@@ -0,0 +1,402 @@
+package semver
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type operator int
+
+const (
+	opEQ operator = iota
+	opNEQ
+	opGT
+	opGTE
+	opLT
+	opLTE
+)
+
+// comparator is a primitive comparison against a single semantic version.
+type comparator struct {
+	op      operator
+	version SemVer
+	// source is the constraint term this comparator is derived from (i.e. ~1.4 or 1.x).
+	source string
+}
+
+func (c comparator) check(v SemVer) bool {
+	cmp := v.Compare(c.version)
+
+	switch c.op {
+	case opEQ:
+		return cmp == 0
+	case opNEQ:
+		return cmp != 0
+	case opGT:
+		return cmp > 0
+	case opGTE:
+		return cmp >= 0
+	case opLT:
+		return cmp < 0
+	case opLTE:
+		return cmp <= 0
+	default:
+		return false
+	}
+}
+
+func (c comparator) explain(v SemVer) error {
+	var reason string
+
+	switch c.op {
+	case opEQ:
+		reason = "is not equal to"
+	case opNEQ:
+		reason = "is equal to"
+	case opGT:
+		reason = "is less than or equal to"
+	case opGTE:
+		reason = "is less than"
+	case opLT:
+		reason = "is greater than or equal to"
+	case opLTE:
+		reason = "is greater than"
+	}
+
+	return fmt.Errorf("%s does not satisfy %q: %s %s %s", v, c.source, v, reason, c.version)
+}
+
+// Constraint is a set of conditions that a semantic version can be checked against.
+//
+// A constraint consists of one or more ranges separated by ||.
+// A range is a list of space or comma separated comparators that all should be satisfied.
+// The following comparators are supported:
+//
+//	=1.2.3  !=1.2.3  >1.2.3  >=1.2.3  <1.2.3  <=1.2.3
+//	1.2.x  1.2.*  1.2  1.x  1  *      (wildcard ranges)
+//	~1.2.3  ~1.2  ~1                  (patch-level changes)
+//	^1.2.3  ^0.3.1  ^0.0.3            (changes that do not modify the left-most non-zero number)
+//
+// Pre-release versions only satisfy a range if a comparator in the same range
+// has a pre-release for the same major, minor, and patch numbers (i.e. >=1.3.0-beta.1 matches 1.3.0-rc.1).
+type Constraint struct {
+	ranges [][]comparator
+	text   string
+}
+
+// NewConstraint parses a constraint string and returns a Constraint.
+func NewConstraint(constraint string) (*Constraint, error) {
+	text := strings.TrimSpace(constraint)
+	if text == "" {
+		return nil, errors.New("empty constraint")
+	}
+
+	c := &Constraint{
+		text: text,
+	}
+
+	for _, r := range strings.Split(text, "||") {
+		terms := tokenize(r)
+		if len(terms) == 0 {
+			return nil, fmt.Errorf("invalid constraint %q: empty range", text)
+		}
+
+		var comparators []comparator
+		for _, term := range terms {
+			cs, err := parseTerm(term)
+			if err != nil {
+				return nil, fmt.Errorf("invalid constraint %q: %s", text, err)
+			}
+			comparators = append(comparators, cs...)
+		}
+
+		c.ranges = append(c.ranges, comparators)
+	}
+
+	return c, nil
+}
+
+// MustConstraint is like NewConstraint but panics if the constraint cannot be parsed.
+func MustConstraint(constraint string) *Constraint {
+	c, err := NewConstraint(constraint)
+	if err != nil {
+		panic(err)
+	}
+
+	return c
+}
+
+// Check returns true if the given semantic version satisfies the constraint.
+func (c *Constraint) Check(v SemVer) bool {
+	for _, r := range c.ranges {
+		if checkRange(r, v) == nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Validate checks the given semantic version against the constraint.
+// If the version does not satisfy the constraint, the returned errors explain why it is rejected by each range.
+func (c *Constraint) Validate(v SemVer) (bool, []error) {
+	var errs []error
+
+	for _, r := range c.ranges {
+		err := checkRange(r, v)
+		if err == nil {
+			return true, nil
+		}
+		errs = append(errs, err)
+	}
+
+	return false, errs
+}
+
+// String returns the constraint string (also implements fmt.Stringer).
+func (c *Constraint) String() string {
+	return c.text
+}
+
+func checkRange(r []comparator, v SemVer) error {
+	for _, c := range r {
+		if !c.check(v) {
+			return c.explain(v)
+		}
+	}
+
+	if len(v.Prerelease) == 0 {
+		return nil
+	}
+
+	// A pre-release version is only allowed if a comparator explicitly opts into pre-releases of the same version core
+	for _, c := range r {
+		if len(c.version.Prerelease) > 0 && c.version.Major == v.Major && c.version.Minor == v.Minor && c.version.Patch == v.Patch {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%s does not satisfy %q: pre-release versions of %d.%d.%d are not included", v, rangeSource(r), v.Major, v.Minor, v.Patch)
+}
+
+func rangeSource(r []comparator) string {
+	var sources []string
+	for _, c := range r {
+		if len(sources) == 0 || sources[len(sources)-1] != c.source {
+			sources = append(sources, c.source)
+		}
+	}
+
+	return strings.Join(sources, " ")
+}
+
+// tokenize splits a range into its terms and joins operators separated from their versions (i.e. ">= 1.2.0").
+func tokenize(r string) []string {
+	fields := strings.FieldsFunc(r, func(c rune) bool {
+		return c == ' ' || c == '\t' || c == ','
+	})
+
+	var terms []string
+	for i := 0; i < len(fields); i++ {
+		term := fields[i]
+		if strings.Trim(term, "=!<>~^") == "" && i+1 < len(fields) {
+			term += fields[i+1]
+			i++
+		}
+		terms = append(terms, term)
+	}
+
+	return terms
+}
+
+// partial is a semantic version in which the trailing numbers can be omitted or replaced by a wildcard.
+type partial struct {
+	major, minor, patch uint
+	// parts is the number of non-wildcard numbers (0 to 3).
+	parts      int
+	prerelease []string
+	metadata   []string
+}
+
+func (p partial) semver() SemVer {
+	return SemVer{
+		Major:      p.major,
+		Minor:      p.minor,
+		Patch:      p.patch,
+		Prerelease: p.prerelease,
+		Metadata:   p.metadata,
+	}
+}
+
+// next returns the lowest version that is not matched by the partial version as a wildcard range.
+func (p partial) next() SemVer {
+	switch p.parts {
+	case 1:
+		return SemVer{Major: p.major + 1}
+	case 2:
+		return SemVer{Major: p.major, Minor: p.minor + 1}
+	default:
+		return SemVer{Major: p.major, Minor: p.minor, Patch: p.patch + 1}
+	}
+}
+
+func parsePartial(s string) (partial, error) {
+	var p partial
+	core := strings.TrimPrefix(s, "v")
+
+	if i := strings.IndexByte(core, '+'); i >= 0 {
+		p.metadata = strings.Split(core[i+1:], ".")
+		core = core[:i]
+	}
+
+	if i := strings.IndexByte(core, '-'); i >= 0 {
+		p.prerelease = strings.Split(core[i+1:], ".")
+		core = core[:i]
+	}
+
+	for _, ids := range [][]string{p.prerelease, p.metadata} {
+		for _, id := range ids {
+			if !isIdentifier(id) {
+				return partial{}, fmt.Errorf("invalid version %q", s)
+			}
+		}
+	}
+
+	nums := strings.Split(core, ".")
+	if len(nums) > 3 {
+		return partial{}, fmt.Errorf("invalid version %q", s)
+	}
+
+	wildcard := false
+	for i, num := range nums {
+		if num == "x" || num == "X" || num == "*" {
+			wildcard = true
+			continue
+		}
+
+		n, err := strconv.ParseUint(num, 10, 0)
+		if err != nil || wildcard {
+			return partial{}, fmt.Errorf("invalid version %q", s)
+		}
+
+		switch i {
+		case 0:
+			p.major = uint(n)
+		case 1:
+			p.minor = uint(n)
+		case 2:
+			p.patch = uint(n)
+		}
+		p.parts++
+	}
+
+	if p.parts < 3 && (p.prerelease != nil || p.metadata != nil) {
+		return partial{}, fmt.Errorf("invalid version %q: pre-release and metadata require a full version", s)
+	}
+
+	return p, nil
+}
+
+// parseTerm parses a single constraint term and expands it into primitive comparators.
+func parseTerm(term string) ([]comparator, error) {
+	i := strings.IndexFunc(term, func(c rune) bool {
+		return !strings.ContainsRune("=!<>~^", c)
+	})
+	if i < 0 {
+		return nil, fmt.Errorf("invalid term %q", term)
+	}
+
+	op, ver := term[:i], term[i:]
+	if op == "" && (ver == "*" || ver == "x" || ver == "X") {
+		return []comparator{
+			{op: opGTE, version: SemVer{}, source: term},
+		}, nil
+	}
+
+	p, err := parsePartial(ver)
+	if err != nil {
+		return nil, err
+	}
+
+	v := p.semver()
+	full := p.parts == 3
+
+	cmp := func(op operator, v SemVer) comparator {
+		return comparator{op: op, version: v, source: term}
+	}
+
+	switch op {
+	case "", "=", "==":
+		if full {
+			return []comparator{cmp(opEQ, v)}, nil
+		}
+		if p.parts == 0 {
+			return []comparator{cmp(opGTE, SemVer{})}, nil
+		}
+		return []comparator{cmp(opGTE, v), cmp(opLT, p.next())}, nil
+
+	case "!=":
+		if !full {
+			return nil, fmt.Errorf("invalid term %q: != requires a full version", term)
+		}
+		return []comparator{cmp(opNEQ, v)}, nil
+
+	case ">":
+		if p.parts == 0 {
+			return nil, fmt.Errorf("invalid term %q: no version can satisfy it", term)
+		}
+		if full {
+			return []comparator{cmp(opGT, v)}, nil
+		}
+		return []comparator{cmp(opGTE, p.next())}, nil
+
+	case ">=":
+		return []comparator{cmp(opGTE, v)}, nil
+
+	case "<":
+		if p.parts == 0 {
+			return nil, fmt.Errorf("invalid term %q: no version can satisfy it", term)
+		}
+		return []comparator{cmp(opLT, v)}, nil
+
+	case "<=":
+		if p.parts == 0 {
+			return []comparator{cmp(opGTE, SemVer{})}, nil
+		}
+		if full {
+			return []comparator{cmp(opLTE, v)}, nil
+		}
+		return []comparator{cmp(opLT, p.next())}, nil
+
+	case "~":
+		if p.parts == 0 {
+			return []comparator{cmp(opGTE, SemVer{})}, nil
+		}
+		upper := SemVer{Major: p.major, Minor: p.minor + 1}
+		if p.parts == 1 {
+			upper = SemVer{Major: p.major + 1}
+		}
+		return []comparator{cmp(opGTE, v), cmp(opLT, upper)}, nil
+
+	case "^":
+		if p.parts == 0 {
+			return []comparator{cmp(opGTE, SemVer{})}, nil
+		}
+
+		var upper SemVer
+		switch {
+		case p.major > 0 || p.parts == 1:
+			upper = SemVer{Major: p.major + 1}
+		case p.minor > 0 || p.parts == 2:
+			upper = SemVer{Major: p.major, Minor: p.minor + 1}
+		default:
+			upper = SemVer{Major: p.major, Minor: p.minor, Patch: p.patch + 1}
+		}
+		return []comparator{cmp(opGTE, v), cmp(opLT, upper)}, nil
+
+	default:
+		return nil, fmt.Errorf("invalid term %q: unknown operator %q", term, op)
+	}
+}
@@ -0,0 +1,240 @@
+package semver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewConstraint(t *testing.T) {
+	tests := []struct {
+		name          string
+		constraint    string
+		expectedError string
+	}{
+		{
+			name:          "Empty",
+			constraint:    "",
+			expectedError: "empty constraint",
+		},
+		{
+			name:          "EmptyRange",
+			constraint:    "1.x || ",
+			expectedError: "empty range",
+		},
+		{
+			name:          "InvalidVersion",
+			constraint:    ">=1.a.0",
+			expectedError: `invalid version "1.a.0"`,
+		},
+		{
+			name:          "TooManyNumbers",
+			constraint:    "1.2.3.4",
+			expectedError: `invalid version "1.2.3.4"`,
+		},
+		{
+			name:          "NumberAfterWildcard",
+			constraint:    "1.x.3",
+			expectedError: `invalid version "1.x.3"`,
+		},
+		{
+			name:          "PartialPrerelease",
+			constraint:    "1.2-rc.1",
+			expectedError: "pre-release and metadata require a full version",
+		},
+		{
+			name:          "UnknownOperator",
+			constraint:    "=>1.2.3",
+			expectedError: `unknown operator "=>"`,
+		},
+		{
+			name:          "PartialNotEqual",
+			constraint:    "!=1.2",
+			expectedError: "!= requires a full version",
+		},
+		{
+			name:          "Unsatisfiable",
+			constraint:    "<*",
+			expectedError: "no version can satisfy it",
+		},
+		{
+			name:       "Exact",
+			constraint: "1.2.3",
+		},
+		{
+			name:       "Range",
+			constraint: ">=1.2.0 <2.0.0",
+		},
+		{
+			name:       "CommaSeparatedRange",
+			constraint: ">= 1.2.0, < 2.0.0",
+		},
+		{
+			name:       "Alternatives",
+			constraint: "1.x || 2.1.*",
+		},
+		{
+			name:       "Tilde",
+			constraint: "~1.4",
+		},
+		{
+			name:       "Caret",
+			constraint: "^0.3.1",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := NewConstraint(tc.constraint)
+
+			if tc.expectedError != "" {
+				assert.Nil(t, c)
+				assert.Contains(t, err.Error(), tc.expectedError)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.constraint, c.String())
+			}
+		})
+	}
+}
+
+func TestMustConstraint(t *testing.T) {
+	assert.NotPanics(t, func() {
+		MustConstraint("^1.2.3")
+	})
+
+	assert.Panics(t, func() {
+		MustConstraint("invalid")
+	})
+}
+
+func TestConstraintCheck(t *testing.T) {
+	tests := []struct {
+		constraint string
+		version    SemVer
+		expected   bool
+	}{
+		{"*", SemVer{Major: 3, Minor: 1, Patch: 4}, true},
+		{"*", SemVer{Major: 3, Minor: 1, Patch: 4, Prerelease: []string{"rc", "1"}}, false},
+		{"1.2.3", SemVer{Major: 1, Minor: 2, Patch: 3}, true},
+		{"=v1.2.3", SemVer{Major: 1, Minor: 2, Patch: 3, Metadata: []string{"20200820"}}, true},
+		{"1.2.3", SemVer{Major: 1, Minor: 2, Patch: 4}, false},
+		{"!=1.2.3", SemVer{Major: 1, Minor: 2, Patch: 4}, true},
+		{"!=1.2.3", SemVer{Major: 1, Minor: 2, Patch: 3}, false},
+		{">1.2.3", SemVer{Major: 1, Minor: 2, Patch: 4}, true},
+		{">1.2.3", SemVer{Major: 1, Minor: 2, Patch: 3}, false},
+		{">1.2", SemVer{Major: 1, Minor: 2, Patch: 9}, false},
+		{">1.2", SemVer{Major: 1, Minor: 3, Patch: 0}, true},
+		{">=1.2", SemVer{Major: 1, Minor: 2, Patch: 0}, true},
+		{"<1.2", SemVer{Major: 1, Minor: 1, Patch: 9}, true},
+		{"<1.2", SemVer{Major: 1, Minor: 2, Patch: 0}, false},
+		{"<=1.2", SemVer{Major: 1, Minor: 2, Patch: 9}, true},
+		{"<=1.2", SemVer{Major: 1, Minor: 3, Patch: 0}, false},
+		{"<=1.2.3", SemVer{Major: 1, Minor: 2, Patch: 3}, true},
+		{">=1.2.0 <2.0.0", SemVer{Major: 1, Minor: 9, Patch: 9}, true},
+		{">=1.2.0 <2.0.0", SemVer{Major: 2, Minor: 0, Patch: 0}, false},
+		{">=1.2.0 <2.0.0", SemVer{Major: 1, Minor: 1, Patch: 0}, false},
+		{">=1.2.0 <2.0.0", SemVer{Major: 2, Minor: 0, Patch: 0, Prerelease: []string{"rc", "1"}}, false},
+		{"~1.4", SemVer{Major: 1, Minor: 4, Patch: 0}, true},
+		{"~1.4", SemVer{Major: 1, Minor: 4, Patch: 11}, true},
+		{"~1.4", SemVer{Major: 1, Minor: 5, Patch: 0}, false},
+		{"~1.4.2", SemVer{Major: 1, Minor: 4, Patch: 1}, false},
+		{"~1", SemVer{Major: 1, Minor: 9, Patch: 0}, true},
+		{"~1", SemVer{Major: 2, Minor: 0, Patch: 0}, false},
+		{"^1.2.3", SemVer{Major: 1, Minor: 9, Patch: 0}, true},
+		{"^1.2.3", SemVer{Major: 2, Minor: 0, Patch: 0}, false},
+		{"^0.3.1", SemVer{Major: 0, Minor: 3, Patch: 7}, true},
+		{"^0.3.1", SemVer{Major: 0, Minor: 3, Patch: 0}, false},
+		{"^0.3.1", SemVer{Major: 0, Minor: 4, Patch: 0}, false},
+		{"^0.0.3", SemVer{Major: 0, Minor: 0, Patch: 3}, true},
+		{"^0.0.3", SemVer{Major: 0, Minor: 0, Patch: 4}, false},
+		{"^0.0", SemVer{Major: 0, Minor: 0, Patch: 9}, true},
+		{"^0.0", SemVer{Major: 0, Minor: 1, Patch: 0}, false},
+		{"^0.x", SemVer{Major: 0, Minor: 9, Patch: 0}, true},
+		{"^0.x", SemVer{Major: 1, Minor: 0, Patch: 0}, false},
+		{"1.x || 2.1.*", SemVer{Major: 1, Minor: 7, Patch: 0}, true},
+		{"1.x || 2.1.*", SemVer{Major: 2, Minor: 1, Patch: 3}, true},
+		{"1.x || 2.1.*", SemVer{Major: 2, Minor: 2, Patch: 0}, false},
+		{"1.12.x", SemVer{Major: 1, Minor: 12, Patch: 17}, true},
+		{"1.15", SemVer{Major: 1, Minor: 15, Patch: 2}, true},
+		{"1.15", SemVer{Major: 1, Minor: 16, Patch: 0}, false},
+		{">=1.3.0-beta.1", SemVer{Major: 1, Minor: 3, Patch: 0, Prerelease: []string{"rc", "1"}}, true},
+		{">=1.3.0-beta.1", SemVer{Major: 1, Minor: 3, Patch: 0, Prerelease: []string{"alpha", "1"}}, false},
+		{">=1.3.0-beta.1", SemVer{Major: 1, Minor: 4, Patch: 0, Prerelease: []string{"rc", "1"}}, false},
+		{">=1.3.0-beta.1", SemVer{Major: 1, Minor: 4, Patch: 0}, true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.constraint+"/"+tc.version.String(), func(t *testing.T) {
+			c := MustConstraint(tc.constraint)
+			assert.Equal(t, tc.expected, c.Check(tc.version))
+		})
+	}
+}
+
+func TestConstraintValidate(t *testing.T) {
+	tests := []struct {
+		name           string
+		constraint     string
+		version        SemVer
+		expectedOK     bool
+		expectedErrors []string
+	}{
+		{
+			name:       "Satisfied",
+			constraint: ">=1.2.0 <2.0.0",
+			version:    SemVer{Major: 1, Minor: 4, Patch: 7},
+			expectedOK: true,
+		},
+		{
+			name:       "LowerBound",
+			constraint: ">=1.2.0 <2.0.0",
+			version:    SemVer{Major: 1, Minor: 1, Patch: 0},
+			expectedOK: false,
+			expectedErrors: []string{
+				`1.1.0 does not satisfy ">=1.2.0": 1.1.0 is less than 1.2.0`,
+			},
+		},
+		{
+			name:       "ExpandedTerm",
+			constraint: "~1.4",
+			version:    SemVer{Major: 1, Minor: 5, Patch: 0},
+			expectedOK: false,
+			expectedErrors: []string{
+				`1.5.0 does not satisfy "~1.4": 1.5.0 is greater than or equal to 1.5.0`,
+			},
+		},
+		{
+			name:       "Alternatives",
+			constraint: "1.x || 2.1.*",
+			version:    SemVer{Major: 2, Minor: 2, Patch: 0},
+			expectedOK: false,
+			expectedErrors: []string{
+				`2.2.0 does not satisfy "1.x": 2.2.0 is greater than or equal to 2.0.0`,
+				`2.2.0 does not satisfy "2.1.*": 2.2.0 is greater than or equal to 2.2.0`,
+			},
+		},
+		{
+			name:       "Prerelease",
+			constraint: ">=1.2.0 <2.0.0",
+			version:    SemVer{Major: 1, Minor: 3, Patch: 0, Prerelease: []string{"rc", "1"}},
+			expectedOK: false,
+			expectedErrors: []string{
+				`1.3.0-rc.1 does not satisfy ">=1.2.0 <2.0.0": pre-release versions of 1.3.0 are not included`,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c := MustConstraint(tc.constraint)
+			ok, errs := c.Validate(tc.version)
+
+			assert.Equal(t, tc.expectedOK, ok)
+			assert.Len(t, errs, len(tc.expectedErrors))
+			for i, err := range errs {
+				assert.EqualError(t, err, tc.expectedErrors[i])
+			}
+		})
+	}
+}
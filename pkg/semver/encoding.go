@@ -0,0 +1,84 @@
+package semver
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// MarshalText implements the encoding.TextMarshaler interface.
+func (v SemVer) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface.
+func (v *SemVer) UnmarshalText(text []byte) error {
+	return v.decode(string(text))
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (v SemVer) MarshalJSON() ([]byte, error) {
+	return json.Marshal(v.String())
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+// A JSON null is a no-op.
+func (v *SemVer) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	return v.decode(s)
+}
+
+// MarshalYAML implements the yaml.Marshaler interface.
+func (v SemVer) MarshalYAML() (interface{}, error) {
+	return v.String(), nil
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (v *SemVer) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+
+	return v.decode(s)
+}
+
+// Scan implements the sql.Scanner interface.
+// A NULL value is scanned into a zero semantic version.
+func (v *SemVer) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case nil:
+		*v = SemVer{}
+		return nil
+	case string:
+		return v.decode(s)
+	case []byte:
+		return v.decode(string(s))
+	default:
+		return fmt.Errorf("cannot scan %T into semantic version", src)
+	}
+}
+
+// Value implements the driver.Valuer interface.
+func (v SemVer) Value() (driver.Value, error) {
+	return v.String(), nil
+}
+
+func (v *SemVer) decode(s string) error {
+	semver, err := Parse(s)
+	if err != nil {
+		return err
+	}
+
+	*v = semver
+
+	return nil
+}
@@ -0,0 +1,224 @@
+package semver
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+type document struct {
+	Name    string `json:"name" yaml:"name"`
+	Version SemVer `json:"version" yaml:"version"`
+}
+
+func TestText(t *testing.T) {
+	tests := []struct {
+		name           string
+		text           string
+		expectedSemVer SemVer
+		expectedError  string
+	}{
+		{
+			name:          "Invalid",
+			text:          "1.2",
+			expectedError: `invalid semantic version "1.2": unexpected end of input at position 3`,
+		},
+		{
+			name: "OK",
+			text: "0.2.7-rc.1+20200820",
+			expectedSemVer: SemVer{
+				Major:      0,
+				Minor:      2,
+				Patch:      7,
+				Prerelease: []string{"rc", "1"},
+				Metadata:   []string{"20200820"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var v SemVer
+			err := v.UnmarshalText([]byte(tc.text))
+
+			if tc.expectedError != "" {
+				assert.EqualError(t, err, tc.expectedError)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedSemVer, v)
+
+				text, err := v.MarshalText()
+				assert.NoError(t, err)
+				assert.Equal(t, tc.text, string(text))
+			}
+		})
+	}
+}
+
+func TestJSON(t *testing.T) {
+	tests := []struct {
+		name             string
+		data             string
+		expectedDocument document
+		expectedError    string
+	}{
+		{
+			name:          "NotString",
+			data:          `{"name": "cherry", "version": 1}`,
+			expectedError: "cannot unmarshal number",
+		},
+		{
+			name:          "Invalid",
+			data:          `{"name": "cherry", "version": "1.2.3-"}`,
+			expectedError: `invalid semantic version "1.2.3-": empty identifier at position 6`,
+		},
+		{
+			name: "Null",
+			data: `{"name":"cherry","version":null}`,
+			expectedDocument: document{
+				Name: "cherry",
+			},
+		},
+		{
+			name: "OK",
+			data: `{"name":"cherry","version":"1.3.0-rc.1"}`,
+			expectedDocument: document{
+				Name: "cherry",
+				Version: SemVer{
+					Major:      1,
+					Minor:      3,
+					Patch:      0,
+					Prerelease: []string{"rc", "1"},
+				},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var doc document
+			err := json.Unmarshal([]byte(tc.data), &doc)
+
+			if tc.expectedError != "" {
+				assert.Contains(t, err.Error(), tc.expectedError)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedDocument, doc)
+
+				data, err := json.Marshal(doc)
+				assert.NoError(t, err)
+				assert.JSONEq(t, `{"name":"cherry","version":"`+doc.Version.String()+`"}`, string(data))
+			}
+		})
+	}
+}
+
+func TestYAML(t *testing.T) {
+	tests := []struct {
+		name             string
+		data             string
+		expectedDocument document
+		expectedError    string
+	}{
+		{
+			name:          "NotString",
+			data:          "name: cherry\nversion: [1, 2, 3]\n",
+			expectedError: "cannot unmarshal !!seq",
+		},
+		{
+			name:          "Invalid",
+			data:          "name: cherry\nversion: 1.2\n",
+			expectedError: `invalid semantic version "1.2": unexpected end of input at position 3`,
+		},
+		{
+			name: "OK",
+			data: "name: cherry\nversion: 1.3.0+sha.abcdeff\n",
+			expectedDocument: document{
+				Name: "cherry",
+				Version: SemVer{
+					Major:    1,
+					Minor:    3,
+					Patch:    0,
+					Metadata: []string{"sha", "abcdeff"},
+				},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var doc document
+			err := yaml.Unmarshal([]byte(tc.data), &doc)
+
+			if tc.expectedError != "" {
+				assert.Contains(t, err.Error(), tc.expectedError)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedDocument, doc)
+
+				data, err := yaml.Marshal(doc)
+				assert.NoError(t, err)
+				assert.Equal(t, tc.data, string(data))
+			}
+		})
+	}
+}
+
+func TestScan(t *testing.T) {
+	tests := []struct {
+		name           string
+		src            interface{}
+		expectedSemVer SemVer
+		expectedError  string
+	}{
+		{
+			name:           "Null",
+			src:            nil,
+			expectedSemVer: SemVer{},
+		},
+		{
+			name:          "UnsupportedType",
+			src:           int64(1),
+			expectedError: "cannot scan int64 into semantic version",
+		},
+		{
+			name:          "Invalid",
+			src:           "v1",
+			expectedError: `invalid semantic version "v1": unexpected end of input at position 2`,
+		},
+		{
+			name:           "String",
+			src:            "v0.2.7",
+			expectedSemVer: SemVer{Major: 0, Minor: 2, Patch: 7},
+		},
+		{
+			name:           "Bytes",
+			src:            []byte("0.2.7-beta"),
+			expectedSemVer: SemVer{Major: 0, Minor: 2, Patch: 7, Prerelease: []string{"beta"}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			v := SemVer{Major: 9}
+			err := v.Scan(tc.src)
+
+			if tc.expectedError != "" {
+				assert.EqualError(t, err, tc.expectedError)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedSemVer, v)
+			}
+		})
+	}
+}
+
+func TestValue(t *testing.T) {
+	v := SemVer{Major: 0, Minor: 2, Patch: 7, Prerelease: []string{"rc", "1"}}
+	value, err := v.Value()
+
+	assert.NoError(t, err)
+	assert.Equal(t, "0.2.7-rc.1", value)
+}
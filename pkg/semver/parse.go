@@ -0,0 +1,208 @@
+package semver
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrEmpty is returned when the input semantic version is empty.
+	ErrEmpty = errors.New("empty version")
+	// ErrUnexpectedEnd is returned when the input semantic version ends before the version core is complete.
+	ErrUnexpectedEnd = errors.New("unexpected end of input")
+	// ErrInvalidCharacter is returned when the input semantic version has a character that is not allowed.
+	ErrInvalidCharacter = errors.New("invalid character")
+	// ErrLeadingZero is returned when a numeric identifier has a leading zero.
+	ErrLeadingZero = errors.New("numeric identifier with leading zero")
+	// ErrEmptyIdentifier is returned when a pre-release or metadata identifier is empty.
+	ErrEmptyIdentifier = errors.New("empty identifier")
+	// ErrOverflow is returned when a major, minor, or patch number does not fit in a uint.
+	ErrOverflow = errors.New("number out of range")
+)
+
+// ParseError describes a problem parsing a semantic version string.
+type ParseError struct {
+	// Input is the semantic version string being parsed.
+	Input string
+	// Pos is the byte offset in the input at which the error occurred.
+	Pos int
+	// Err is the reason for the error, which is one of the Err* variables in this package.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("invalid semantic version %q: %s at position %d", e.Input, e.Err, e.Pos)
+}
+
+// Unwrap returns the reason for the error, so errors.Is can be used for checking it.
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// Parse reads a semantic version string and returns a SemVer.
+// An optional v prefix is allowed (i.e. v1.2.3).
+// If the input is not a valid semantic version, the returned error will be a *ParseError.
+// See https://semver.org/#backusnaur-form-grammar-for-valid-semver-versions
+func Parse(semver string) (SemVer, error) {
+	var v SemVer
+	var err error
+
+	if semver == "" {
+		return SemVer{}, &ParseError{Input: semver, Pos: 0, Err: ErrEmpty}
+	}
+
+	pos := 0
+	if semver[0] == 'v' {
+		pos++
+	}
+
+	if v.Major, pos, err = parseNumber(semver, pos); err != nil {
+		return SemVer{}, err
+	}
+
+	if pos, err = expect(semver, pos, '.'); err != nil {
+		return SemVer{}, err
+	}
+
+	if v.Minor, pos, err = parseNumber(semver, pos); err != nil {
+		return SemVer{}, err
+	}
+
+	if pos, err = expect(semver, pos, '.'); err != nil {
+		return SemVer{}, err
+	}
+
+	if v.Patch, pos, err = parseNumber(semver, pos); err != nil {
+		return SemVer{}, err
+	}
+
+	if pos < len(semver) && semver[pos] == '-' {
+		if v.Prerelease, pos, err = parseIdentifiers(semver, pos+1, true); err != nil {
+			return SemVer{}, err
+		}
+	}
+
+	if pos < len(semver) && semver[pos] == '+' {
+		if v.Metadata, pos, err = parseIdentifiers(semver, pos+1, false); err != nil {
+			return SemVer{}, err
+		}
+	}
+
+	if pos < len(semver) {
+		return SemVer{}, &ParseError{Input: semver, Pos: pos, Err: ErrInvalidCharacter}
+	}
+
+	return v, nil
+}
+
+// MustParse is like Parse but panics if the semantic version cannot be parsed.
+// It simplifies the initialization of variables holding semantic versions.
+func MustParse(semver string) SemVer {
+	v, err := Parse(semver)
+	if err != nil {
+		panic(err)
+	}
+
+	return v
+}
+
+func expect(s string, pos int, c byte) (int, error) {
+	if pos == len(s) {
+		return pos, &ParseError{Input: s, Pos: pos, Err: ErrUnexpectedEnd}
+	}
+
+	if s[pos] != c {
+		return pos, &ParseError{Input: s, Pos: pos, Err: ErrInvalidCharacter}
+	}
+
+	return pos + 1, nil
+}
+
+// parseNumber parses a major, minor, or patch number starting at pos.
+func parseNumber(s string, pos int) (uint, int, error) {
+	const maxUint = ^uint(0)
+
+	start := pos
+	var n uint
+
+	for ; pos < len(s) && isDigit(s[pos]); pos++ {
+		d := uint(s[pos] - '0')
+		if n > (maxUint-d)/10 {
+			return 0, start, &ParseError{Input: s, Pos: start, Err: ErrOverflow}
+		}
+		n = n*10 + d
+	}
+
+	switch {
+	case pos == start && pos == len(s):
+		return 0, pos, &ParseError{Input: s, Pos: pos, Err: ErrUnexpectedEnd}
+	case pos == start:
+		return 0, pos, &ParseError{Input: s, Pos: pos, Err: ErrInvalidCharacter}
+	case pos-start > 1 && s[start] == '0':
+		return 0, start, &ParseError{Input: s, Pos: start, Err: ErrLeadingZero}
+	}
+
+	return n, pos, nil
+}
+
+// parseIdentifiers parses a dot-separated list of identifiers starting at pos.
+// Pre-release identifiers end at a + character and their numeric identifiers cannot have leading zeros.
+// The identifiers are sub-strings of the input, so no copy of the input is made.
+func parseIdentifiers(s string, pos int, prerelease bool) ([]string, int, error) {
+	end := len(s)
+	if prerelease {
+		for i := pos; i < len(s); i++ {
+			if s[i] == '+' {
+				end = i
+				break
+			}
+		}
+	}
+
+	n := 1
+	for i := pos; i < end; i++ {
+		if s[i] == '.' {
+			n++
+		}
+	}
+
+	ids := make([]string, 0, n)
+
+	for {
+		start := pos
+		numeric := true
+
+		for ; pos < end && s[pos] != '.'; pos++ {
+			c := s[pos]
+			switch {
+			case isDigit(c):
+			case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c == '-':
+				numeric = false
+			default:
+				return nil, pos, &ParseError{Input: s, Pos: pos, Err: ErrInvalidCharacter}
+			}
+		}
+
+		if pos == start {
+			return nil, pos, &ParseError{Input: s, Pos: pos, Err: ErrEmptyIdentifier}
+		}
+
+		if prerelease && numeric && pos-start > 1 && s[start] == '0' {
+			return nil, start, &ParseError{Input: s, Pos: start, Err: ErrLeadingZero}
+		}
+
+		ids = append(ids, s[start:pos])
+
+		if pos == end {
+			return ids, pos, nil
+		}
+
+		// Skip the dot separator
+		pos++
+	}
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
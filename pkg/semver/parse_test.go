@@ -0,0 +1,259 @@
+package semver
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name           string
+		semver         string
+		expectedSemver SemVer
+		expectedError  error
+		expectedPos    int
+	}{
+		{
+			name:           "Empty",
+			semver:         "",
+			expectedSemver: SemVer{},
+			expectedError:  ErrEmpty,
+			expectedPos:    0,
+		},
+		{
+			name:           "NoMinor",
+			semver:         "1",
+			expectedSemver: SemVer{},
+			expectedError:  ErrUnexpectedEnd,
+			expectedPos:    1,
+		},
+		{
+			name:           "NoPatch",
+			semver:         "0.1",
+			expectedSemver: SemVer{},
+			expectedError:  ErrUnexpectedEnd,
+			expectedPos:    3,
+		},
+		{
+			name:           "InvalidMajor",
+			semver:         "X.1.0",
+			expectedSemver: SemVer{},
+			expectedError:  ErrInvalidCharacter,
+			expectedPos:    0,
+		},
+		{
+			name:           "InvalidMinor",
+			semver:         "0.Y.0",
+			expectedSemver: SemVer{},
+			expectedError:  ErrInvalidCharacter,
+			expectedPos:    2,
+		},
+		{
+			name:           "InvalidPatch",
+			semver:         "0.1.Z",
+			expectedSemver: SemVer{},
+			expectedError:  ErrInvalidCharacter,
+			expectedPos:    4,
+		},
+		{
+			name:           "InvalidPrerelease",
+			semver:         "0.1.0-",
+			expectedSemver: SemVer{},
+			expectedError:  ErrEmptyIdentifier,
+			expectedPos:    6,
+		},
+		{
+			name:           "InvalidPrerelease",
+			semver:         "0.1.0-beta.",
+			expectedSemver: SemVer{},
+			expectedError:  ErrEmptyIdentifier,
+			expectedPos:    11,
+		},
+		{
+			name:           "InvalidMetadata",
+			semver:         "0.1.0-beta.1+",
+			expectedSemver: SemVer{},
+			expectedError:  ErrEmptyIdentifier,
+			expectedPos:    13,
+		},
+		{
+			name:           "InvalidMetadata",
+			semver:         "0.1.0-beta.1+20200818.",
+			expectedSemver: SemVer{},
+			expectedError:  ErrEmptyIdentifier,
+			expectedPos:    22,
+		},
+		{
+			name:          "LeadingZeroMajor",
+			semver:        "01.1.0",
+			expectedError: ErrLeadingZero,
+			expectedPos:   0,
+		},
+		{
+			name:          "LeadingZeroPatch",
+			semver:        "v1.1.00",
+			expectedError: ErrLeadingZero,
+			expectedPos:   5,
+		},
+		{
+			name:          "LeadingZeroPrerelease",
+			semver:        "1.1.0-rc.01",
+			expectedError: ErrLeadingZero,
+			expectedPos:   9,
+		},
+		{
+			name:          "Overflow",
+			semver:        "1.99999999999999999999999.0",
+			expectedError: ErrOverflow,
+			expectedPos:   2,
+		},
+		{
+			name:          "InvalidPrereleaseCharacter",
+			semver:        "1.1.0-rc_1",
+			expectedError: ErrInvalidCharacter,
+			expectedPos:   8,
+		},
+		{
+			name:          "InvalidMetadataCharacter",
+			semver:        "1.1.0+sha+abcdeff",
+			expectedError: ErrInvalidCharacter,
+			expectedPos:   9,
+		},
+		{
+			name:          "TrailingCharacter",
+			semver:        "1.1.0 ",
+			expectedError: ErrInvalidCharacter,
+			expectedPos:   5,
+		},
+		{
+			name:   "LeadingZeroMetadata",
+			semver: "1.1.0-rc.0+001",
+			expectedSemver: SemVer{
+				Major:      1,
+				Minor:      1,
+				Patch:      0,
+				Prerelease: []string{"rc", "0"},
+				Metadata:   []string{"001"},
+			},
+		},
+		{
+			name:   "Release",
+			semver: "0.1.0",
+			expectedSemver: SemVer{
+				Major: 0,
+				Minor: 1,
+				Patch: 0,
+			},
+		},
+		{
+			name:   "Release",
+			semver: "v0.1.0",
+			expectedSemver: SemVer{
+				Major: 0,
+				Minor: 1,
+				Patch: 0,
+			},
+		},
+		{
+			name:   "WithPrerelease",
+			semver: "0.1.0-beta",
+			expectedSemver: SemVer{
+				Major:      0,
+				Minor:      1,
+				Patch:      0,
+				Prerelease: []string{"beta"},
+			},
+		},
+		{
+			name:   "WithPrerelease",
+			semver: "v0.1.0-rc.1",
+			expectedSemver: SemVer{
+				Major:      0,
+				Minor:      1,
+				Patch:      0,
+				Prerelease: []string{"rc", "1"},
+			},
+		},
+		{
+			name:   "WithMetadata",
+			semver: "0.1.0+20200820",
+			expectedSemver: SemVer{
+				Major:    0,
+				Minor:    1,
+				Patch:    0,
+				Metadata: []string{"20200820"},
+			},
+		},
+		{
+			name:   "WithMetadata",
+			semver: "v0.1.0+sha.abcdeff",
+			expectedSemver: SemVer{
+				Major:    0,
+				Minor:    1,
+				Patch:    0,
+				Metadata: []string{"sha", "abcdeff"},
+			},
+		},
+		{
+			name:   "WithPrereleaseAndMetadata",
+			semver: "0.1.0-beta+20200820",
+			expectedSemver: SemVer{
+				Major:      0,
+				Minor:      1,
+				Patch:      0,
+				Prerelease: []string{"beta"},
+				Metadata:   []string{"20200820"},
+			},
+		},
+		{
+			name:   "WithPrereleaseAndMetadata",
+			semver: "v0.1.0-rc.1+sha.abcdeff.20200820",
+			expectedSemver: SemVer{
+				Major:      0,
+				Minor:      1,
+				Patch:      0,
+				Prerelease: []string{"rc", "1"},
+				Metadata:   []string{"sha", "abcdeff", "20200820"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			semver, err := Parse(tc.semver)
+
+			if tc.expectedError != nil {
+				assert.True(t, errors.Is(err, tc.expectedError))
+				assert.Equal(t, tc.expectedPos, err.(*ParseError).Pos)
+				assert.Equal(t, SemVer{}, semver)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedSemver, semver)
+			}
+		})
+	}
+}
+
+func TestMustParse(t *testing.T) {
+	assert.Equal(t, SemVer{Major: 0, Minor: 2, Patch: 7}, MustParse("v0.2.7"))
+
+	assert.Panics(t, func() {
+		MustParse("0.2")
+	})
+}
+
+func TestParseError(t *testing.T) {
+	_, err := Parse("1.01.0")
+
+	assert.EqualError(t, err, `invalid semantic version "1.01.0": numeric identifier with leading zero at position 2`)
+	assert.True(t, errors.Is(err, ErrLeadingZero))
+	assert.Equal(t, &ParseError{Input: "1.01.0", Pos: 2, Err: ErrLeadingZero}, err)
+}
+
+func BenchmarkParse(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_, _ = Parse("v1.3.0-rc.1+sha.abcdeff.20200820")
+	}
+}
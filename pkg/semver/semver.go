@@ -1,8 +1,8 @@
 package semver
 
 import (
+	"errors"
 	"fmt"
-	"regexp"
 	"strconv"
 	"strings"
 )
@@ -28,41 +28,6 @@ type SemVer struct {
 	Metadata   []string
 }
 
-// Parse reads a semantic version string and returns a SemVer.
-// If the second return value is false, it implies that the input semver was incorrect.
-func Parse(semver string) (SemVer, bool) {
-	var major, minor, patch uint64
-	var prerelease, metadata []string
-
-	// Make sure the string is a valid semantic version
-	if re := regexp.MustCompile(`^v?[0-9]+\.[0-9]+\.[0-9]+(\-[0-9A-Za-z-]+(\.[0-9A-Za-z-]+)*)?(\+[0-9A-Za-z-]+(\.[0-9A-Za-z-]+)*)?$`); !re.MatchString(semver) {
-		return SemVer{}, false
-	}
-
-	re := regexp.MustCompile(`^v?([0-9]+)\.([0-9]+)\.([0-9]+)(\-[\.[0-9A-Za-z-]+)?(\+[\.[0-9A-Za-z-]*)?$`)
-	subs := re.FindStringSubmatch(semver)
-
-	major, _ = strconv.ParseUint(subs[1], 10, 64)
-	minor, _ = strconv.ParseUint(subs[2], 10, 64)
-	patch, _ = strconv.ParseUint(subs[3], 10, 64)
-
-	if subs[4] != "" {
-		prerelease = strings.Split(subs[4][1:], ".")
-	}
-
-	if subs[5] != "" {
-		metadata = strings.Split(subs[5][1:], ".")
-	}
-
-	return SemVer{
-		Major:      uint(major),
-		Minor:      uint(minor),
-		Patch:      uint(patch),
-		Prerelease: prerelease,
-		Metadata:   metadata,
-	}, true
-}
-
 // AddPrerelease adds a new pre-release identifier to the current semantic version.
 // This is a shortcut for v.Prerelease = append(v.Prerelease, s...).
 func (v *SemVer) AddPrerelease(s ...string) {
@@ -110,6 +75,139 @@ func (v SemVer) Release(version Version) SemVer {
 	}
 }
 
+// Compare compares two semantic versions according to the precedence rules of semantic versioning.
+// It returns -1 if v < u, 0 if v == u, and +1 if v > u.
+// Metadata identifiers are ignored when determining precedence.
+// See https://semver.org/#spec-item-11
+func (v SemVer) Compare(u SemVer) int {
+	if c := compareUint(v.Major, u.Major); c != 0 {
+		return c
+	}
+
+	if c := compareUint(v.Minor, u.Minor); c != 0 {
+		return c
+	}
+
+	if c := compareUint(v.Patch, u.Patch); c != 0 {
+		return c
+	}
+
+	// A pre-release version has a lower precedence than the normal version
+	switch {
+	case len(v.Prerelease) == 0 && len(u.Prerelease) == 0:
+		return 0
+	case len(v.Prerelease) == 0:
+		return 1
+	case len(u.Prerelease) == 0:
+		return -1
+	}
+
+	for i := 0; i < len(v.Prerelease) && i < len(u.Prerelease); i++ {
+		if c := compareIdentifier(v.Prerelease[i], u.Prerelease[i]); c != 0 {
+			return c
+		}
+	}
+
+	// A larger set of pre-release identifiers has a higher precedence than a smaller set
+	return compareUint(uint(len(v.Prerelease)), uint(len(u.Prerelease)))
+}
+
+// LessThan returns true if v has a lower precedence than u.
+func (v SemVer) LessThan(u SemVer) bool {
+	return v.Compare(u) < 0
+}
+
+// GreaterThan returns true if v has a higher precedence than u.
+func (v SemVer) GreaterThan(u SemVer) bool {
+	return v.Compare(u) > 0
+}
+
+// Equal returns true if v and u have the same precedence.
+// Metadata identifiers are ignored, so 1.0.0+20200820 is equal to 1.0.0+sha.abcdeff.
+func (v SemVer) Equal(u SemVer) bool {
+	return v.Compare(u) == 0
+}
+
+// NextRelease returns the next release version at the given level.
+// For a release version, this is equivalent to v.Next().Release(version).
+// For a pre-release version, the version core is reused if it is already at the given level.
+//
+//	1.2.0       --> 1.2.1 (patch), 1.3.0 (minor), 2.0.0 (major)
+//	1.3.0-rc.2  --> 1.3.0 (patch), 1.3.0 (minor), 2.0.0 (major)
+//	2.0.0-rc.1  --> 2.0.0 (patch), 2.0.0 (minor), 2.0.0 (major)
+func (v SemVer) NextRelease(version Version) SemVer {
+	if len(v.Prerelease) == 0 {
+		return v.Next().Release(version)
+	}
+
+	core := SemVer{
+		Major: v.Major,
+		Minor: v.Minor,
+		Patch: v.Patch,
+	}
+
+	switch version {
+	case Patch:
+		return core
+	case Minor:
+		if core.Patch == 0 {
+			return core
+		}
+		return core.Release(Minor)
+	case Major:
+		if core.Minor == 0 && core.Patch == 0 {
+			return core
+		}
+		return core.Release(Major)
+	default:
+		return SemVer{}
+	}
+}
+
+// NextPrerelease returns the next pre-release version for the given label (i.e. alpha, beta, or rc) at the given level.
+// If the current version is a pre-release of the same version core, the pre-release number is incremented
+// or the label is advanced; otherwise, a new pre-release is started for the next release version.
+//
+//	1.2.0        --> 1.2.1-rc.1 (patch), 1.3.0-rc.1 (minor)
+//	1.3.0-rc.1   --> 1.3.0-rc.2
+//	1.3.0-beta.4 --> 1.3.0-rc.1
+//
+// An error is returned if the label is invalid or the resulting version would not have a higher precedence
+// (i.e. 1.3.0-rc.2 --> 1.3.0-beta.1).
+func (v SemVer) NextPrerelease(version Version, label string) (SemVer, error) {
+	if !isAlphanumeric(label) {
+		return SemVer{}, fmt.Errorf("invalid pre-release label: %q", label)
+	}
+
+	if version != Patch && version != Minor && version != Major {
+		return SemVer{}, errors.New("invalid version level")
+	}
+
+	next := v.NextRelease(version)
+	sameCore := len(v.Prerelease) > 0 && next.Major == v.Major && next.Minor == v.Minor && next.Patch == v.Patch
+
+	if sameCore && v.Prerelease[0] == label && len(v.Prerelease) > 1 && isNumeric(v.Prerelease[1]) {
+		n, err := strconv.ParseUint(v.Prerelease[1], 10, 64)
+		if err != nil {
+			return SemVer{}, err
+		}
+		next.AddPrerelease(label, strconv.FormatUint(n+1, 10))
+	} else {
+		next.AddPrerelease(label, "1")
+	}
+
+	if sameCore && !next.GreaterThan(v) {
+		return SemVer{}, fmt.Errorf("cannot move from %s to a %s pre-release", v, label)
+	}
+
+	return next, nil
+}
+
+// IsPrerelease returns true if the semantic version has pre-release identifiers.
+func (v SemVer) IsPrerelease() bool {
+	return len(v.Prerelease) > 0
+}
+
 // String returns a semantic version string (also implements fmt.Stringer).
 func (v SemVer) String() string {
 	var tail string
@@ -124,3 +222,107 @@ func (v SemVer) String() string {
 
 	return fmt.Sprintf("%d.%d.%d%s", v.Major, v.Minor, v.Patch, tail)
 }
+
+func compareUint(a, b uint) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// compareIdentifier compares two pre-release identifiers.
+// Numeric identifiers are compared numerically and alphanumeric identifiers are compared lexically in ASCII sort order.
+// Numeric identifiers always have a lower precedence than alphanumeric identifiers.
+func compareIdentifier(a, b string) int {
+	aNum, bNum := isNumeric(a), isNumeric(b)
+
+	switch {
+	case aNum && bNum:
+		// Compare numeric identifiers without converting them, so arbitrarily large numbers are supported
+		if c := compareUint(uint(len(strings.TrimLeft(a, "0"))), uint(len(strings.TrimLeft(b, "0")))); c != 0 {
+			return c
+		}
+		return strings.Compare(strings.TrimLeft(a, "0"), strings.TrimLeft(b, "0"))
+	case aNum:
+		return -1
+	case bNum:
+		return 1
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+func isNumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for i := 0; i < len(s); i++ {
+		if !isDigit(s[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isIdentifier returns true if s is a non-empty identifier consisting of only [0-9A-Za-z-].
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !(c >= '0' && c <= '9') && !(c >= 'A' && c <= 'Z') && !(c >= 'a' && c <= 'z') && c != '-' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isAlphanumeric returns true if s is an identifier that is not numeric.
+func isAlphanumeric(s string) bool {
+	return isIdentifier(s) && !isNumeric(s)
+}
+
+// Collection is a list of semantic versions that implements sort.Interface.
+// Sorting a collection orders the versions by their precedence in ascending order.
+type Collection []SemVer
+
+// Len returns the number of semantic versions in the collection.
+func (c Collection) Len() int {
+	return len(c)
+}
+
+// Less reports whether the version at index i has a lower precedence than the version at index j.
+func (c Collection) Less(i, j int) bool {
+	return c[i].LessThan(c[j])
+}
+
+// Swap swaps the versions at indices i and j.
+func (c Collection) Swap(i, j int) {
+	c[i], c[j] = c[j], c[i]
+}
+
+// Max returns the version with the highest precedence in the collection.
+// If the collection is empty, the second return value will be false.
+func (c Collection) Max() (SemVer, bool) {
+	if len(c) == 0 {
+		return SemVer{}, false
+	}
+
+	max := c[0]
+	for _, v := range c[1:] {
+		if v.GreaterThan(max) {
+			max = v
+		}
+	}
+
+	return max, true
+}
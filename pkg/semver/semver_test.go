@@ -1,178 +1,12 @@
 package semver
 
 import (
+	"sort"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 )
 
-func TestParse(t *testing.T) {
-	tests := []struct {
-		name           string
-		semver         string
-		expectedSemver SemVer
-		expectedOK     bool
-	}{
-		{
-			name:           "Empty",
-			semver:         "",
-			expectedSemver: SemVer{},
-			expectedOK:     false,
-		},
-		{
-			name:           "NoMinor",
-			semver:         "1",
-			expectedSemver: SemVer{},
-			expectedOK:     false,
-		},
-		{
-			name:           "NoPatch",
-			semver:         "0.1",
-			expectedSemver: SemVer{},
-			expectedOK:     false,
-		},
-		{
-			name:           "InvalidMajor",
-			semver:         "X.1.0",
-			expectedSemver: SemVer{},
-			expectedOK:     false,
-		},
-		{
-			name:           "InvalidMinor",
-			semver:         "0.Y.0",
-			expectedSemver: SemVer{},
-			expectedOK:     false,
-		},
-		{
-			name:           "InvalidPatch",
-			semver:         "0.1.Z",
-			expectedSemver: SemVer{},
-			expectedOK:     false,
-		},
-		{
-			name:           "InvalidPrerelease",
-			semver:         "0.1.0-",
-			expectedSemver: SemVer{},
-			expectedOK:     false,
-		},
-		{
-			name:           "InvalidPrerelease",
-			semver:         "0.1.0-beta.",
-			expectedSemver: SemVer{},
-			expectedOK:     false,
-		},
-		{
-			name:           "InvalidMetadata",
-			semver:         "0.1.0-beta.1+",
-			expectedSemver: SemVer{},
-			expectedOK:     false,
-		},
-		{
-			name:           "InvalidMetadata",
-			semver:         "0.1.0-beta.1+20200818.",
-			expectedSemver: SemVer{},
-			expectedOK:     false,
-		},
-		{
-			name:   "Release",
-			semver: "0.1.0",
-			expectedSemver: SemVer{
-				Major: 0,
-				Minor: 1,
-				Patch: 0,
-			},
-			expectedOK: true,
-		},
-		{
-			name:   "Release",
-			semver: "v0.1.0",
-			expectedSemver: SemVer{
-				Major: 0,
-				Minor: 1,
-				Patch: 0,
-			},
-			expectedOK: true,
-		},
-		{
-			name:   "WithPrerelease",
-			semver: "0.1.0-beta",
-			expectedSemver: SemVer{
-				Major:      0,
-				Minor:      1,
-				Patch:      0,
-				Prerelease: []string{"beta"},
-			},
-			expectedOK: true,
-		},
-		{
-			name:   "WithPrerelease",
-			semver: "v0.1.0-rc.1",
-			expectedSemver: SemVer{
-				Major:      0,
-				Minor:      1,
-				Patch:      0,
-				Prerelease: []string{"rc", "1"},
-			},
-			expectedOK: true,
-		},
-		{
-			name:   "WithMetadata",
-			semver: "0.1.0+20200820",
-			expectedSemver: SemVer{
-				Major:    0,
-				Minor:    1,
-				Patch:    0,
-				Metadata: []string{"20200820"},
-			},
-			expectedOK: true,
-		},
-		{
-			name:   "WithMetadata",
-			semver: "v0.1.0+sha.abcdeff",
-			expectedSemver: SemVer{
-				Major:    0,
-				Minor:    1,
-				Patch:    0,
-				Metadata: []string{"sha", "abcdeff"},
-			},
-			expectedOK: true,
-		},
-		{
-			name:   "WithPrereleaseAndMetadata",
-			semver: "0.1.0-beta+20200820",
-			expectedSemver: SemVer{
-				Major:      0,
-				Minor:      1,
-				Patch:      0,
-				Prerelease: []string{"beta"},
-				Metadata:   []string{"20200820"},
-			},
-			expectedOK: true,
-		},
-		{
-			name:   "WithPrereleaseAndMetadata",
-			semver: "v0.1.0-rc.1+sha.abcdeff.20200820",
-			expectedSemver: SemVer{
-				Major:      0,
-				Minor:      1,
-				Patch:      0,
-				Prerelease: []string{"rc", "1"},
-				Metadata:   []string{"sha", "abcdeff", "20200820"},
-			},
-			expectedOK: true,
-		},
-	}
-
-	for _, tc := range tests {
-		t.Run(tc.name, func(t *testing.T) {
-			semver, ok := Parse(tc.semver)
-
-			assert.Equal(t, tc.expectedSemver, semver)
-			assert.Equal(t, tc.expectedOK, ok)
-		})
-	}
-}
-
 func TestAddPrerelease(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -426,6 +260,153 @@ func TestRelease(t *testing.T) {
 	}
 }
 
+func TestNextRelease(t *testing.T) {
+	tests := []struct {
+		semver          SemVer
+		version         Version
+		expectedRelease SemVer
+	}{
+		{
+			SemVer{Major: 1, Minor: 2, Patch: 0},
+			Patch,
+			SemVer{Major: 1, Minor: 2, Patch: 1},
+		},
+		{
+			SemVer{Major: 1, Minor: 2, Patch: 0},
+			Minor,
+			SemVer{Major: 1, Minor: 3, Patch: 0},
+		},
+		{
+			SemVer{Major: 1, Minor: 2, Patch: 0},
+			Major,
+			SemVer{Major: 2, Minor: 0, Patch: 0},
+		},
+		{
+			SemVer{Major: 1, Minor: 3, Patch: 0, Prerelease: []string{"rc", "2"}},
+			Patch,
+			SemVer{Major: 1, Minor: 3, Patch: 0},
+		},
+		{
+			SemVer{Major: 1, Minor: 3, Patch: 0, Prerelease: []string{"rc", "2"}},
+			Minor,
+			SemVer{Major: 1, Minor: 3, Patch: 0},
+		},
+		{
+			SemVer{Major: 1, Minor: 3, Patch: 0, Prerelease: []string{"rc", "2"}},
+			Major,
+			SemVer{Major: 2, Minor: 0, Patch: 0},
+		},
+		{
+			SemVer{Major: 1, Minor: 3, Patch: 1, Prerelease: []string{"beta", "1"}},
+			Minor,
+			SemVer{Major: 1, Minor: 4, Patch: 0},
+		},
+		{
+			SemVer{Major: 2, Minor: 0, Patch: 0, Prerelease: []string{"alpha"}, Metadata: []string{"20200820"}},
+			Major,
+			SemVer{Major: 2, Minor: 0, Patch: 0},
+		},
+		{
+			SemVer{Major: 2, Minor: 0, Patch: 0, Prerelease: []string{"alpha"}},
+			Version(-1),
+			SemVer{},
+		},
+	}
+
+	for _, tc := range tests {
+		assert.Equal(t, tc.expectedRelease, tc.semver.NextRelease(tc.version))
+	}
+}
+
+func TestNextPrerelease(t *testing.T) {
+	tests := []struct {
+		name               string
+		semver             SemVer
+		version            Version
+		label              string
+		expectedPrerelease SemVer
+		expectedError      string
+	}{
+		{
+			name:          "InvalidLabel",
+			semver:        SemVer{Major: 1, Minor: 2, Patch: 0},
+			version:       Patch,
+			label:         "rc.1",
+			expectedError: `invalid pre-release label: "rc.1"`,
+		},
+		{
+			name:          "NumericLabel",
+			semver:        SemVer{Major: 1, Minor: 2, Patch: 0},
+			version:       Patch,
+			label:         "1",
+			expectedError: `invalid pre-release label: "1"`,
+		},
+		{
+			name:          "InvalidVersion",
+			semver:        SemVer{Major: 1, Minor: 2, Patch: 0},
+			version:       Version(-1),
+			label:         "rc",
+			expectedError: "invalid version level",
+		},
+		{
+			name:               "FromRelease",
+			semver:             SemVer{Major: 1, Minor: 2, Patch: 0},
+			version:            Minor,
+			label:              "alpha",
+			expectedPrerelease: SemVer{Major: 1, Minor: 3, Patch: 0, Prerelease: []string{"alpha", "1"}},
+		},
+		{
+			name:               "SameLabel",
+			semver:             SemVer{Major: 1, Minor: 3, Patch: 0, Prerelease: []string{"rc", "1"}},
+			version:            Patch,
+			label:              "rc",
+			expectedPrerelease: SemVer{Major: 1, Minor: 3, Patch: 0, Prerelease: []string{"rc", "2"}},
+		},
+		{
+			name:               "SameLabelWithoutNumber",
+			semver:             SemVer{Major: 1, Minor: 3, Patch: 0, Prerelease: []string{"rc"}},
+			version:            Minor,
+			label:              "rc",
+			expectedPrerelease: SemVer{Major: 1, Minor: 3, Patch: 0, Prerelease: []string{"rc", "1"}},
+		},
+		{
+			name:               "NextLabel",
+			semver:             SemVer{Major: 1, Minor: 3, Patch: 0, Prerelease: []string{"beta", "4"}},
+			version:            Patch,
+			label:              "rc",
+			expectedPrerelease: SemVer{Major: 1, Minor: 3, Patch: 0, Prerelease: []string{"rc", "1"}},
+		},
+		{
+			name:          "PreviousLabel",
+			semver:        SemVer{Major: 1, Minor: 3, Patch: 0, Prerelease: []string{"rc", "2"}},
+			version:       Patch,
+			label:         "beta",
+			expectedError: "cannot move from 1.3.0-rc.2 to a beta pre-release",
+		},
+		{
+			name:               "NextMajor",
+			semver:             SemVer{Major: 1, Minor: 3, Patch: 0, Prerelease: []string{"rc", "2"}},
+			version:            Major,
+			label:              "beta",
+			expectedPrerelease: SemVer{Major: 2, Minor: 0, Patch: 0, Prerelease: []string{"beta", "1"}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			prerelease, err := tc.semver.NextPrerelease(tc.version, tc.label)
+
+			if tc.expectedError != "" {
+				assert.EqualError(t, err, tc.expectedError)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedPrerelease, prerelease)
+				assert.True(t, prerelease.IsPrerelease())
+			}
+		})
+	}
+}
+
 func TestString(t *testing.T) {
 	tests := []struct {
 		name            string
@@ -480,3 +461,166 @@ func TestString(t *testing.T) {
 		})
 	}
 }
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name            string
+		v, u            SemVer
+		expectedCompare int
+	}{
+		{
+			name:            "Equal",
+			v:               SemVer{Major: 1, Minor: 2, Patch: 3},
+			u:               SemVer{Major: 1, Minor: 2, Patch: 3},
+			expectedCompare: 0,
+		},
+		{
+			name:            "MajorLower",
+			v:               SemVer{Major: 1, Minor: 9, Patch: 9},
+			u:               SemVer{Major: 2, Minor: 0, Patch: 0},
+			expectedCompare: -1,
+		},
+		{
+			name:            "MinorHigher",
+			v:               SemVer{Major: 2, Minor: 10, Patch: 0},
+			u:               SemVer{Major: 2, Minor: 9, Patch: 0},
+			expectedCompare: 1,
+		},
+		{
+			name:            "PatchLower",
+			v:               SemVer{Major: 2, Minor: 1, Patch: 0},
+			u:               SemVer{Major: 2, Minor: 1, Patch: 1},
+			expectedCompare: -1,
+		},
+		{
+			name:            "PrereleaseLowerThanRelease",
+			v:               SemVer{Major: 1, Minor: 0, Patch: 0, Prerelease: []string{"alpha"}},
+			u:               SemVer{Major: 1, Minor: 0, Patch: 0},
+			expectedCompare: -1,
+		},
+		{
+			name:            "ReleaseHigherThanPrerelease",
+			v:               SemVer{Major: 1, Minor: 0, Patch: 0},
+			u:               SemVer{Major: 1, Minor: 0, Patch: 0, Prerelease: []string{"rc", "1"}},
+			expectedCompare: 1,
+		},
+		{
+			name:            "NumericIdentifiers",
+			v:               SemVer{Major: 1, Minor: 0, Patch: 0, Prerelease: []string{"beta", "2"}},
+			u:               SemVer{Major: 1, Minor: 0, Patch: 0, Prerelease: []string{"beta", "11"}},
+			expectedCompare: -1,
+		},
+		{
+			name:            "LargeNumericIdentifiers",
+			v:               SemVer{Major: 1, Minor: 0, Patch: 0, Prerelease: []string{"99999999999999999999999"}},
+			u:               SemVer{Major: 1, Minor: 0, Patch: 0, Prerelease: []string{"100000000000000000000000"}},
+			expectedCompare: -1,
+		},
+		{
+			name:            "AlphanumericIdentifiers",
+			v:               SemVer{Major: 1, Minor: 0, Patch: 0, Prerelease: []string{"beta"}},
+			u:               SemVer{Major: 1, Minor: 0, Patch: 0, Prerelease: []string{"alpha", "beta"}},
+			expectedCompare: 1,
+		},
+		{
+			name:            "NumericLowerThanAlphanumeric",
+			v:               SemVer{Major: 1, Minor: 0, Patch: 0, Prerelease: []string{"alpha", "1"}},
+			u:               SemVer{Major: 1, Minor: 0, Patch: 0, Prerelease: []string{"alpha", "beta"}},
+			expectedCompare: -1,
+		},
+		{
+			name:            "LargerSetOfIdentifiers",
+			v:               SemVer{Major: 1, Minor: 0, Patch: 0, Prerelease: []string{"alpha", "1"}},
+			u:               SemVer{Major: 1, Minor: 0, Patch: 0, Prerelease: []string{"alpha"}},
+			expectedCompare: 1,
+		},
+		{
+			name:            "MetadataIgnored",
+			v:               SemVer{Major: 1, Minor: 0, Patch: 0, Prerelease: []string{"rc", "1"}, Metadata: []string{"20200820"}},
+			u:               SemVer{Major: 1, Minor: 0, Patch: 0, Prerelease: []string{"rc", "1"}, Metadata: []string{"sha", "abcdeff"}},
+			expectedCompare: 0,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedCompare, tc.v.Compare(tc.u))
+			assert.Equal(t, -tc.expectedCompare, tc.u.Compare(tc.v))
+			assert.Equal(t, tc.expectedCompare < 0, tc.v.LessThan(tc.u))
+			assert.Equal(t, tc.expectedCompare > 0, tc.v.GreaterThan(tc.u))
+			assert.Equal(t, tc.expectedCompare == 0, tc.v.Equal(tc.u))
+		})
+	}
+}
+
+func TestCollection(t *testing.T) {
+	tests := []struct {
+		name               string
+		collection         Collection
+		expectedCollection Collection
+		expectedMax        SemVer
+		expectedOK         bool
+	}{
+		{
+			name:               "Empty",
+			collection:         Collection{},
+			expectedCollection: Collection{},
+			expectedMax:        SemVer{},
+			expectedOK:         false,
+		},
+		{
+			// Example from https://semver.org/#spec-item-11
+			name: "Precedence",
+			collection: Collection{
+				{Major: 1, Minor: 0, Patch: 0},
+				{Major: 1, Minor: 0, Patch: 0, Prerelease: []string{"rc", "1"}},
+				{Major: 1, Minor: 0, Patch: 0, Prerelease: []string{"beta", "11"}},
+				{Major: 1, Minor: 0, Patch: 0, Prerelease: []string{"alpha", "beta"}},
+				{Major: 1, Minor: 0, Patch: 0, Prerelease: []string{"beta", "2"}},
+				{Major: 1, Minor: 0, Patch: 0, Prerelease: []string{"alpha", "1"}},
+				{Major: 1, Minor: 0, Patch: 0, Prerelease: []string{"beta"}},
+				{Major: 1, Minor: 0, Patch: 0, Prerelease: []string{"alpha"}},
+			},
+			expectedCollection: Collection{
+				{Major: 1, Minor: 0, Patch: 0, Prerelease: []string{"alpha"}},
+				{Major: 1, Minor: 0, Patch: 0, Prerelease: []string{"alpha", "1"}},
+				{Major: 1, Minor: 0, Patch: 0, Prerelease: []string{"alpha", "beta"}},
+				{Major: 1, Minor: 0, Patch: 0, Prerelease: []string{"beta"}},
+				{Major: 1, Minor: 0, Patch: 0, Prerelease: []string{"beta", "2"}},
+				{Major: 1, Minor: 0, Patch: 0, Prerelease: []string{"beta", "11"}},
+				{Major: 1, Minor: 0, Patch: 0, Prerelease: []string{"rc", "1"}},
+				{Major: 1, Minor: 0, Patch: 0},
+			},
+			expectedMax: SemVer{Major: 1, Minor: 0, Patch: 0},
+			expectedOK:  true,
+		},
+		{
+			name: "Releases",
+			collection: Collection{
+				{Major: 0, Minor: 10, Patch: 0},
+				{Major: 1, Minor: 2, Patch: 0},
+				{Major: 0, Minor: 2, Patch: 7},
+				{Major: 0, Minor: 9, Patch: 12},
+			},
+			expectedCollection: Collection{
+				{Major: 0, Minor: 2, Patch: 7},
+				{Major: 0, Minor: 9, Patch: 12},
+				{Major: 0, Minor: 10, Patch: 0},
+				{Major: 1, Minor: 2, Patch: 0},
+			},
+			expectedMax: SemVer{Major: 1, Minor: 2, Patch: 0},
+			expectedOK:  true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			max, ok := tc.collection.Max()
+			assert.Equal(t, tc.expectedMax, max)
+			assert.Equal(t, tc.expectedOK, ok)
+
+			sort.Sort(tc.collection)
+			assert.Equal(t, tc.expectedCollection, tc.collection)
+		})
+	}
+}
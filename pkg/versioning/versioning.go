@@ -0,0 +1,220 @@
+// Package versioning resolves the current semantic version of a git repository from its version tags.
+// The version of a commit that is not tagged is a pre-release of the next patch version
+// with the number of commits since the last tag and the commit hash (i.e. 0.2.8-10.abcdeff after v0.2.7).
+package versioning
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/moorara/cherry/internal/git"
+	"github.com/moorara/cherry/pkg/semver"
+)
+
+var (
+	// ErrNoTag is returned by Repository.Describe when there is no tag reachable from a revision.
+	ErrNoTag = errors.New("no tag found")
+
+	// ErrInvalidTag is returned by Resolve when the last version tag is not a semantic version.
+	ErrInvalidTag = errors.New("invalid version tag")
+)
+
+// DescribeOptions are the options for describing a revision.
+type DescribeOptions struct {
+	// Match only considers the tags matching at least one of these glob patterns (i.e. v[0-9]*).
+	Match []string
+	// Exclude does not consider the tags matching any of these glob patterns (i.e. services/api/*).
+	Exclude []string
+}
+
+// Description is the most recent tag reachable from a revision.
+type Description struct {
+	// Tag is the name of the tag.
+	Tag string
+	// Distance is the number of commits since the tagged commit.
+	Distance int
+	// Commit is the full hash of the commit described.
+	Commit string
+}
+
+// Repository is the read-only queries on a git repository the semantic version is resolved from.
+type Repository interface {
+	// Head returns the full hash of the HEAD commit.
+	Head(ctx context.Context) (string, error)
+	// Branch returns the name of the current branch or HEAD if detached.
+	Branch(ctx context.Context) (string, error)
+	// IsClean determines whether or not the working tree has no staged, unstaged, or untracked changes.
+	IsClean(ctx context.Context) (bool, error)
+	// CommitCount returns the number of commits reachable from a revision.
+	CommitCount(ctx context.Context, rev string) (int, error)
+	// Describe returns the most recent tag reachable from a revision.
+	// If there is no such tag, ErrNoTag is returned.
+	Describe(ctx context.Context, rev string, opts DescribeOptions) (Description, error)
+}
+
+// Open returns a repository for a directory inside a git working tree.
+// The git binary is used if available, otherwise the .git directory is read directly.
+func Open(dir string) (Repository, error) {
+	repo, err := git.Open(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return &gitRepository{repo}, nil
+}
+
+// gitRepository adapts a repository of the internal git package to Repository.
+type gitRepository struct {
+	git.Repository
+}
+
+func (r *gitRepository) Describe(ctx context.Context, rev string, opts DescribeOptions) (Description, error) {
+	desc, err := r.Repository.Describe(ctx, rev, git.DescribeOptions{
+		Match:   opts.Match,
+		Exclude: opts.Exclude,
+	})
+
+	if err == git.ErrNoTag {
+		return Description{}, ErrNoTag
+	} else if err != nil {
+		return Description{}, err
+	}
+
+	return Description{
+		Tag:      desc.Tag,
+		Distance: desc.Distance,
+		Commit:   desc.Commit,
+	}, nil
+}
+
+// Options are the options for resolving the semantic version.
+type Options struct {
+	// TagPrefix is the prefix of the version tags (i.e. services/api/ for services/api/v1.2.3).
+	// Only the tags with this prefix are considered and the prefix is removed before parsing them.
+	TagPrefix string
+	// Match only considers the tags matching at least one of these glob patterns after TagPrefix (i.e. v1.4.*).
+	// If not set, the tags matching v[0-9]* or [0-9]* after TagPrefix are considered, so other tags (i.e. latest) are ignored.
+	Match []string
+	// Exclude does not consider the tags matching any of these glob patterns (i.e. services/api/*).
+	Exclude []string
+}
+
+func (o Options) describeOptions() DescribeOptions {
+	match := o.Match
+	if len(match) == 0 {
+		match = []string{"v[0-9]*", "[0-9]*"}
+	}
+
+	var opts DescribeOptions
+	for _, pattern := range match {
+		opts.Match = append(opts.Match, o.TagPrefix+pattern)
+	}
+	opts.Exclude = o.Exclude
+
+	return opts
+}
+
+// Info is the git information the semantic version is resolved from.
+type Info struct {
+	// Commit is the full hash of the HEAD commit.
+	Commit string
+	// Branch is the name of the current branch or HEAD if detached.
+	Branch string
+	// Dirty is true if the working tree has staged, unstaged, or untracked changes.
+	Dirty bool
+	// Tag is the most recent version tag reachable from the HEAD commit or empty if there is none.
+	Tag string
+	// Distance is the number of commits since Tag or the number of all commits if there is no tag.
+	Distance int
+}
+
+// ShortCommit returns the abbreviated hash of the HEAD commit.
+func (i Info) ShortCommit() string {
+	if len(i.Commit) < 7 {
+		return i.Commit
+	}
+
+	return i.Commit[:7]
+}
+
+// Resolve returns the current semantic version of a repository.
+//
+//   - If HEAD is tagged and the working tree is clean, the version is the tag (i.e. 0.2.7 for v0.2.7).
+//   - If HEAD is tagged and the working tree is dirty, the version is a pre-release of the next version (i.e. 0.2.8-0.dev).
+//   - If HEAD is not tagged, the version is a pre-release of the next version with the number of commits since the tag and
+//     the commit hash or dev if the working tree is dirty (i.e. 0.2.8-10.abcdeff or 0.2.8-10.dev).
+//   - A pre-release tag is kept, so the version is still lower than the final release (i.e. 0.3.0-rc.1.10.abcdeff).
+//   - If there is no tag, the version is a pre-release of 0.1.0 with the number of all commits (i.e. 0.1.0-12.abcdeff).
+func Resolve(ctx context.Context, repo Repository, opts Options) (semver.SemVer, Info, error) {
+	var info Info
+	var err error
+
+	if info.Commit, err = repo.Head(ctx); err != nil {
+		return semver.SemVer{}, Info{}, err
+	}
+
+	if info.Branch, err = repo.Branch(ctx); err != nil {
+		return semver.SemVer{}, Info{}, err
+	}
+
+	clean, err := repo.IsClean(ctx)
+	if err != nil {
+		return semver.SemVer{}, Info{}, err
+	}
+	info.Dirty = !clean
+
+	desc, err := repo.Describe(ctx, "HEAD", opts.describeOptions())
+	if err != nil && err != ErrNoTag {
+		return semver.SemVer{}, Info{}, err
+	}
+
+	// The commit hash is not included for a dirty working tree since the changes are not committed yet
+	build := info.ShortCommit()
+	if info.Dirty {
+		build = "dev"
+	}
+
+	if err == ErrNoTag {
+		// No git tag and no previous semantic version -> using the default initial semantic version
+		if info.Distance, err = repo.CommitCount(ctx, "HEAD"); err != nil {
+			return semver.SemVer{}, Info{}, err
+		}
+
+		v := semver.SemVer{Major: 0, Minor: 1, Patch: 0}
+		v.AddPrerelease(strconv.Itoa(info.Distance), build)
+
+		return v, info, nil
+	}
+
+	info.Tag = desc.Tag
+	info.Distance = desc.Distance
+
+	v, err := semver.Parse(strings.TrimPrefix(desc.Tag, opts.TagPrefix))
+	if err != nil {
+		return semver.SemVer{}, Info{}, fmt.Errorf("%w %s: %s", ErrInvalidTag, desc.Tag, err)
+	}
+
+	switch {
+	case info.Distance > 0:
+		// The tag is the most recent tag reachable from the HEAD commit
+		// Example: v0.2.7 and 10 commits --> 0.2.8-10.abcdeff
+		// Example: v0.3.0-rc.1 and 10 commits --> 0.3.0-rc.1.10.abcdeff
+		if !v.IsPrerelease() {
+			v = v.Next()
+		}
+		v.AddPrerelease(strconv.Itoa(info.Distance), build)
+
+	case info.Dirty:
+		// The tag points to the HEAD commit, but there are uncommitted changes
+		// Example: v0.2.7 --> 0.2.8-0.dev
+		if !v.IsPrerelease() {
+			v = v.Next()
+		}
+		v.AddPrerelease("0", "dev")
+	}
+
+	return v, info, nil
+}
@@ -0,0 +1,208 @@
+package versioning
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/moorara/cherry/internal/git"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		name            string
+		repo            func() (*git.MemoryRepository, string)
+		opts            Options
+		expectedVersion string
+		expectedInfo    func(head string) Info
+		expectedError   error
+	}{
+		{
+			name: "NoTag",
+			repo: func() (*git.MemoryRepository, string) {
+				r := git.NewMemoryRepository("main")
+				r.Commit("Initial commit")
+				return r, r.Commit("Add feature")
+			},
+			expectedVersion: "0.1.0-2.%s",
+			expectedInfo: func(head string) Info {
+				return Info{Commit: head, Branch: "main", Distance: 2}
+			},
+		},
+		{
+			name: "NoTagDirty",
+			repo: func() (*git.MemoryRepository, string) {
+				r := git.NewMemoryRepository("main")
+				head := r.Commit("Initial commit")
+				r.SetDirty(true)
+				return r, head
+			},
+			expectedVersion: "0.1.0-1.dev",
+			expectedInfo: func(head string) Info {
+				return Info{Commit: head, Branch: "main", Dirty: true, Distance: 1}
+			},
+		},
+		{
+			name: "Tagged",
+			repo: func() (*git.MemoryRepository, string) {
+				r := git.NewMemoryRepository("main")
+				head := r.Commit("Initial commit")
+				r.Tag("v0.2.7")
+				return r, head
+			},
+			expectedVersion: "0.2.7",
+			expectedInfo: func(head string) Info {
+				return Info{Commit: head, Branch: "main", Tag: "v0.2.7"}
+			},
+		},
+		{
+			name: "TaggedDirty",
+			repo: func() (*git.MemoryRepository, string) {
+				r := git.NewMemoryRepository("main")
+				head := r.Commit("Initial commit")
+				r.Tag("v0.2.7")
+				r.SetDirty(true)
+				return r, head
+			},
+			expectedVersion: "0.2.8-0.dev",
+			expectedInfo: func(head string) Info {
+				return Info{Commit: head, Branch: "main", Dirty: true, Tag: "v0.2.7"}
+			},
+		},
+		{
+			name: "CommitsSinceTag",
+			repo: func() (*git.MemoryRepository, string) {
+				r := git.NewMemoryRepository("main")
+				r.Commit("Initial commit")
+				r.Tag("v0.2.7")
+				r.Commit("Add feature")
+				return r, r.Commit("Fix bug")
+			},
+			expectedVersion: "0.2.8-2.%s",
+			expectedInfo: func(head string) Info {
+				return Info{Commit: head, Branch: "main", Tag: "v0.2.7", Distance: 2}
+			},
+		},
+		{
+			name: "CommitsSincePrereleaseTag",
+			repo: func() (*git.MemoryRepository, string) {
+				r := git.NewMemoryRepository("main")
+				r.Commit("Initial commit")
+				r.Tag("v0.3.0-rc.1")
+				head := r.Commit("Fix bug")
+				r.SetDirty(true)
+				return r, head
+			},
+			expectedVersion: "0.3.0-rc.1.1.dev",
+			expectedInfo: func(head string) Info {
+				return Info{Commit: head, Branch: "main", Dirty: true, Tag: "v0.3.0-rc.1", Distance: 1}
+			},
+		},
+		{
+			name: "Project",
+			repo: func() (*git.MemoryRepository, string) {
+				r := git.NewMemoryRepository("main")
+				r.Commit("Initial commit")
+				r.Tag("services/api/v1.2.3")
+				head := r.Commit("Add feature")
+				r.Tag("v0.5.0")
+				return r, head
+			},
+			opts:            Options{TagPrefix: "services/api/"},
+			expectedVersion: "1.2.4-1.%s",
+			expectedInfo: func(head string) Info {
+				return Info{Commit: head, Branch: "main", Tag: "services/api/v1.2.3", Distance: 1}
+			},
+		},
+		{
+			name: "ExcludeProjects",
+			repo: func() (*git.MemoryRepository, string) {
+				r := git.NewMemoryRepository("main")
+				r.Commit("Initial commit")
+				r.Tag("v0.5.0")
+				head := r.Commit("Add feature")
+				r.Tag("services/api/v1.2.3")
+				return r, head
+			},
+			opts:            Options{Exclude: []string{"services/api/*"}},
+			expectedVersion: "0.5.1-1.%s",
+			expectedInfo: func(head string) Info {
+				return Info{Commit: head, Branch: "main", Tag: "v0.5.0", Distance: 1}
+			},
+		},
+		{
+			name: "ReleaseLine",
+			repo: func() (*git.MemoryRepository, string) {
+				r := git.NewMemoryRepository("release/1.4")
+				r.Commit("Initial commit")
+				r.Tag("v1.4.6")
+				r.Commit("Add feature")
+				r.Tag("v1.5.0")
+				return r, r.Commit("Fix bug")
+			},
+			opts:            Options{Match: []string{"v1.4.*"}},
+			expectedVersion: "1.4.7-2.%s",
+			expectedInfo: func(head string) Info {
+				return Info{Commit: head, Branch: "release/1.4", Tag: "v1.4.6", Distance: 2}
+			},
+		},
+		{
+			name: "ProjectReleaseLine",
+			repo: func() (*git.MemoryRepository, string) {
+				r := git.NewMemoryRepository("release/1.4")
+				r.Commit("Initial commit")
+				r.Tag("services/api/1.4.6")
+				r.Commit("Add feature")
+				r.Tag("v1.4.9")
+				return r, r.Commit("Fix bug")
+			},
+			opts:            Options{TagPrefix: "services/api/", Match: []string{"v1.4.*", "1.4.*"}},
+			expectedVersion: "1.4.7-2.%s",
+			expectedInfo: func(head string) Info {
+				return Info{Commit: head, Branch: "release/1.4", Tag: "services/api/1.4.6", Distance: 2}
+			},
+		},
+		{
+			name: "NonVersionTag",
+			repo: func() (*git.MemoryRepository, string) {
+				r := git.NewMemoryRepository("main")
+				r.Commit("Initial commit")
+				r.Tag("0.4.0")
+				head := r.Commit("Add feature")
+				r.Tag("latest")
+				return r, head
+			},
+			expectedVersion: "0.4.1-1.%s",
+			expectedInfo: func(head string) Info {
+				return Info{Commit: head, Branch: "main", Tag: "0.4.0", Distance: 1}
+			},
+		},
+		{
+			name: "InvalidTag",
+			repo: func() (*git.MemoryRepository, string) {
+				r := git.NewMemoryRepository("main")
+				head := r.Commit("Initial commit")
+				r.Tag("v1")
+				return r, head
+			},
+			expectedError: ErrInvalidTag,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			repo, head := tc.repo()
+			version, info, err := Resolve(context.Background(), &gitRepository{repo}, tc.opts)
+
+			if tc.expectedError != nil {
+				assert.True(t, errors.Is(err, tc.expectedError))
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedInfo(head), info)
+				assert.Equal(t, strings.Replace(tc.expectedVersion, "%s", info.ShortCommit(), 1), version.String())
+			}
+		})
+	}
+}
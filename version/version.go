@@ -29,6 +29,9 @@ var (
 
 	// BuildTime is the time binary built
 	BuildTime string
+
+	// PublicKey is the minisign public key the release artifacts are signed for
+	PublicKey string
 )
 
 // String returns a string describing the version information in details